@@ -1,14 +1,18 @@
 package main
 
 import (
+	"crypto/sha1"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/gubarz/cheatmd/internal/config"
 	"github.com/gubarz/cheatmd/internal/executor"
+	"github.com/gubarz/cheatmd/internal/history"
 	"github.com/gubarz/cheatmd/internal/parser"
 	"github.com/gubarz/cheatmd/internal/ui"
 	"github.com/spf13/cobra"
@@ -31,6 +35,33 @@ Then press Ctrl+G to trigger the cheatmd selector.`,
 	RunE:      runWidget,
 }
 
+var themesCmd = &cobra.Command{
+	Use:   "themes",
+	Short: "Pick a color theme interactively",
+	Long: `Opens a TUI that live-previews each available theme - built-in
+(dracula, gruvbox, solarized-dark, tokyonight) and any user-defined theme
+under ~/.config/cheatmd/themes/*.toml - against the list and preview panes,
+and saves the highlighted theme to your config on Enter.`,
+	RunE: runThemes,
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Inspect or clear the cheat selection / variable-value history",
+}
+
+var historyShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print each cheat's selection count and last-used time",
+	RunE:  runHistoryShow,
+}
+
+var historyClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Delete all recorded selection and variable-value history",
+	RunE:  runHistoryClear,
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "cheatmd [path]",
 	Short: "Executable Markdown Cheatsheets",
@@ -46,6 +77,9 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	rootCmd.AddCommand(widgetCmd)
+	rootCmd.AddCommand(themesCmd)
+	historyCmd.AddCommand(historyShowCmd, historyClearCmd)
+	rootCmd.AddCommand(historyCmd)
 
 	rootCmd.PersistentFlags().StringP("output", "o", "", "Output mode: print, copy, exec")
 	rootCmd.PersistentFlags().StringP("query", "q", "", "Initial search query")
@@ -55,6 +89,20 @@ func init() {
 	rootCmd.PersistentFlags().Bool("exec", false, "Execute command (shorthand for -o exec)")
 	rootCmd.PersistentFlags().Bool("auto", false, "Auto-select if query matches exactly one result")
 	rootCmd.PersistentFlags().BoolP("benchmark", "b", false, "Benchmark load time and exit")
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Print diagnostic info, e.g. which config file was loaded")
+	rootCmd.PersistentFlags().Bool("no-cache", false, "Disable the on-disk parse cache and re-parse every file")
+	rootCmd.PersistentFlags().Bool("strict", false, "Fail on any parse diagnostic instead of best-effort loading")
+	rootCmd.PersistentFlags().String("force-color", "", "Override color-capability detection: truecolor, 256, 16, or none")
+	rootCmd.PersistentFlags().Bool("exact", false, "Match literal substrings instead of fuzzy matching")
+	rootCmd.PersistentFlags().Bool("literal", false, "Don't fold diacritics when matching, e.g. require \"café\" instead of matching it via \"cafe\"")
+	rootCmd.PersistentFlags().String("height", "", "Render inline below the cursor at a fixed row budget instead of fullscreen, e.g. 40% or 20 (mirrors fzf --height)")
+	rootCmd.PersistentFlags().Bool("reverse", false, "With --height, put the input above the list instead of below")
+	rootCmd.PersistentFlags().String("multi", "", "Allow marking multiple cheats with Tab before resolving/running them; optionally caps the count, e.g. --multi=5 (mirrors fzf --multi)")
+	rootCmd.PersistentFlags().Lookup("multi").NoOptDefVal = "0"
+	rootCmd.PersistentFlags().String("multi-join", "", "How to join marked cheats' commands: and, pipe, or heredoc (default: and)")
+	rootCmd.PersistentFlags().Bool("watch", false, "Re-parse the cheat directory in the background on file change, same as pressing ctrl+r")
+	rootCmd.PersistentFlags().String("preview", "", "Command to render the cheat-select preview panel, e.g. 'bat --language=bash --color=always {cmd}'")
+	rootCmd.PersistentFlags().String("preview-window", "", "Preview panel position/size: up, down[:N], or right[:N%] (mirrors fzf --preview-window)")
 
 	viper.BindPFlag("output", rootCmd.PersistentFlags().Lookup("output"))
 }
@@ -63,6 +111,65 @@ func initConfig() {
 	if err := config.Init(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 	}
+
+	if forceColor, _ := rootCmd.PersistentFlags().GetString("force-color"); forceColor != "" {
+		if err := ui.SetForcedColorProfile(forceColor); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+	}
+
+	if exact, _ := rootCmd.PersistentFlags().GetBool("exact"); exact {
+		config.SetMatchAlgorithm("exact")
+	}
+
+	if literal, _ := rootCmd.PersistentFlags().GetBool("literal"); literal {
+		config.SetLiteralMatch(true)
+	}
+
+	if verbose, _ := rootCmd.PersistentFlags().GetBool("verbose"); verbose {
+		if path := config.ConfigFileUsed(); path != "" {
+			fmt.Fprintf(os.Stderr, "Using config file: %s\n", path)
+		} else {
+			fmt.Fprintln(os.Stderr, "No config file found")
+		}
+	}
+}
+
+func runThemes(cmd *cobra.Command, args []string) error {
+	return ui.RunThemePicker()
+}
+
+func runHistoryShow(cmd *cobra.Command, args []string) error {
+	h := history.Load(config.GetHistoryPath(), config.GetHistoryMaxEntries())
+	if len(h.Cheats) == 0 {
+		fmt.Println("No history recorded yet")
+		return nil
+	}
+
+	type row struct {
+		key   string
+		entry history.Entry
+	}
+	rows := make([]row, 0, len(h.Cheats))
+	for key, entry := range h.Cheats {
+		rows = append(rows, row{key, entry})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].entry.Last.After(rows[j].entry.Last) })
+
+	for _, r := range rows {
+		fmt.Printf("%s  count=%-4d last=%s\n", r.key, r.entry.Count, r.entry.Last.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func runHistoryClear(cmd *cobra.Command, args []string) error {
+	h := history.Load(config.GetHistoryPath(), config.GetHistoryMaxEntries())
+	h.Clear()
+	if err := h.Save(); err != nil {
+		return fmt.Errorf("error clearing history: %w", err)
+	}
+	fmt.Println("History cleared")
+	return nil
 }
 
 func runWidget(cmd *cobra.Command, args []string) error {
@@ -180,6 +287,32 @@ func runCheats(cmd *cobra.Command, args []string) error {
 		config.SetAutoSelect(true)
 	}
 
+	if height, _ := cmd.Flags().GetString("height"); height != "" {
+		config.SetHeight(height)
+	}
+	if reverse, _ := cmd.Flags().GetBool("reverse"); reverse {
+		config.SetReverse(true)
+	}
+	if cmd.Flags().Changed("multi") {
+		config.SetMulti(true)
+		multi, _ := cmd.Flags().GetString("multi")
+		if n, err := strconv.Atoi(multi); err == nil && n > 0 {
+			config.SetMultiMax(n)
+		}
+	}
+	if multiJoin, _ := cmd.Flags().GetString("multi-join"); multiJoin != "" {
+		config.SetMultiJoin(multiJoin)
+	}
+	if watch, _ := cmd.Flags().GetBool("watch"); watch {
+		config.SetWatch(true)
+	}
+	if preview, _ := cmd.Flags().GetString("preview"); preview != "" {
+		config.SetPreviewCmd(preview)
+	}
+	if previewWindow, _ := cmd.Flags().GetString("preview-window"); previewWindow != "" {
+		config.SetPreviewWindow(previewWindow)
+	}
+
 	query, _ := cmd.Flags().GetString("query")
 	match, _ := cmd.Flags().GetString("match")
 
@@ -198,10 +331,20 @@ func runCheats(cmd *cobra.Command, args []string) error {
 	benchmark, _ := cmd.Flags().GetBool("benchmark")
 	start := time.Now()
 
-	p := parser.NewParser()
+	var p *parser.Parser
+	noCache, _ := cmd.Flags().GetBool("no-cache")
+	if noCache {
+		p = parser.NewParser()
+	} else {
+		p = parser.NewParserWithCache(cacheFileFor(absPath))
+	}
+	if strict, _ := cmd.Flags().GetBool("strict"); strict {
+		p.SetStrict(true)
+	}
 	var index *parser.CheatIndex
 
-	if info.IsDir() {
+	isDir := info.IsDir()
+	if isDir {
 		index, err = p.ParseDirectory(absPath)
 	} else {
 		index, err = p.ParseSingleFile(absPath)
@@ -211,6 +354,16 @@ func runCheats(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("parse error: %w", err)
 	}
 
+	// reload re-parses the same root with the same parser (reusing its
+	// on-disk cache unless --no-cache), for the picker's "reload" action
+	// (default ctrl+r) and --watch's background fsnotify trigger.
+	reload := func() (*parser.CheatIndex, error) {
+		if isDir {
+			return p.ParseDirectory(absPath)
+		}
+		return p.ParseSingleFile(absPath)
+	}
+
 	// Check for duplicate exports
 	if len(index.Duplicates) > 0 {
 		fmt.Fprintln(os.Stderr, "Warning: duplicate exports found:")
@@ -220,6 +373,12 @@ func runCheats(cmd *cobra.Command, args []string) error {
 		fmt.Fprintln(os.Stderr)
 	}
 
+	if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
+		for _, d := range index.Diagnostics {
+			fmt.Fprintf(os.Stderr, "%s:%d: %s: %s [%s]\n", d.File, d.Line, d.Severity, d.Message, d.Code)
+		}
+	}
+
 	if len(index.Cheats) == 0 {
 		return fmt.Errorf("no cheats found in %s", absPath)
 	}
@@ -240,7 +399,19 @@ func runCheats(cmd *cobra.Command, args []string) error {
 	}
 
 	// Run the TUI
-	return ui.Run(index, exec, query, match)
+	watchDir := ""
+	if config.GetWatch() {
+		watchDir = absPath
+	}
+	return ui.Run(index, exec, query, match, reload, watchDir)
+}
+
+// cacheFileFor returns the parse-cache path for a given cheat directory,
+// under config.GetCacheDir(). The directory's own path is hashed into the
+// filename so multiple cheat trees don't collide on the same cache file.
+func cacheFileFor(absPath string) string {
+	sum := sha1.Sum([]byte(absPath))
+	return filepath.Join(config.GetCacheDir(), fmt.Sprintf("%x.cache", sum))
 }
 
 func main() {
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -14,19 +16,47 @@ import (
 
 // Config holds the application configuration
 type Config struct {
-	Path              string `mapstructure:"path"`
-	Output            string `mapstructure:"output"`
-	Shell             string `mapstructure:"shell"`
-	PreHook           string `mapstructure:"pre_hook"`
-	PostHook          string `mapstructure:"post_hook"`
-	RequireCheatBlock bool   `mapstructure:"require_cheat_block"`
-	AutoSelect        bool   `mapstructure:"auto_select"`
+	Path              string            `mapstructure:"path"`
+	Output            string            `mapstructure:"output"`
+	Shell             string            `mapstructure:"shell"`
+	PreHook           string            `mapstructure:"pre_hook"`
+	PostHook          string            `mapstructure:"post_hook"`
+	RequireCheatBlock bool              `mapstructure:"require_cheat_block"`
+	AutoSelect        bool              `mapstructure:"auto_select"`
+	AutoContinue      bool              `mapstructure:"auto_continue"`
+	Editor            string            `mapstructure:"editor"`
+	Selector          string            `mapstructure:"selector"`
+	Clipboard         string            `mapstructure:"clipboard"`
+	MatchAlgorithm    string            `mapstructure:"match_algorithm"`
+	PreviewWidth      int               `mapstructure:"preview_width"`
+	PreviewCmd        string            `mapstructure:"preview_cmd"`
+	PreviewWindow     string            `mapstructure:"preview_window"`
+	Keybindings       map[string]string `mapstructure:"keybindings"`
+	Theme             string            `mapstructure:"theme"`
+	Height            string            `mapstructure:"height"`
+	Reverse           bool              `mapstructure:"reverse"`
+	LiteralMatch      bool              `mapstructure:"literal_match"`
+	Multi             bool              `mapstructure:"multi"`
+	MultiMax          int               `mapstructure:"multi_max"`
+	MultiJoin         string            `mapstructure:"multi_join"`
+	Watch             bool              `mapstructure:"watch"`
+	HistoryEnabled    bool              `mapstructure:"history_enabled"`
+	HistoryPath       string            `mapstructure:"history_path"`
+	HistoryMaxEntries int               `mapstructure:"history_max_entries"`
 
 	// Colors
 	Colors ColorConfig
 
 	// Columns
 	Columns ColumnConfig
+
+	// Hooks
+	Hooks HooksConfig
+}
+
+// HooksConfig holds pre_hook/post_hook execution settings
+type HooksConfig struct {
+	IgnorePreFailure bool `mapstructure:"ignore_pre_failure"`
 }
 
 // ColorConfig holds all color settings
@@ -62,8 +92,28 @@ var defaults = struct {
 	postHook          string
 	requireCheatBlock bool
 	autoSelect        bool
+	autoContinue      bool
+	editor            string
+	selector          string
+	clipboard         string
+	matchAlgorithm    string
+	previewWidth      int
+	previewCmd        string
+	previewWindow     string
+	theme             string
+	height            string
+	reverse           bool
+	literalMatch      bool
+	multi             bool
+	multiMax          int
+	multiJoin         string
+	watch             bool
+	historyEnabled    bool
+	historyPath       string
+	historyMaxEntries int
 	colors            ColorConfig
 	columns           ColumnConfig
+	hooks             HooksConfig
 }{
 	path:              ".",
 	output:            "print",
@@ -72,6 +122,25 @@ var defaults = struct {
 	postHook:          "",
 	requireCheatBlock: false,
 	autoSelect:        false,
+	autoContinue:      false, // true = skip a var's prompt when history/env prefilled it, instead of just preselecting it
+	editor:            "",    // "" = $EDITOR, falling back to the OS default opener
+	selector:          "",    // "" = built-in picker; or "fzf", "sk", "gum"
+	clipboard:         "auto",
+	matchAlgorithm:    "fuzzy-v2", // "substring", "exact", "fuzzy-v1", or "fuzzy-v2"
+	previewWidth:      50,         // preview pane's share of the split width, as a percentage
+	previewCmd:        "",         // "" = render the cheat/option itself instead of shelling out
+	previewWindow:     "up",       // cheat-select preview panel position: "up", "down[:N]", or "right[:N%]"
+	theme:             "",         // "" = use the flat color_* keys below ("custom" theme)
+	height:            "",         // "" = fullscreen/alt-screen; else fzf-style "40%" or "20" rows, inline
+	reverse:           false,      // inline height mode only: input above the list instead of below
+	literalMatch:      false,      // false = fold diacritics (e.g. "cafe" matches "café") before matching
+	multi:             false,      // --multi: Tab-toggle marking several cheats before resolving/executing them
+	multiMax:          0,          // --multi=N caps how many cheats can be marked; 0 means unbounded
+	multiJoin:         "and",      // "and" (&&), "pipe" (|), or "heredoc" (one command per line)
+	watch:             false,      // --watch: re-parse the cheat directory in the background on file change
+	historyEnabled:    true,       // float frequently/recently selected cheats to the top and prefill vars from past values
+	historyPath:       "",         // "" = GetHistoryPath's XDG state dir default (~/.local/state/cheatmd/history.json)
+	historyMaxEntries: 500,        // oldest-selected cheats are evicted once history.json holds more than this many
 	colors: ColorConfig{
 		Header:   "36",  // Cyan
 		Command:  "32",  // Green
@@ -88,6 +157,9 @@ var defaults = struct {
 		Desc:    40,
 		Command: 60,
 	},
+	hooks: HooksConfig{
+		IgnorePreFailure: false,
+	},
 }
 
 // ============================================================================
@@ -102,12 +174,6 @@ func Init() error {
 	setDefaults()
 	configureViper()
 
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			fmt.Fprintf(os.Stderr, "Warning: config file error: %v\n", err)
-		}
-	}
-
 	return viper.Unmarshal(&cfg)
 }
 
@@ -117,6 +183,7 @@ func setDefaults() {
 	if shell == "" {
 		shell = "/bin/bash"
 	}
+	editor := os.Getenv("EDITOR")
 
 	viper.SetDefault("path", defaults.path)
 	viper.SetDefault("output", defaults.output)
@@ -125,6 +192,26 @@ func setDefaults() {
 	viper.SetDefault("post_hook", defaults.postHook)
 	viper.SetDefault("require_cheat_block", defaults.requireCheatBlock)
 	viper.SetDefault("auto_select", defaults.autoSelect)
+	viper.SetDefault("auto_continue", defaults.autoContinue)
+	viper.SetDefault("editor", editor)
+	viper.SetDefault("selector", defaults.selector)
+	viper.SetDefault("clipboard", defaults.clipboard)
+	viper.SetDefault("match_algorithm", defaults.matchAlgorithm)
+	viper.SetDefault("preview_width", defaults.previewWidth)
+	viper.SetDefault("preview_cmd", defaults.previewCmd)
+	viper.SetDefault("preview_window", defaults.previewWindow)
+	viper.SetDefault("theme", defaults.theme)
+	viper.SetDefault("height", defaults.height)
+	viper.SetDefault("reverse", defaults.reverse)
+	viper.SetDefault("literal_match", defaults.literalMatch)
+	viper.SetDefault("multi", defaults.multi)
+	viper.SetDefault("multi_max", defaults.multiMax)
+	viper.SetDefault("multi_join", defaults.multiJoin)
+	viper.SetDefault("watch", defaults.watch)
+	viper.SetDefault("history_enabled", defaults.historyEnabled)
+	viper.SetDefault("history_path", defaults.historyPath)
+	viper.SetDefault("history_max_entries", defaults.historyMaxEntries)
+	viper.SetDefault("hooks.ignore_pre_failure", defaults.hooks.IgnorePreFailure)
 
 	// Colors
 	viper.SetDefault("color_header", defaults.colors.Header)
@@ -143,28 +230,239 @@ func setDefaults() {
 	viper.SetDefault("column_command", defaults.columns.Command)
 }
 
-// configureViper sets up viper configuration sources
+// configureViper sets up viper configuration sources: project overlays and
+// the user config are merged (lowest to highest priority), then a
+// ".cheatmd.env" sidecar next to whichever one ended up authoritative is
+// loaded into the environment, and finally CHEATMD_* env vars are bound,
+// outranking everything merged before them.
 func configureViper() {
 	viper.SetConfigName("cheatmd")
 	viper.SetConfigType("yaml")
 
-	if home, err := os.UserHomeDir(); err == nil {
-		viper.AddConfigPath(filepath.Join(home, ".config", "cheatmd"))
-		viper.AddConfigPath(home)
-	}
-	viper.AddConfigPath(".")
+	mergeProjectConfigs()
+	mergeUserConfig()
+
+	loadDotEnv()
 
 	viper.SetEnvPrefix("CHEATMD")
 	viper.AutomaticEnv()
 }
 
+// activeConfigFile is the config file ConfigFileUsed reports and
+// PathRelativeToConfig resolves relative paths against: the
+// highest-priority file actually found and merged by configureViper (the
+// user config, or the nearest project overlay if there's no user config at
+// all). Empty when nothing was found.
+var activeConfigFile string
+
+// mergeProjectConfigs walks from the current directory up to the filesystem
+// root collecting ".cheatmd.yaml" overlays, then merges them outermost
+// ancestor first so a closer-to-CWD overlay takes precedence over a parent
+// directory's (the "repo-root config < project subdir config" ordering).
+func mergeProjectConfigs() {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return
+	}
+
+	var paths []string
+	for dir := cwd; ; {
+		if candidate := filepath.Join(dir, ".cheatmd.yaml"); fileExists(candidate) {
+			paths = append(paths, candidate)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	// paths is currently deepest-first (CWD first); reverse it so the
+	// outermost ancestor merges first and a closer overlay wins.
+	for i, j := 0, len(paths)-1; i < j; i, j = i+1, j-1 {
+		paths[i], paths[j] = paths[j], paths[i]
+	}
+
+	for _, path := range paths {
+		mergeConfigFile(path)
+	}
+}
+
+// mergeUserConfig merges the user-level config - cheatmd/cheatmd.yaml under
+// XDG_CONFIG_HOME (honoring its platform fallback, see xdgConfigHome), or
+// failing that ~/cheatmd.yaml - last, so it overrides any project overlay.
+// CHEATMD_* env vars still outrank it, bound afterward by configureViper.
+func mergeUserConfig() {
+	var candidates []string
+	if configHome := xdgConfigHome(); configHome != "" {
+		candidates = append(candidates, filepath.Join(configHome, "cheatmd", "cheatmd.yaml"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, "cheatmd.yaml"))
+	}
+
+	for _, path := range candidates {
+		if fileExists(path) {
+			mergeConfigFile(path)
+			return
+		}
+	}
+}
+
+// mergeConfigFile merges path into viper's active config, recording it as
+// activeConfigFile on success. The first successful call seeds viper's
+// config via ReadInConfig, since MergeInConfig requires one to already be
+// set; every call after that merges on top, each one taking priority over
+// what came before.
+func mergeConfigFile(path string) {
+	viper.SetConfigFile(path)
+
+	var err error
+	if activeConfigFile == "" {
+		err = viper.ReadInConfig()
+	} else {
+		err = viper.MergeInConfig()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: config file %s: %v\n", path, err)
+		return
+	}
+	activeConfigFile = path
+}
+
+// loadDotEnv loads KEY=VALUE pairs from a ".cheatmd.env" file sitting next
+// to the resolved config file (activeConfigFile, falling back to ".") into
+// the process environment, so CHEATMD_* settings below can live in a
+// sidecar file instead of having to be exported by hand - before
+// AutomaticEnv binds them.
+//
+// This is a minimal, hand-rolled KEY=VALUE reader rather than a dependency
+// on github.com/joho/godotenv. It covers the common
+// "KEY=VALUE", quoted-value, and "# comment" forms godotenv itself handles;
+// multi-line values and "export " prefixes aren't supported. An already-set
+// environment variable is left alone, matching godotenv.Load's behavior.
+func loadDotEnv() {
+	dir := "."
+	if activeConfigFile != "" {
+		dir = filepath.Dir(activeConfigFile)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, ".cheatmd.env"))
+	if err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		if _, set := os.LookupEnv(key); !set {
+			os.Setenv(key, value)
+		}
+	}
+}
+
+// fileExists reports whether path exists and is not a directory.
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// ============================================================================
+// XDG Base Directories
+// ============================================================================
+
+// xdgConfigHome returns the base directory config files live under (callers
+// append their own app subdirectory, e.g. "cheatmd"), honoring
+// XDG_CONFIG_HOME with the usual per-platform fallback: %APPDATA% on
+// Windows (so the effective default becomes %APPDATA%\cheatmd once "cheatmd"
+// is appended), ~/.config elsewhere.
+func xdgConfigHome() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return dir
+	}
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("APPDATA"); dir != "" {
+			return dir
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".config")
+	}
+	return ""
+}
+
+// xdgDataHome returns the base directory data files live under, honoring
+// XDG_DATA_HOME with the usual per-platform fallback: %APPDATA% on Windows,
+// ~/.local/share elsewhere.
+func xdgDataHome() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir
+	}
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("APPDATA"); dir != "" {
+			return dir
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".local", "share")
+	}
+	return ""
+}
+
+// xdgStateHome returns the base directory state files (data that should
+// persist but isn't worth backing up or sharing, e.g. usage history) live
+// under, honoring XDG_STATE_HOME with the usual per-platform fallback:
+// %APPDATA% on Windows, ~/.local/state elsewhere.
+func xdgStateHome() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return dir
+	}
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("APPDATA"); dir != "" {
+			return dir
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".local", "state")
+	}
+	return ""
+}
+
+// xdgCacheHome returns the base directory cache files live under, honoring
+// XDG_CACHE_HOME with the usual per-platform fallback: %LOCALAPPDATA% on
+// Windows, ~/.cache elsewhere.
+func xdgCacheHome() string {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return dir
+	}
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("LOCALAPPDATA"); dir != "" {
+			return dir
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".cache")
+	}
+	return ""
+}
+
 // ============================================================================
 // Getters - Core Settings
 // ============================================================================
 
-// GetPath returns the cheat path with tilde expansion
+// GetPath returns the cheat path, tilde-expanded and, if still relative,
+// resolved against the config file's directory rather than the process's
+// CWD (see PathRelativeToConfig).
 func GetPath() string {
-	return expandTilde(viper.GetString("path"))
+	return PathRelativeToConfig(expandTilde(viper.GetString("path")))
 }
 
 // GetOutput returns the output mode
@@ -187,6 +485,12 @@ func GetPostHook() string {
 	return viper.GetString("post_hook")
 }
 
+// GetCacheDir returns the directory cheatmd's on-disk parse cache lives
+// under (honoring XDG_CACHE_HOME), e.g. for parser.NewParserWithCache.
+func GetCacheDir() string {
+	return filepath.Join(xdgCacheHome(), "cheatmd")
+}
+
 // GetRequireCheatBlock returns whether to require cheat blocks
 func GetRequireCheatBlock() bool {
 	return viper.GetBool("require_cheat_block")
@@ -197,6 +501,183 @@ func GetAutoSelect() bool {
 	return viper.GetBool("auto_select")
 }
 
+// GetAutoContinue returns whether a var with a prefilled value (from history
+// or an env var matching its name) skips its prompt entirely instead of
+// just preselecting the value for the user to confirm or change.
+func GetAutoContinue() bool {
+	return viper.GetBool("auto_continue")
+}
+
+// GetEditor returns the configured editor command, or "" to fall back to
+// the OS's default opener. Read from the CHEATMD_EDITOR/EDITOR env var or
+// the "editor" config key.
+func GetEditor() string {
+	return viper.GetString("editor")
+}
+
+// GetSelector returns the configured selector backend ("", "fzf", "sk", or
+// "gum"). An empty value means "use the built-in picker". Read from the
+// CHEATMD_SELECTOR env var or the "selector" config key.
+func GetSelector() string {
+	return viper.GetString("selector")
+}
+
+// GetHooksIgnorePreFailure returns whether a failing pre_hook should be
+// logged and ignored rather than aborting execution.
+func GetHooksIgnorePreFailure() bool {
+	return viper.GetBool("hooks.ignore_pre_failure")
+}
+
+// GetClipboard returns the configured clipboard mode: "auto" (SSH session
+// prefers OSC 52, tmux session prefers the tmux buffer, otherwise native,
+// falling back through OSC 52 to stdout), "native" (OS clipboard only),
+// "osc52" (always use the terminal escape sequence), "tmux" (always use the
+// tmux buffer), or "stdout" (always print instead of copying). Read from the
+// CHEATMD_CLIPBOARD env var or the "clipboard" config key.
+func GetClipboard() string {
+	return viper.GetString("clipboard")
+}
+
+// GetMatchAlgorithm returns the configured variable-selector match
+// algorithm ("substring", "exact", "fuzzy-v1", or "fuzzy-v2"), used when a
+// cheat's selector args don't set --algorithm themselves. Read from the
+// CHEATMD_MATCH_ALGORITHM env var or the "match_algorithm" config key.
+func GetMatchAlgorithm() string {
+	return viper.GetString("match_algorithm")
+}
+
+// GetPreviewWidth returns the preview pane's share of the split width as a
+// percentage (0-100) of the total, used when a var prompt's preview pane is
+// toggled on. Read from the CHEATMD_PREVIEW_WIDTH env var or the
+// "preview_width" config key.
+func GetPreviewWidth() int {
+	return viper.GetInt("preview_width")
+}
+
+// GetPreviewCmd returns the external command used to render the cheat-select
+// preview panel(s), fzf-style: "{}" substitutes the cursored cheat's file
+// path, and "{folder}", "{file}", "{header}", "{cmd}" substitute its other
+// identifying fields (each shell-quoted) for commands like
+// "bat --language=bash --color=always". "" (the default) falls back to
+// rendering the cheat itself.
+func GetPreviewCmd() string {
+	return viper.GetString("preview_cmd")
+}
+
+// GetPreviewWindow returns the cheat-select preview panel's position and
+// size, fzf --preview-window style: "up" or "down" place it above/below the
+// list at a fixed 6-line height (or ":N" lines, if given); "right[:N%]"
+// places it beside the list, N% of the width (default: config.GetPreviewWidth's
+// percentage). Read from the CHEATMD_PREVIEW_WINDOW env var, the
+// "preview_window" config key, or the --preview-window flag.
+func GetPreviewWindow() string {
+	return viper.GetString("preview_window")
+}
+
+// GetKeybindings returns the user's "keybindings" config overrides, mapping
+// a hyphenated key chord (fzf's spelling, e.g. "ctrl-y") to an action name
+// or "+"-chained action names (e.g. "toggle-select+down"), layered over the
+// TUI's built-in bindings. Empty when unset.
+func GetKeybindings() map[string]string {
+	return viper.GetStringMapString("keybindings")
+}
+
+// GetTheme returns the configured theme name: "" (the default) or "custom"
+// both mean "use the flat color_* keys below", a built-in name
+// (e.g. "dracula") selects a shipped palette, and anything else is looked up
+// as "<name>.toml" under ThemesDir. Read from the CHEATMD_THEME env var or
+// the "theme" config key.
+func GetTheme() string {
+	return viper.GetString("theme")
+}
+
+// GetHeight returns the configured inline-height spec: "" (the default) runs
+// fullscreen in the alt-screen buffer, a percentage like "40%" or a bare row
+// count like "20" instead renders below the cursor within that row budget,
+// fzf --height style, leaving the rest of the scrollback untouched. Read from
+// the CHEATMD_HEIGHT env var, the "height" config key, or the --height flag.
+func GetHeight() string {
+	return viper.GetString("height")
+}
+
+// GetReverse returns whether inline-height mode (GetHeight non-empty) places
+// the input above the list instead of below it. Has no effect in the default
+// fullscreen mode, which always keeps the input at the bottom. Read from the
+// CHEATMD_REVERSE env var, the "reverse" config key, or the --reverse flag.
+func GetReverse() bool {
+	return viper.GetBool("reverse")
+}
+
+// GetLiteralMatch returns whether fuzzy matching should skip diacritic
+// folding (accented and unaccented spellings no longer match each other),
+// matching candidates byte-for-byte (case aside) instead. Read from the
+// CHEATMD_LITERAL_MATCH env var, the "literal_match" config key, or the
+// --literal flag.
+func GetLiteralMatch() bool {
+	return viper.GetBool("literal_match")
+}
+
+// GetMulti returns whether the cheat-select picker allows Tab-toggle
+// marking of several cheats at once (--multi) instead of picking one.
+func GetMulti() bool {
+	return viper.GetBool("multi")
+}
+
+// GetMultiMax returns the maximum number of cheats --multi=N allows to be
+// marked at once; 0 means unbounded.
+func GetMultiMax() int {
+	return viper.GetInt("multi_max")
+}
+
+// GetMultiJoin returns how executor.BuildFinalCommandBatch combines marked
+// cheats' commands: "and" (&&-chained, the default), "pipe" (|-chained), or
+// "heredoc" (one command per line). Read from the CHEATMD_MULTI_JOIN env
+// var, the "multi_join" config key, or the --multi-join flag.
+func GetMultiJoin() string {
+	return viper.GetString("multi_join")
+}
+
+// GetWatch returns whether the picker runs a background filesystem watcher
+// over the cheat directories that triggers the same re-parse as the
+// "reload" action (default ctrl+r) on file change. Read from the
+// CHEATMD_WATCH env var, the "watch" config key, or the --watch flag.
+func GetWatch() bool {
+	return viper.GetBool("watch")
+}
+
+// GetHistoryEnabled returns whether cheat selections and variable values are
+// recorded to the history.History at GetHistoryPath, for frecency-sorted
+// listing and variable prefill. Read from the CHEATMD_HISTORY_ENABLED env
+// var or the "history_enabled" config key.
+func GetHistoryEnabled() bool {
+	return viper.GetBool("history_enabled")
+}
+
+// GetHistoryPath returns the file history.History is loaded from and saved
+// to. "" (the default) resolves to cheatmd/history.json under the XDG state
+// directory (honoring XDG_STATE_HOME). Read from the
+// CHEATMD_HISTORY_PATH env var or the "history_path" config key.
+func GetHistoryPath() string {
+	if path := viper.GetString("history_path"); path != "" {
+		return expandTilde(path)
+	}
+	return filepath.Join(xdgStateHome(), "cheatmd", "history.json")
+}
+
+// GetHistoryMaxEntries returns how many cheats' usage history.History keeps
+// before evicting the least-recently-selected ones. Read from the
+// CHEATMD_HISTORY_MAX_ENTRIES env var or the "history_max_entries" config
+// key.
+func GetHistoryMaxEntries() int {
+	return viper.GetInt("history_max_entries")
+}
+
+// ThemesDir returns the directory user-defined theme files (*.toml) are
+// loaded from, honoring XDG_CONFIG_HOME like the rest of the config search.
+func ThemesDir() string {
+	return filepath.Join(xdgConfigHome(), "cheatmd", "themes")
+}
+
 // ============================================================================
 // Getters - Colors
 // ============================================================================
@@ -311,6 +792,120 @@ func SetAutoSelect(enabled bool) {
 	cfg.AutoSelect = enabled
 }
 
+// SetMatchAlgorithm overrides the match algorithm at runtime, e.g. from the
+// root command's --exact flag forcing "exact" for the whole invocation.
+func SetMatchAlgorithm(algorithm string) {
+	viper.Set("match_algorithm", algorithm)
+	cfg.MatchAlgorithm = algorithm
+}
+
+// SetPreviewCmd overrides the cheat-select preview command at runtime, e.g.
+// from the root command's --preview flag.
+func SetPreviewCmd(previewCmd string) {
+	viper.Set("preview_cmd", previewCmd)
+	cfg.PreviewCmd = previewCmd
+}
+
+// SetPreviewWindow overrides the cheat-select preview panel's position/size
+// at runtime, e.g. from the root command's --preview-window flag.
+func SetPreviewWindow(spec string) {
+	viper.Set("preview_window", spec)
+	cfg.PreviewWindow = spec
+}
+
+// SetHeight overrides the inline-height spec at runtime, e.g. from the root
+// command's --height flag.
+func SetHeight(height string) {
+	viper.Set("height", height)
+	cfg.Height = height
+}
+
+// SetReverse overrides the inline-height layout at runtime, e.g. from the
+// root command's --reverse flag.
+func SetReverse(reverse bool) {
+	viper.Set("reverse", reverse)
+	cfg.Reverse = reverse
+}
+
+// SetLiteralMatch overrides diacritic folding at runtime, e.g. from the root
+// command's --literal flag.
+func SetLiteralMatch(literal bool) {
+	viper.Set("literal_match", literal)
+	cfg.LiteralMatch = literal
+}
+
+// SetMulti overrides --multi mode at runtime.
+func SetMulti(multi bool) {
+	viper.Set("multi", multi)
+	cfg.Multi = multi
+}
+
+// SetMultiMax overrides --multi's N cap at runtime.
+func SetMultiMax(max int) {
+	viper.Set("multi_max", max)
+	cfg.MultiMax = max
+}
+
+// SetMultiJoin overrides --multi-join's join mode at runtime.
+func SetMultiJoin(join string) {
+	viper.Set("multi_join", join)
+	cfg.MultiJoin = join
+}
+
+// SetWatch overrides --watch mode at runtime.
+func SetWatch(watch bool) {
+	viper.Set("watch", watch)
+	cfg.Watch = watch
+}
+
+// SetTheme sets the active theme both in memory and on disk, so it sticks
+// across runs: it writes the full config (all settings viper currently
+// knows about, not just "theme") to ConfigFileUsed, or to the user config
+// path under XDG_CONFIG_HOME if no config file was loaded yet. Used by
+// `cheatmd themes` when the user presses Enter on a theme.
+func SetTheme(name string) error {
+	viper.Set("theme", name)
+	cfg.Theme = name
+
+	path := activeConfigFile
+	if path == "" {
+		dir := xdgConfigHome()
+		if dir == "" {
+			return fmt.Errorf("could not determine a config directory to save the theme to")
+		}
+		path = filepath.Join(dir, "cheatmd", "cheatmd.yaml")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("creating config directory: %w", err)
+		}
+		activeConfigFile = path
+	}
+
+	return viper.WriteConfigAs(path)
+}
+
+// ============================================================================
+// Getters - Meta
+// ============================================================================
+
+// ConfigFileUsed returns the path of the highest-priority config file that
+// was actually found and merged during Init (typically the user config, or
+// the nearest project ".cheatmd.yaml" overlay if there was no user config at
+// all), or "" if none was found.
+func ConfigFileUsed() string {
+	return activeConfigFile
+}
+
+// PathRelativeToConfig resolves p against the directory of ConfigFileUsed
+// rather than the process's CWD, so a relative "path:" entry written in a
+// config file keeps working after the user cd's elsewhere. An absolute p,
+// or one with no active config file to resolve against, is returned as-is.
+func PathRelativeToConfig(p string) string {
+	if p == "" || filepath.IsAbs(p) || activeConfigFile == "" {
+		return p
+	}
+	return filepath.Join(filepath.Dir(activeConfigFile), p)
+}
+
 // ============================================================================
 // Helpers
 // ============================================================================
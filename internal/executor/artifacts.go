@@ -0,0 +1,122 @@
+package executor
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/gubarz/cheatmd/internal/parser"
+)
+
+// ResolveInputs resolves cheat's structured Inputs (the DSL's
+// "input NAME type=TYPE" lines) to plain string values, ready to merge into
+// cheat.Scope alongside its Vars. Every Type is read the same way - a
+// blocking stdin prompt, reusing the Executor's shared reader the same way
+// ResolveScope's prompts do - but Type determines what's checked before the
+// value is accepted:
+//
+//   - "dir"/"file": the path must exist and be a directory/regular file.
+//   - "container": the value is a Docker container name or ID, checked with
+//     "docker inspect".
+//   - "url": the value is checked reachable with an HTTP HEAD request.
+//
+// A bad value re-prompts rather than failing the whole resolution, the same
+// way a validate-failing var would in the TUI. A blank answer (EOF, or an
+// empty line) skips the input rather than storing "".
+func (e *Executor) ResolveInputs(cheat *parser.Cheat) (map[string]string, error) {
+	resolved := make(map[string]string, len(cheat.Inputs))
+	for _, in := range cheat.Inputs {
+		value, err := e.resolveInput(in)
+		if err != nil {
+			return nil, fmt.Errorf("resolve input $%s: %w", in.Name, err)
+		}
+		if value != "" {
+			resolved[in.Name] = value
+		}
+	}
+	return resolved, nil
+}
+
+// resolveInput prompts for in's value, re-prompting while it fails
+// validateInput, and returns "" unresolved on a blank answer or EOF.
+func (e *Executor) resolveInput(in parser.InputDef) (string, error) {
+	for {
+		fmt.Fprintf(os.Stderr, "%s (%s): ", in.Name, in.Type)
+		value, err := e.readStdinLine()
+		if err != nil {
+			return "", err
+		}
+		if value == "" {
+			return "", nil
+		}
+
+		if err := validateInput(in, value); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v, try again\n", err)
+			continue
+		}
+		return value, nil
+	}
+}
+
+// validateInput checks value against in.Type, per the rules documented on
+// ResolveInputs.
+func validateInput(in parser.InputDef, value string) error {
+	switch in.Type {
+	case "dir":
+		info, err := os.Stat(value)
+		if err != nil {
+			return fmt.Errorf("%q: %w", value, err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%q is not a directory", value)
+		}
+	case "file":
+		info, err := os.Stat(value)
+		if err != nil {
+			return fmt.Errorf("%q: %w", value, err)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("%q is a directory, not a file", value)
+		}
+	case "container":
+		if err := exec.Command("docker", "inspect", value).Run(); err != nil {
+			return fmt.Errorf("docker inspect %q: %w", value, err)
+		}
+	case "url":
+		client := http.Client{Timeout: 5 * time.Second}
+		resp, err := client.Head(value)
+		if err != nil {
+			return fmt.Errorf("HEAD %q: %w", value, err)
+		}
+		resp.Body.Close()
+	}
+	return nil
+}
+
+// captureOutputs reads cheat's Outputs (the DSL's "output NAME type=TYPE"
+// lines) against the just-finished command's stdout, returning a map keyed
+// by each output's Name - the same keys Pipeline.Run threads into the next
+// step's scope as "$prev.<name>".
+func captureOutputs(cheat *parser.Cheat, stdout string) map[string]string {
+	captured := make(map[string]string, len(cheat.Outputs))
+	for _, out := range cheat.Outputs {
+		switch out.Type {
+		case "stdout":
+			captured[out.Name] = strings.TrimSpace(stdout)
+		case "file":
+			if out.Path == "" {
+				continue
+			}
+			data, err := os.ReadFile(out.Path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: output $%s: %v\n", out.Name, err)
+				continue
+			}
+			captured[out.Name] = strings.TrimSpace(string(data))
+		}
+	}
+	return captured
+}
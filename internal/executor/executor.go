@@ -1,16 +1,45 @@
 package executor
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
+	"github.com/atotto/clipboard"
 	"github.com/gubarz/cheatmd/internal/config"
 	"github.com/gubarz/cheatmd/internal/parser"
+	"github.com/gubarz/cheatmd/internal/parser/template"
+	"github.com/gubarz/cheatmd/internal/secrets"
 )
 
+// secretRefPrefix marks an opaque placeholder stored in Cheat.Scope for a
+// variable whose VarDef.Source points at an external secret store. The
+// actual secret is only fetched by BuildFinalCommand, just before exec, so
+// it never lingers in the resolved scope or gets echoed by OutputPrint.
+const secretRefPrefix = "\x00secret:"
+
+// secretRefSep separates the reference from its Transforms chain inside a
+// SecretRef placeholder, since the secret itself - and so the point at which
+// Transforms can run - isn't available until BuildFinalCommand.
+const secretRefSep = "\x00"
+
+// SecretRef wraps a secret reference (e.g. "keyring:service/account") as the
+// opaque scope value stored for a Source-backed variable. transforms (if any)
+// travel along with the ref and are applied to the looked-up secret by
+// BuildFinalCommand, just before substitution.
+func SecretRef(ref string, transforms []string) string {
+	parts := append([]string{ref}, transforms...)
+	return secretRefPrefix + strings.Join(parts, secretRefSep)
+}
+
 // ============================================================================
 // Shell Runner Interface
 // ============================================================================
@@ -18,7 +47,7 @@ import (
 // ShellRunner defines the interface for shell command execution
 type ShellRunner interface {
 	RunShell(command string) (string, error)
-	Execute(command string) error
+	Execute(cheat *parser.Cheat, command string) error
 }
 
 // ============================================================================
@@ -30,41 +59,115 @@ type Clipboard interface {
 	Copy(text string) error
 }
 
-// systemClipboard implements Clipboard using system commands
-type systemClipboard struct{}
+// osc52MaxBytes is the largest payload OSC 52 can carry - terminals commonly
+// cap pasteboard-via-escape-sequence input around 74994 decoded bytes.
+const osc52MaxBytes = 74994
 
-// Copy copies text to the system clipboard
-func (c *systemClipboard) Copy(text string) error {
-	cmd := c.findClipboardCommand()
-	if cmd == nil {
-		// No clipboard tool found, just print
-		fmt.Println(text)
-		return nil
+// NativeClipboard copies via the OS's native clipboard (X11/Wayland, macOS,
+// Windows) through github.com/atotto/clipboard.
+type NativeClipboard struct{}
+
+// Copy implements Clipboard.
+func (NativeClipboard) Copy(text string) error {
+	return clipboard.WriteAll(text)
+}
+
+// OSC52Clipboard copies by writing the OSC 52 terminal escape sequence to
+// /dev/tty (falling back to stderr when no tty is open), so an SSH session
+// can populate the *local* terminal's clipboard with nothing installed on
+// the remote end.
+type OSC52Clipboard struct{}
+
+// Copy implements Clipboard.
+func (OSC52Clipboard) Copy(text string) error {
+	if len(text) > osc52MaxBytes {
+		return fmt.Errorf("clipboard: payload is %d bytes, exceeds the %d byte OSC 52 limit", len(text), osc52MaxBytes)
+	}
+
+	w, closeW := osc52Writer()
+	defer closeW()
+
+	_, err := fmt.Fprintf(w, "\x1b]52;c;%s\x07", base64.StdEncoding.EncodeToString([]byte(text)))
+	return err
+}
+
+// osc52Writer opens /dev/tty for the OSC 52 sequence, falling back to stderr
+// (which most terminals still forward escape sequences from) when no tty is
+// available.
+func osc52Writer() (io.Writer, func()) {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return os.Stderr, func() {}
 	}
+	return tty, func() { tty.Close() }
+}
+
+// TmuxClipboard copies into the tmux paste buffer via "tmux load-buffer", for
+// sessions where OSC 52 passthrough isn't configured but tmux itself is on
+// PATH.
+type TmuxClipboard struct{}
+
+// Copy implements Clipboard.
+func (TmuxClipboard) Copy(text string) error {
+	cmd := exec.Command("tmux", "load-buffer", "-")
 	cmd.Stdin = strings.NewReader(text)
 	return cmd.Run()
 }
 
-// findClipboardCommand returns the appropriate clipboard command for the system
-func (c *systemClipboard) findClipboardCommand() *exec.Cmd {
-	switch {
-	case commandExists("wl-copy"):
-		return exec.Command("wl-copy")
-	case commandExists("xclip"):
-		return exec.Command("xclip", "-selection", "clipboard")
-	case commandExists("xsel"):
-		return exec.Command("xsel", "--clipboard", "--input")
-	case commandExists("pbcopy"):
-		return exec.Command("pbcopy")
-	default:
+// NullClipboard prints instead of copying - the last-resort fallback when no
+// other strategy is reachable.
+type NullClipboard struct{}
+
+// Copy implements Clipboard.
+func (NullClipboard) Copy(text string) error {
+	fmt.Println(text)
+	return nil
+}
+
+// chainClipboard tries each Clipboard in order, falling through to the next
+// on error - auto mode's native-then-OSC52-then-print degrade path.
+type chainClipboard []Clipboard
+
+// Copy implements Clipboard.
+func (c chainClipboard) Copy(text string) error {
+	var lastErr error
+	for _, cb := range c {
+		if err := cb.Copy(text); err != nil {
+			lastErr = err
+			continue
+		}
 		return nil
 	}
+	return lastErr
 }
 
-// commandExists checks if a command is available in PATH
-func commandExists(name string) bool {
-	_, err := exec.LookPath(name)
-	return err == nil
+// resolveClipboard picks a Clipboard strategy per config.GetClipboard():
+//   - "native": NativeClipboard only
+//   - "osc52": OSC52Clipboard only
+//   - "tmux": TmuxClipboard only
+//   - "stdout": NullClipboard only (always print)
+//   - "auto" (default): an SSH session prefers OSC 52, a tmux session prefers
+//     the tmux buffer, otherwise native - falling back through OSC 52 to
+//     printing if native has no clipboard to reach
+func resolveClipboard() Clipboard {
+	switch config.GetClipboard() {
+	case "native":
+		return NativeClipboard{}
+	case "osc52":
+		return OSC52Clipboard{}
+	case "tmux":
+		return TmuxClipboard{}
+	case "stdout":
+		return NullClipboard{}
+	default: // "auto"
+		if os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "" {
+			return OSC52Clipboard{}
+		}
+		if os.Getenv("TMUX") != "" {
+			return TmuxClipboard{}
+		}
+		return chainClipboard{NativeClipboard{}, OSC52Clipboard{}, NullClipboard{}}
+	}
 }
 
 // ============================================================================
@@ -76,6 +179,7 @@ type Executor struct {
 	index     *parser.CheatIndex
 	shell     string
 	clipboard Clipboard
+	stdin     *bufio.Reader // lazily set by ResolveScope's prompts; one shared reader so buffered input survives across prompts
 }
 
 // NewExecutor creates a new executor with the given cheat index
@@ -83,7 +187,7 @@ func NewExecutor(index *parser.CheatIndex) *Executor {
 	return &Executor{
 		index:     index,
 		shell:     config.GetShell(),
-		clipboard: &systemClipboard{},
+		clipboard: resolveClipboard(),
 	}
 }
 
@@ -93,6 +197,14 @@ func (e *Executor) WithClipboard(c Clipboard) *Executor {
 	return e
 }
 
+// CopyToClipboard copies text via the Clipboard strategy resolveClipboard()
+// picked from config.GetClipboard(), so every copy path (OutputWithMode's
+// OutputCopy case, or a UI package's own copy action) shares one
+// native/osc52/tmux implementation instead of each maintaining its own.
+func (e *Executor) CopyToClipboard(text string) error {
+	return e.clipboard.Copy(text)
+}
+
 // Index returns the cheat index
 func (e *Executor) Index() *parser.CheatIndex {
 	return e.index
@@ -123,26 +235,104 @@ func (e *Executor) RunShell(command string) (string, error) {
 	return strings.TrimSpace(stdout.String()), nil
 }
 
-// Execute runs a command interactively with inherited stdin/stdout/stderr
-func (e *Executor) Execute(command string) error {
+// Execute runs a command interactively with inherited stdin/stdout/stderr,
+// bracketed by cheat's pre_hook/post_hook (see RunWithHooks).
+func (e *Executor) Execute(cheat *parser.Cheat, command string) error {
+	return e.RunWithHooks(cheat, command, OutputExec, func() error {
+		cmd := exec.Command(e.shell, "-c", command)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.Env = os.Environ()
+		return cmd.Run()
+	})
+}
+
+// RunPipelineStep runs one step of a parser.Cheat.Pipeline command. Like
+// Execute, it streams to the terminal with inherited stdin/stdout so the
+// user watches the chain run; unlike Execute, stdout is also captured
+// (trimmed) so the caller can thread it into the next step's "$_"
+// reference. RunPipelineStep is not itself bracketed by pre_hook/post_hook -
+// the caller wraps the whole step sequence in one RunWithHooks call (see
+// ui.executePipelineSteps).
+func (e *Executor) RunPipelineStep(command string) (string, error) {
+	var stdout bytes.Buffer
 	cmd := exec.Command(e.shell, "-c", command)
 	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
+	cmd.Stdout = io.MultiWriter(os.Stdout, &stdout)
 	cmd.Stderr = os.Stderr
 	cmd.Env = os.Environ()
-	return cmd.Run()
+	err := cmd.Run()
+	return strings.TrimSpace(stdout.String()), err
 }
 
 // ============================================================================
 // Command Building
 // ============================================================================
 
-// BuildFinalCommand substitutes all variables in a cheat's command
+// maskedValuePlaceholder replaces a Masked variable's real value when
+// BuildDisplayCommand builds the command for OutputPrint.
+const maskedValuePlaceholder = "********"
+
+// BuildFinalCommand substitutes all variables in a cheat's command.
+// Secret-backed variables (see SecretRef) are fetched from their backend
+// here, at the last possible moment, rather than being resolved earlier.
+//
+// A Command using the template package's richer syntax ("{{#if}}",
+// "${var:-default}", "{{#each}}", ...) is evaluated by that package instead
+// of the plain string-replace below, which stays the fast path for the
+// common case of a command with only bare "$var" references.
 func (e *Executor) BuildFinalCommand(cheat *parser.Cheat) string {
-	result := cheat.Command
+	return e.buildCommand(cheat, false)
+}
 
-	// Substitute all scope variables
+// BuildDisplayCommand is BuildFinalCommand for OutputPrint: any variable
+// declared "secret" (VarDef.Masked) has its resolved value replaced with
+// maskedValuePlaceholder instead of being echoed in the clear.
+func (e *Executor) BuildDisplayCommand(cheat *parser.Cheat) string {
+	return e.buildCommand(cheat, true)
+}
+
+func (e *Executor) buildCommand(cheat *parser.Cheat, mask bool) string {
+	var masked map[string]bool
+	if mask {
+		masked = e.maskedVarNames(cheat)
+	}
+
+	resolved := make(map[string]string, len(cheat.Scope))
 	for name, value := range cheat.Scope {
+		if packed, ok := strings.CutPrefix(value, secretRefPrefix); ok {
+			parts := strings.Split(packed, secretRefSep)
+			ref, transforms := parts[0], parts[1:]
+
+			secret, err := secrets.Lookup(context.Background(), ref)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not resolve secret for $%s: %v\n", name, err)
+				continue
+			}
+			if len(transforms) > 0 {
+				secret, err = parser.ApplyTransforms(secret, transforms)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: transform for $%s: %v\n", name, err)
+				}
+			}
+			value = secret
+		}
+		if masked[name] {
+			value = maskedValuePlaceholder
+		}
+		resolved[name] = value
+	}
+
+	if template.HasTemplateSyntax(cheat.Command) {
+		if tmpl, err := template.Parse(cheat.Command); err == nil {
+			return strings.ReplaceAll(tmpl.Eval(resolved), "\\$", "$")
+		}
+		fmt.Fprintln(os.Stderr, "Warning: could not parse command template, falling back to plain substitution")
+	}
+
+	result := cheat.Command
+	for name, value := range resolved {
 		result = strings.ReplaceAll(result, "$"+name, value)
 	}
 
@@ -152,6 +342,74 @@ func (e *Executor) BuildFinalCommand(cheat *parser.Cheat) string {
 	return result
 }
 
+// MultiJoinMode selects how BuildFinalCommandBatch combines the commands of
+// several --multi-selected cheats into the single shell invocation that's
+// ultimately printed/copied/executed.
+type MultiJoinMode string
+
+const (
+	// MultiJoinAnd chains commands with && so a failure stops the rest.
+	MultiJoinAnd MultiJoinMode = "and"
+	// MultiJoinPipe pipes each command's stdout into the next.
+	MultiJoinPipe MultiJoinMode = "pipe"
+	// MultiJoinHeredoc places each command on its own line, for pasting as a
+	// small script rather than a single chained/piped command line.
+	MultiJoinHeredoc MultiJoinMode = "heredoc"
+)
+
+// BuildFinalCommandBatch builds every cheat's final command via
+// BuildFinalCommand and joins them per join ("and", "pipe", or "heredoc"),
+// falling back to MultiJoinAnd for an unrecognized value. This is the
+// --multi/--multi-join counterpart to BuildFinalCommand for a single cheat.
+func (e *Executor) BuildFinalCommandBatch(cheats []*parser.Cheat, join string) string {
+	parts := make([]string, len(cheats))
+	for i, cheat := range cheats {
+		parts[i] = e.BuildFinalCommand(cheat)
+	}
+	switch MultiJoinMode(join) {
+	case MultiJoinPipe:
+		return strings.Join(parts, " | ")
+	case MultiJoinHeredoc:
+		return strings.Join(parts, "\n")
+	default:
+		return strings.Join(parts, " && ")
+	}
+}
+
+// maskedVarNames returns the names of every variable - local to cheat or
+// declared by one of its imported modules - that any conditional variant
+// declares Masked, so buildCommand can mask a name consistently regardless
+// of which variant actually resolved it.
+func (e *Executor) maskedVarNames(cheat *parser.Cheat) map[string]bool {
+	masked := make(map[string]bool)
+	collect := func(vars []parser.VarDef) {
+		for _, v := range vars {
+			if v.Masked {
+				masked[v.Name] = true
+			}
+		}
+	}
+	collect(cheat.Vars)
+
+	seen := make(map[string]bool)
+	var walkImports func(imports []string)
+	walkImports = func(imports []string) {
+		for _, name := range imports {
+			if seen[name] || e.index == nil {
+				continue
+			}
+			seen[name] = true
+			if module, ok := e.index.Modules[name]; ok {
+				collect(module.Vars)
+				walkImports(module.Imports)
+			}
+		}
+	}
+	walkImports(cheat.Imports)
+
+	return masked
+}
+
 // SubstituteVars replaces variables in a string using the given scope
 func SubstituteVars(s string, scope map[string]string) string {
 	for name, value := range scope {
@@ -168,26 +426,127 @@ func SubstituteVars(s string, scope map[string]string) string {
 type OutputMode string
 
 const (
-	OutputPrint OutputMode = "print"
-	OutputCopy  OutputMode = "copy"
-	OutputExec  OutputMode = "exec"
+	OutputPrint    OutputMode = "print"
+	OutputCopy     OutputMode = "copy"
+	OutputExec     OutputMode = "exec"
+	OutputPipeline OutputMode = "pipeline"
 )
 
 // Output handles command output based on the configured mode
-func (e *Executor) Output(command string) error {
+func (e *Executor) Output(cheat *parser.Cheat, command string) error {
 	mode := OutputMode(config.GetOutput())
-	return e.OutputWithMode(command, mode)
+	return e.OutputWithMode(cheat, command, mode)
 }
 
-// OutputWithMode handles command output with an explicit mode
-func (e *Executor) OutputWithMode(command string, mode OutputMode) error {
+// OutputWithMode handles command output with an explicit mode, bracketed by
+// cheat's pre_hook/post_hook (see RunWithHooks).
+func (e *Executor) OutputWithMode(cheat *parser.Cheat, command string, mode OutputMode) error {
 	switch mode {
 	case OutputExec:
-		return e.Execute(command)
+		return e.Execute(cheat, command)
 	case OutputCopy:
-		return e.clipboard.Copy(command)
+		return e.RunWithHooks(cheat, command, mode, func() error {
+			return e.clipboard.Copy(command)
+		})
 	default: // print
-		fmt.Println(command)
-		return nil
+		return e.RunWithHooks(cheat, command, mode, func() error {
+			fmt.Println(command)
+			return nil
+		})
+	}
+}
+
+// ============================================================================
+// Hooks
+// ============================================================================
+
+// RunWithHooks brackets action with the effective pre_hook/post_hook -
+// cheat.PreHook/cheat.PostHook if set, else config.GetPreHook()/GetPostHook()
+// - running both in the configured shell with CHEATMD_* context in the
+// environment. A pre_hook that exits non-zero aborts action and skips it,
+// unless config.GetHooksIgnorePreFailure() is set; post_hook always runs
+// afterwards, even when the pre_hook or action failed, and its failure is
+// only logged since the output has already been delivered. Execute and
+// OutputWithMode use this for their own dispatch; callers with a bespoke
+// output path (e.g. a UI package printing its own status lines) can reuse it
+// directly so hooks still fire the same way.
+func (e *Executor) RunWithHooks(cheat *parser.Cheat, command string, mode OutputMode, action func() error) error {
+	if cheat == nil {
+		cheat = &parser.Cheat{}
+	}
+	env := hookEnv(cheat, command, mode)
+
+	var actionErr error
+	if preHook := effectiveHook(cheat.PreHook, config.GetPreHook()); preHook != "" {
+		if err := e.runHook(preHook, env); err != nil {
+			if !config.GetHooksIgnorePreFailure() {
+				actionErr = fmt.Errorf("pre_hook: %w", err)
+			} else {
+				fmt.Fprintf(os.Stderr, "Warning: pre_hook failed, continuing (hooks.ignore_pre_failure): %v\n", err)
+			}
+		}
+	}
+
+	start := time.Now()
+	if actionErr == nil {
+		actionErr = action()
+	}
+	duration := time.Since(start)
+
+	if postHook := effectiveHook(cheat.PostHook, config.GetPostHook()); postHook != "" {
+		postEnv := append(env,
+			fmt.Sprintf("CHEATMD_EXIT_CODE=%d", exitCodeOf(actionErr)),
+			fmt.Sprintf("CHEATMD_DURATION_MS=%d", duration.Milliseconds()),
+		)
+		if err := e.runHook(postHook, postEnv); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: post_hook failed: %v\n", err)
+		}
+	}
+
+	return actionErr
+}
+
+// effectiveHook prefers a per-cheat hook override over the global config hook.
+func effectiveHook(cheatHook, globalHook string) string {
+	if cheatHook != "" {
+		return cheatHook
+	}
+	return globalHook
+}
+
+// hookEnv builds the CHEATMD_* variables exposed to pre_hook/post_hook,
+// describing the cheat and the command about to be output.
+func hookEnv(cheat *parser.Cheat, finalCommand string, mode OutputMode) []string {
+	return []string{
+		"CHEATMD_CHEAT_HEADER=" + cheat.Header,
+		"CHEATMD_CHEAT_DESC=" + cheat.Description,
+		"CHEATMD_CHEAT_FILE=" + cheat.File,
+		"CHEATMD_FINAL_COMMAND=" + finalCommand,
+		"CHEATMD_OUTPUT_MODE=" + string(mode),
+	}
+}
+
+// runHook runs a pre_hook/post_hook command in the configured shell, on top
+// of the current environment plus env. Its stdout/stderr go to stderr so a
+// print-mode hook doesn't interleave with the final command on stdout.
+func (e *Executor) runHook(hookCmd string, env []string) error {
+	cmd := exec.Command(e.shell, "-c", hookCmd)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), env...)
+	return cmd.Run()
+}
+
+// exitCodeOf extracts the shell-style exit code from an error returned by an
+// action, or 0 when it succeeded.
+func exitCodeOf(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
 	}
+	return 1
 }
@@ -0,0 +1,177 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gubarz/cheatmd/internal/parser"
+	"github.com/spf13/viper"
+)
+
+// withHookConfig sets the global pre_hook/post_hook/hooks.ignore_pre_failure
+// config keys for the duration of a test and restores them afterward.
+func withHookConfig(t *testing.T, preHook, postHook string, ignorePreFailure bool) {
+	t.Helper()
+	viper.Set("pre_hook", preHook)
+	viper.Set("post_hook", postHook)
+	viper.Set("hooks.ignore_pre_failure", ignorePreFailure)
+	t.Cleanup(func() {
+		viper.Set("pre_hook", "")
+		viper.Set("post_hook", "")
+		viper.Set("hooks.ignore_pre_failure", false)
+	})
+}
+
+func newTestExecutor(t *testing.T) *Executor {
+	t.Helper()
+	e := NewExecutor(parser.NewCheatIndex())
+	if e.shell == "" {
+		e.shell = "/bin/sh"
+	}
+	return e
+}
+
+func TestRunWithHooksEnvAndOrder(t *testing.T) {
+	dir := t.TempDir()
+	preFile := filepath.Join(dir, "pre.env")
+	postFile := filepath.Join(dir, "post.env")
+
+	withHookConfig(t,
+		"env | grep ^CHEATMD_ > "+preFile,
+		"env | grep ^CHEATMD_ > "+postFile,
+		false,
+	)
+
+	cheat := &parser.Cheat{Header: "Deploy", Description: "ship it", File: "deploy.md"}
+	e := newTestExecutor(t)
+
+	var actionRan bool
+	err := e.RunWithHooks(cheat, "echo hi", OutputPrint, func() error {
+		actionRan = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RunWithHooks: %v", err)
+	}
+	if !actionRan {
+		t.Fatal("action was not run")
+	}
+
+	pre, err := os.ReadFile(preFile)
+	if err != nil {
+		t.Fatalf("pre_hook did not run: %v", err)
+	}
+	if got := string(pre); !strings.Contains(got, "CHEATMD_CHEAT_HEADER=Deploy") || !strings.Contains(got, "CHEATMD_FINAL_COMMAND=echo hi") {
+		t.Errorf("pre_hook env missing expected vars: %q", got)
+	}
+
+	post, err := os.ReadFile(postFile)
+	if err != nil {
+		t.Fatalf("post_hook did not run: %v", err)
+	}
+	if got := string(post); !strings.Contains(got, "CHEATMD_EXIT_CODE=0") || !strings.Contains(got, "CHEATMD_DURATION_MS=") {
+		t.Errorf("post_hook env missing expected vars: %q", got)
+	}
+}
+
+func TestRunWithHooksAbortsOnPreHookFailure(t *testing.T) {
+	withHookConfig(t, "exit 1", "", false)
+
+	e := newTestExecutor(t)
+	var actionRan bool
+	err := e.RunWithHooks(&parser.Cheat{}, "echo hi", OutputPrint, func() error {
+		actionRan = true
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected pre_hook failure to abort, got nil error")
+	}
+	if actionRan {
+		t.Fatal("action ran despite pre_hook failure")
+	}
+}
+
+func TestRunWithHooksIgnoresPreHookFailureWhenConfigured(t *testing.T) {
+	withHookConfig(t, "exit 1", "", true)
+
+	e := newTestExecutor(t)
+	var actionRan bool
+	err := e.RunWithHooks(&parser.Cheat{}, "echo hi", OutputPrint, func() error {
+		actionRan = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected hooks.ignore_pre_failure to swallow the error, got: %v", err)
+	}
+	if !actionRan {
+		t.Fatal("action did not run despite hooks.ignore_pre_failure")
+	}
+}
+
+func TestRunWithHooksPerCheatOverridesGlobal(t *testing.T) {
+	dir := t.TempDir()
+	cheatFile := filepath.Join(dir, "cheat.ran")
+	globalFile := filepath.Join(dir, "global.ran")
+
+	withHookConfig(t, "touch "+globalFile, "", false)
+
+	cheat := &parser.Cheat{PreHook: "touch " + cheatFile}
+	e := newTestExecutor(t)
+
+	if err := e.RunWithHooks(cheat, "echo hi", OutputPrint, func() error { return nil }); err != nil {
+		t.Fatalf("RunWithHooks: %v", err)
+	}
+
+	if _, err := os.Stat(cheatFile); err != nil {
+		t.Error("per-cheat pre_hook did not run")
+	}
+	if _, err := os.Stat(globalFile); err == nil {
+		t.Error("global pre_hook ran even though the cheat overrode it")
+	}
+}
+
+func TestBuildFinalCommandBatch(t *testing.T) {
+	e := newTestExecutor(t)
+	cheats := []*parser.Cheat{
+		{Header: "One", Command: "echo one"},
+		{Header: "Two", Command: "echo two"},
+	}
+
+	tests := []struct {
+		join string
+		want string
+	}{
+		{"and", "echo one && echo two"},
+		{"pipe", "echo one | echo two"},
+		{"heredoc", "echo one\necho two"},
+		{"bogus", "echo one && echo two"},
+	}
+
+	for _, tt := range tests {
+		if got := e.BuildFinalCommandBatch(cheats, tt.join); got != tt.want {
+			t.Errorf("BuildFinalCommandBatch(%q) = %q, want %q", tt.join, got, tt.want)
+		}
+	}
+}
+
+func TestRunPipelineStepCapturesStdout(t *testing.T) {
+	e := newTestExecutor(t)
+
+	out, err := e.RunPipelineStep("echo hello")
+	if err != nil {
+		t.Fatalf("RunPipelineStep: %v", err)
+	}
+	if out != "hello" {
+		t.Errorf("RunPipelineStep stdout = %q, want %q", out, "hello")
+	}
+}
+
+func TestRunPipelineStepReturnsShellError(t *testing.T) {
+	e := newTestExecutor(t)
+
+	if _, err := e.RunPipelineStep("exit 1"); err == nil {
+		t.Fatal("expected an error from a failing step")
+	}
+}
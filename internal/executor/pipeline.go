@@ -0,0 +1,73 @@
+package executor
+
+import (
+	"fmt"
+
+	"github.com/gubarz/cheatmd/internal/parser"
+)
+
+// Pipeline runs a sequence of cheats end-to-end, threading each step's
+// captured Outputs into the next step's scope as "prev.<name>" vars - so a
+// chain of cheat blocks can act as a composable mini-workflow instead of a
+// set of one-shot snippets run in isolation.
+type Pipeline struct {
+	executor *Executor
+}
+
+// NewPipeline creates a Pipeline that resolves and runs its steps through e.
+func NewPipeline(e *Executor) *Pipeline {
+	return &Pipeline{executor: e}
+}
+
+// Run resolves and executes each cheat in steps in order. Before a step
+// runs, the previous step's captured Outputs (see OutputDef) are merged into
+// its Scope as "prev.<name>" vars, so its Command can reference
+// "$prev.result" the same way it references any other var; ResolveInputs and
+// ResolveScope then fill in the step's own Inputs/Vars. Run stops at the
+// first error from resolving or executing a step and returns it wrapped with
+// the step's position and header; on success it returns the last step's
+// captured Outputs.
+func (p *Pipeline) Run(steps []*parser.Cheat) (map[string]string, error) {
+	var prev map[string]string
+
+	for i, cheat := range steps {
+		if cheat.Scope == nil {
+			cheat.Scope = make(map[string]string)
+		}
+		for name, value := range prev {
+			cheat.Scope["prev."+name] = value
+		}
+
+		inputs, err := p.executor.ResolveInputs(cheat)
+		if err != nil {
+			return nil, fmt.Errorf("step %d (%s): %w", i+1, cheat.Header, err)
+		}
+		for name, value := range inputs {
+			cheat.Scope[name] = value
+		}
+
+		scope, err := p.executor.ResolveScope(cheat)
+		if err != nil {
+			return nil, fmt.Errorf("step %d (%s): %w", i+1, cheat.Header, err)
+		}
+		for name, value := range scope {
+			cheat.Scope[name] = value
+		}
+
+		command := p.executor.BuildFinalCommand(cheat)
+
+		var stdout string
+		runErr := p.executor.RunWithHooks(cheat, command, OutputExec, func() error {
+			out, err := p.executor.RunShell(command)
+			stdout = out
+			return err
+		})
+		if runErr != nil {
+			return nil, fmt.Errorf("step %d (%s): %w", i+1, cheat.Header, runErr)
+		}
+
+		prev = captureOutputs(cheat, stdout)
+	}
+
+	return prev, nil
+}
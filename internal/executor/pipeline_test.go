@@ -0,0 +1,59 @@
+package executor
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gubarz/cheatmd/internal/parser"
+)
+
+func TestPipelineThreadsOutputBetweenSteps(t *testing.T) {
+	steps := []*parser.Cheat{
+		{
+			Header:  "step1",
+			Command: "echo hello",
+			Outputs: []parser.OutputDef{{Name: "result", Type: "stdout"}},
+		},
+		{
+			Header:  "step2",
+			Command: "echo got:$prev.result",
+			Outputs: []parser.OutputDef{{Name: "result", Type: "stdout"}},
+		},
+	}
+	e := newTestExecutor(t)
+
+	out, err := NewPipeline(e).Run(steps)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := out["result"]; got != "got:hello" {
+		t.Errorf("final result = %q, want %q", got, "got:hello")
+	}
+}
+
+func TestPipelineCapturesFileOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.txt")
+	steps := []*parser.Cheat{
+		{
+			Header:  "write",
+			Command: "echo written > " + path,
+			Outputs: []parser.OutputDef{{Name: "contents", Type: "file", Path: path}},
+		},
+	}
+	e := newTestExecutor(t)
+
+	out, err := NewPipeline(e).Run(steps)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := out["contents"]; got != "written" {
+		t.Errorf("contents = %q, want %q", got, "written")
+	}
+}
+
+func TestValidateInputRejectsMissingFile(t *testing.T) {
+	err := validateInput(parser.InputDef{Name: "f", Type: "file"}, filepath.Join(t.TempDir(), "missing"))
+	if err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
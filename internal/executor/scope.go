@@ -0,0 +1,336 @@
+package executor
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gubarz/cheatmd/internal/config"
+	"github.com/gubarz/cheatmd/internal/parser"
+	"github.com/gubarz/cheatmd/internal/parser/template"
+	"github.com/gubarz/cheatmd/internal/secrets"
+)
+
+// ResolveScope walks cheat's variable schema - its own Vars plus any
+// imported module's, transitively - resolving each to a value via a
+// blocking stdin prompt, and returns the map assignable to cheat.Scope. It's
+// the non-interactive counterpart to the TUI's own resolution walk (see
+// resolveAllVariables in internal/ui): no bubbletea dependency, so it's
+// usable from tests and any future headless entry point.
+//
+// A var with Shell set runs it and offers a multi-line result as numbered
+// choices, auto-picking the only one when config.GetAutoSelect(). Default
+// fills the value when the prompt comes back empty; Validate (a regex) and
+// Type ("int"/"bool") are checked before the value is accepted. A Masked var
+// reads its input without echoing it to the terminal, and a Source-backed
+// var resolves to an opaque SecretRef, exactly as BuildFinalCommand expects.
+func (e *Executor) ResolveScope(cheat *parser.Cheat) (map[string]string, error) {
+	order, variants := e.collectScopeVars(cheat)
+	scope := make(map[string]string, len(order))
+
+	for _, name := range order {
+		def, ok := selectScopeVariant(variants[name], scope)
+		if !ok {
+			continue
+		}
+
+		value, err := e.resolveScopeVar(def, scope)
+		if err != nil {
+			return nil, fmt.Errorf("resolve $%s: %w", name, err)
+		}
+		scope[name] = value
+	}
+
+	return scope, nil
+}
+
+// collectScopeVars gathers every variable name ResolveScope needs to fill,
+// in declaration order (imported modules first, transitively, then cheat's
+// own Vars), along with all of its conditional variants.
+func (e *Executor) collectScopeVars(cheat *parser.Cheat) ([]string, map[string][]parser.VarDef) {
+	variants := make(map[string][]parser.VarDef)
+	var order []string
+	add := func(v parser.VarDef) {
+		if _, ok := variants[v.Name]; !ok {
+			order = append(order, v.Name)
+		}
+		variants[v.Name] = append(variants[v.Name], v)
+	}
+
+	seen := make(map[string]bool)
+	var walkImports func(imports []string)
+	walkImports = func(imports []string) {
+		for _, name := range imports {
+			if seen[name] || e.index == nil {
+				continue
+			}
+			seen[name] = true
+			if module, ok := e.index.Modules[name]; ok {
+				walkImports(module.Imports)
+				for _, v := range module.Vars {
+					add(v)
+				}
+			}
+		}
+	}
+	walkImports(cheat.Imports)
+	for _, v := range cheat.Vars {
+		add(v)
+	}
+
+	return order, variants
+}
+
+// selectScopeVariant picks the first variant whose Condition matches scope,
+// falling back to the first unconditional variant. It reports false when
+// every variant is conditional and none matched, so the caller skips the
+// variable entirely (mirrors selectVariant in internal/ui/resolve.go).
+func selectScopeVariant(variants []parser.VarDef, scope map[string]string) (parser.VarDef, bool) {
+	var fallback *parser.VarDef
+	for i := range variants {
+		v := &variants[i]
+		if v.Condition == "" {
+			if fallback == nil {
+				fallback = v
+			}
+			continue
+		}
+		if template.Evaluate(v.Condition, scope) {
+			return *v, true
+		}
+	}
+	if fallback != nil {
+		return *fallback, true
+	}
+	return parser.VarDef{}, false
+}
+
+// resolveScopeVar resolves a single variable definition against scope,
+// applying the same knobs the TUI's resolveVar does, minus the rendering.
+func (e *Executor) resolveScopeVar(v parser.VarDef, scope map[string]string) (string, error) {
+	if v.Literal != "" {
+		return SubstituteVars(v.Literal, scope), nil
+	}
+
+	if v.IsSecret() {
+		return e.resolveScopeSecret(v)
+	}
+
+	var choices []string
+	if strings.TrimSpace(v.Shell) != "" {
+		if output, err := e.RunShell(SubstituteVars(v.Shell, scope)); err == nil {
+			choices = splitNonEmptyLines(output)
+		}
+	}
+
+	value, err := e.promptForValue(v, choices)
+	if err != nil {
+		return "", err
+	}
+	return validateScopeValue(v, applyScopeTransforms(v, value))
+}
+
+// resolveScopeSecret prompts for a secret-backed variable, offering
+// secrets.List's known names as choices but never resolving the actual
+// secret value - the chosen reference is wrapped as an opaque SecretRef, the
+// same contract ui.resolveSecretVar upholds for the TUI.
+func (e *Executor) resolveScopeSecret(v parser.VarDef) (string, error) {
+	r, err := secrets.ParseRef(v.Source)
+	if err != nil {
+		return "", err
+	}
+	choices, _ := secrets.List(context.Background(), r.Scheme, r.Rest)
+
+	value, err := e.promptForValue(v, choices)
+	if err != nil {
+		return "", err
+	}
+
+	ref := value
+	if !strings.Contains(ref, ":") {
+		// User accepted a bare name offered by List - re-attach the scheme.
+		ref = r.Scheme + ":" + ref
+	}
+	return SecretRef(ref, v.Transforms), nil
+}
+
+// applyScopeTransforms runs a resolved value through v's declared
+// Transforms, warning to stderr and returning the raw value unchanged if a
+// transform fails rather than aborting resolution.
+func applyScopeTransforms(v parser.VarDef, value string) string {
+	if len(v.Transforms) == 0 {
+		return value
+	}
+	transformed, err := parser.ApplyTransforms(value, v.Transforms)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: transform for $%s: %v\n", v.Name, err)
+		return value
+	}
+	return transformed
+}
+
+// validateScopeValue checks value against v's Type and Validate regex.
+func validateScopeValue(v parser.VarDef, value string) (string, error) {
+	switch v.Type {
+	case "", "string", "path", "choice", "enum", "file", "dir", "date", "regex", "secret":
+		// No format check beyond Validate below.
+	case "int":
+		if _, err := strconv.Atoi(value); err != nil {
+			return "", fmt.Errorf("%q is not a valid int", value)
+		}
+	case "float":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "", fmt.Errorf("%q is not a valid float", value)
+		}
+	case "bool":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return "", fmt.Errorf("%q is not a valid bool", value)
+		}
+	default:
+		return "", fmt.Errorf("unknown var type %q", v.Type)
+	}
+
+	if v.Validate != "" {
+		re, err := regexp.Compile(v.Validate)
+		if err != nil {
+			return "", fmt.Errorf("invalid validate pattern %q: %w", v.Validate, err)
+		}
+		if !re.MatchString(value) {
+			return "", fmt.Errorf("%q does not match validate pattern %q", value, v.Validate)
+		}
+	}
+
+	return value, nil
+}
+
+// ============================================================================
+// Stdin Prompts
+// ============================================================================
+
+// promptForValue blocks on stdin for v's value: the only choice when
+// config.GetAutoSelect() and Shell produced exactly one, a numbered pick
+// among several choices, a masked read for a Masked var, or a plain line
+// otherwise. Default fills the value when the read comes back empty.
+func (e *Executor) promptForValue(v parser.VarDef, choices []string) (string, error) {
+	if len(choices) == 1 && config.GetAutoSelect() {
+		return choices[0], nil
+	}
+	if len(choices) > 0 {
+		return e.promptChoice(v, choices)
+	}
+	if v.Masked {
+		return e.promptMasked(v)
+	}
+	return e.promptLine(v)
+}
+
+// promptLabel renders a var's prompt label, showing its Default (if any) the
+// way a shell prompt shows one.
+func promptLabel(v parser.VarDef) string {
+	if v.Default != "" {
+		return fmt.Sprintf("%s [%s]", v.Name, v.Default)
+	}
+	return v.Name
+}
+
+// applyDefault falls back to v.Default when value is empty.
+func applyDefault(v parser.VarDef, value string) string {
+	if value == "" {
+		return v.Default
+	}
+	return value
+}
+
+// promptLine reads a single line from stdin for v.
+func (e *Executor) promptLine(v parser.VarDef) (string, error) {
+	fmt.Fprintf(os.Stderr, "%s: ", promptLabel(v))
+	line, err := e.readStdinLine()
+	if err != nil {
+		return "", err
+	}
+	return applyDefault(v, line), nil
+}
+
+// promptChoice shows choices as a numbered list and reads a pick from stdin -
+// a number selects that choice, anything else is taken as a typed value (or
+// falls back to v.Default when empty).
+func (e *Executor) promptChoice(v parser.VarDef, choices []string) (string, error) {
+	fmt.Fprintf(os.Stderr, "%s:\n", promptLabel(v))
+	for i, c := range choices {
+		fmt.Fprintf(os.Stderr, "  %d) %s\n", i+1, c)
+	}
+	fmt.Fprint(os.Stderr, "> ")
+
+	line, err := e.readStdinLine()
+	if err != nil {
+		return "", err
+	}
+	if n, convErr := strconv.Atoi(line); convErr == nil && n >= 1 && n <= len(choices) {
+		return choices[n-1], nil
+	}
+	return applyDefault(v, line), nil
+}
+
+// promptMasked reads a line from stdin with terminal echo disabled, for a
+// Masked variable's value.
+func (e *Executor) promptMasked(v parser.VarDef) (string, error) {
+	fmt.Fprintf(os.Stderr, "%s (hidden): ", promptLabel(v))
+	restoreEcho := disableEcho()
+	line, err := e.readStdinLine()
+	restoreEcho()
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return applyDefault(v, line), nil
+}
+
+// disableEcho turns off terminal echo via "stty -echo" for the duration of a
+// masked prompt, restoring it afterward - no new terminal-handling
+// dependency needed since the package already shells out for terminal work
+// elsewhere (see TmuxClipboard). A non-terminal stdin (e.g.
+// in tests) just makes stty fail, in which case this is a no-op.
+func disableEcho() func() {
+	run := func(arg string) error {
+		cmd := exec.Command("stty", arg)
+		cmd.Stdin = os.Stdin
+		return cmd.Run()
+	}
+	if err := run("-echo"); err != nil {
+		return func() {}
+	}
+	return func() { run("echo") }
+}
+
+// readStdinLine reads one line from stdin, trimmed, tolerating EOF so a
+// prompt with no trailing newline (the last line of a piped input) still
+// comes back as a value instead of an error. It reuses one bufio.Reader
+// across every prompt in a ResolveScope call - a fresh reader per prompt
+// would silently drop whatever it had already buffered past the first "\n".
+func (e *Executor) readStdinLine() (string, error) {
+	if e.stdin == nil {
+		e.stdin = bufio.NewReader(os.Stdin)
+	}
+	line, err := e.stdin.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// splitNonEmptyLines splits s into its non-empty, trimmed lines.
+func splitNonEmptyLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			lines = append(lines, trimmed)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,89 @@
+package executor
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gubarz/cheatmd/internal/parser"
+)
+
+// withStdinInput replaces os.Stdin with a pipe preloaded with input for the
+// duration of a test, restoring the original afterward.
+func withStdinInput(t *testing.T, input string) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe: %v", err)
+	}
+	if _, err := w.WriteString(input); err != nil {
+		t.Fatalf("write stdin: %v", err)
+	}
+	w.Close()
+
+	orig := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() { os.Stdin = orig })
+}
+
+func TestBuildDisplayCommandMasksSecretVars(t *testing.T) {
+	cheat := &parser.Cheat{
+		Command: "curl -H 'Authorization: Bearer $token'",
+		Vars:    []parser.VarDef{{Name: "token", Masked: true}},
+		Scope:   map[string]string{"token": "supersecret"},
+	}
+	e := newTestExecutor(t)
+
+	if got := e.BuildFinalCommand(cheat); !strings.Contains(got, "supersecret") {
+		t.Errorf("BuildFinalCommand should keep the real value, got: %q", got)
+	}
+
+	display := e.BuildDisplayCommand(cheat)
+	if strings.Contains(display, "supersecret") {
+		t.Errorf("BuildDisplayCommand leaked the masked value: %q", display)
+	}
+	if !strings.Contains(display, maskedValuePlaceholder) {
+		t.Errorf("BuildDisplayCommand should show the mask placeholder, got: %q", display)
+	}
+}
+
+func TestResolveScopeAppliesDefaultOnEmptyInput(t *testing.T) {
+	withStdinInput(t, "\n")
+
+	cheat := &parser.Cheat{Vars: []parser.VarDef{{Name: "env", Default: "dev"}}}
+	e := newTestExecutor(t)
+
+	scope, err := e.ResolveScope(cheat)
+	if err != nil {
+		t.Fatalf("ResolveScope: %v", err)
+	}
+	if got := scope["env"]; got != "dev" {
+		t.Errorf("env = %q, want the Default %q", got, "dev")
+	}
+}
+
+func TestResolveScopeRejectsValueFailingValidate(t *testing.T) {
+	withStdinInput(t, "staging\n")
+
+	cheat := &parser.Cheat{Vars: []parser.VarDef{{Name: "env", Validate: "^(dev|prod)$"}}}
+	e := newTestExecutor(t)
+
+	if _, err := e.ResolveScope(cheat); err == nil {
+		t.Fatal("expected a validate error for \"staging\", got nil")
+	}
+}
+
+func TestResolveScopePicksNumberedChoiceFromShell(t *testing.T) {
+	withStdinInput(t, "2\n")
+
+	cheat := &parser.Cheat{Vars: []parser.VarDef{{Name: "color", Shell: "printf 'red\\nblue\\n'"}}}
+	e := newTestExecutor(t)
+
+	scope, err := e.ResolveScope(cheat)
+	if err != nil {
+		t.Fatalf("ResolveScope: %v", err)
+	}
+	if got := scope["color"]; got != "blue" {
+		t.Errorf("color = %q, want %q", got, "blue")
+	}
+}
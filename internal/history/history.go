@@ -0,0 +1,171 @@
+// Package history records a small amount of persistent usage data - how
+// often and how recently each cheat has been selected, and the values a
+// user previously typed for each named variable - so the picker can float
+// frequently/recently used cheats to the top (see History.FrecencyScore)
+// and variable resolution can prefill a variable with what was entered last
+// time, before falling back to its shell provider.
+package history
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// varHistoryMax caps how many distinct values are remembered per variable
+// name, oldest dropped first, so a variable fed unique data (timestamps,
+// generated IDs) doesn't grow history.json without bound.
+const varHistoryMax = 10
+
+// frecencyHalfLife is how long it takes a selection's weight in
+// FrecencyScore to decay by half, so a cheat used constantly last year
+// doesn't permanently outrank one used twice this morning.
+const frecencyHalfLife = 7 * 24 * time.Hour
+
+// Entry tracks one cheat's selection count and last-used time.
+type Entry struct {
+	Count int       `json:"count"`
+	Last  time.Time `json:"last"`
+}
+
+// History is a persistent store of cheat selection and variable value
+// usage, loaded once via Load and flushed to disk via Save after each
+// change that should survive the process.
+type History struct {
+	mu         sync.Mutex
+	path       string
+	maxEntries int
+
+	Cheats map[string]Entry    `json:"cheats"`         // Key(header, command) -> usage
+	Vars   map[string][]string `json:"vars,omitempty"` // var name -> values, most recent first
+}
+
+// Key identifies a cheat for RecordSelection/FrecencyScore by a short hash
+// of its header and command, stable across re-parses (unlike a pointer or
+// a file path, which a "reload" re-parse or a renamed file would change).
+func Key(header, command string) string {
+	sum := sha256.Sum256([]byte(header + "\x00" + command))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Load reads path's history.json, returning an empty History (not an
+// error) if the file doesn't exist yet, the same "first run has no state"
+// convention parser.NewParserWithCache uses for a missing cache file.
+func Load(path string, maxEntries int) *History {
+	h := &History{
+		path:       path,
+		maxEntries: maxEntries,
+		Cheats:     map[string]Entry{},
+		Vars:       map[string][]string{},
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return h
+	}
+	_ = json.Unmarshal(data, h)
+	if h.Cheats == nil {
+		h.Cheats = map[string]Entry{}
+	}
+	if h.Vars == nil {
+		h.Vars = map[string][]string{}
+	}
+	return h
+}
+
+// Save writes History to its path as indented JSON (a human can `cat` or
+// edit history.json directly), creating parent directories as needed.
+func (h *History) Save() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(h.path, data, 0o644)
+}
+
+// RecordSelection bumps key's count and last-used timestamp, evicting the
+// least-recently-used entries once len(Cheats) exceeds maxEntries.
+func (h *History) RecordSelection(key string, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	e := h.Cheats[key]
+	e.Count++
+	e.Last = now
+	h.Cheats[key] = e
+	h.evictLocked()
+}
+
+func (h *History) evictLocked() {
+	if h.maxEntries <= 0 || len(h.Cheats) <= h.maxEntries {
+		return
+	}
+	keys := make([]string, 0, len(h.Cheats))
+	for k := range h.Cheats {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return h.Cheats[keys[i]].Last.Before(h.Cheats[keys[j]].Last) })
+	for _, k := range keys[:len(keys)-h.maxEntries] {
+		delete(h.Cheats, k)
+	}
+}
+
+// FrecencyScore combines key's selection count and recency into a single
+// sort key, fzf --tiebreak style: each selection is worth 1 point, halved
+// every frecencyHalfLife since it was made. A key with no history scores 0.
+func (h *History) FrecencyScore(key string, now time.Time) float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	e, ok := h.Cheats[key]
+	if !ok {
+		return 0
+	}
+	halfLives := float64(now.Sub(e.Last)) / float64(frecencyHalfLife)
+	return float64(e.Count) * math.Pow(0.5, halfLives)
+}
+
+// RecordVarValue puts value at the front of name's history, removing any
+// earlier copy of it, capped at varHistoryMax.
+func (h *History) RecordVarValue(name, value string) {
+	if value == "" {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	values := h.Vars[name]
+	for i, v := range values {
+		if v == value {
+			values = append(values[:i], values[i+1:]...)
+			break
+		}
+	}
+	values = append([]string{value}, values...)
+	if len(values) > varHistoryMax {
+		values = values[:varHistoryMax]
+	}
+	h.Vars[name] = values
+}
+
+// VarValues returns name's previously entered values, most recent first.
+func (h *History) VarValues(name string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.Vars[name]...)
+}
+
+// Clear empties History in memory; the caller still has to call Save.
+func (h *History) Clear() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.Cheats = map[string]Entry{}
+	h.Vars = map[string][]string{}
+}
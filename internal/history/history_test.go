@@ -0,0 +1,76 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordSelectionAndFrecencyScore(t *testing.T) {
+	h := Load(filepath.Join(t.TempDir(), "history.json"), 0)
+	now := time.Now()
+
+	key := Key("Deploy", "kubectl apply -f deploy.yaml")
+	if got := h.FrecencyScore(key, now); got != 0 {
+		t.Fatalf("FrecencyScore for unrecorded key = %v, want 0", got)
+	}
+
+	h.RecordSelection(key, now)
+	if got := h.FrecencyScore(key, now); got != 1 {
+		t.Fatalf("FrecencyScore right after one selection = %v, want 1", got)
+	}
+
+	decayed := h.FrecencyScore(key, now.Add(frecencyHalfLife))
+	if decayed <= 0.4 || decayed >= 0.6 {
+		t.Fatalf("FrecencyScore one half-life later = %v, want ~0.5", decayed)
+	}
+}
+
+func TestRecordSelectionEvictsLeastRecentlyUsed(t *testing.T) {
+	h := Load(filepath.Join(t.TempDir(), "history.json"), 2)
+	now := time.Now()
+
+	h.RecordSelection("a", now.Add(-2*time.Hour))
+	h.RecordSelection("b", now.Add(-1*time.Hour))
+	h.RecordSelection("c", now)
+
+	if len(h.Cheats) != 2 {
+		t.Fatalf("len(Cheats) = %d, want 2", len(h.Cheats))
+	}
+	if _, ok := h.Cheats["a"]; ok {
+		t.Fatalf("Cheats[%q] should have been evicted", "a")
+	}
+}
+
+func TestRecordVarValueDedupsAndCaps(t *testing.T) {
+	h := Load(filepath.Join(t.TempDir(), "history.json"), 0)
+
+	h.RecordVarValue("region", "us-east-1")
+	h.RecordVarValue("region", "eu-west-1")
+	h.RecordVarValue("region", "us-east-1") // re-selecting moves it back to front
+
+	got := h.VarValues("region")
+	want := []string{"us-east-1", "eu-west-1"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("VarValues(%q) = %v, want %v", "region", got, want)
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "history.json")
+	h := Load(path, 0)
+	h.RecordSelection("abc123", time.Now())
+	h.RecordVarValue("region", "us-east-1")
+
+	if err := h.Save(); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	reloaded := Load(path, 0)
+	if reloaded.Cheats["abc123"].Count != 1 {
+		t.Fatalf("reloaded Cheats[%q].Count = %d, want 1", "abc123", reloaded.Cheats["abc123"].Count)
+	}
+	if got := reloaded.VarValues("region"); len(got) != 1 || got[0] != "us-east-1" {
+		t.Fatalf("reloaded VarValues(%q) = %v, want [us-east-1]", "region", got)
+	}
+}
@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const cacheTestCheat = "## Deploy\n\n```bash\ndeploy $env\n```\n\n<!-- cheat\nvar env = echo prod\n-->\n"
+
+func writeCacheTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll(%q): %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile(%q): %v", path, err)
+	}
+}
+
+func TestParseDirectoryCacheReusesUnchangedFile(t *testing.T) {
+	dir := t.TempDir()
+	md := filepath.Join(dir, "deploy.md")
+	writeCacheTestFile(t, md, cacheTestCheat)
+	cachePath := filepath.Join(t.TempDir(), "cache.gob")
+
+	p1 := NewParserWithCache(cachePath)
+	idx1, err := p1.ParseDirectory(dir)
+	if err != nil {
+		t.Fatalf("ParseDirectory: %v", err)
+	}
+	if len(idx1.Cheats) != 1 {
+		t.Fatalf("got %d cheats, want 1: %+v", len(idx1.Cheats), idx1.Cheats)
+	}
+
+	// A second Parser reading the same cache, without touching the file,
+	// should reuse the cached entry rather than re-parsing.
+	p2 := NewParserWithCache(cachePath)
+	idx2, err := p2.ParseDirectory(dir)
+	if err != nil {
+		t.Fatalf("ParseDirectory: %v", err)
+	}
+	if len(idx2.Cheats) != 1 {
+		t.Fatalf("got %d cheats from cached run, want 1: %+v", len(idx2.Cheats), idx2.Cheats)
+	}
+	if idx2.Cheats[0].Command != idx1.Cheats[0].Command {
+		t.Errorf("cached cheat Command = %q, want %q", idx2.Cheats[0].Command, idx1.Cheats[0].Command)
+	}
+}
+
+func TestParseDirectoryCacheInvalidatesOnChange(t *testing.T) {
+	dir := t.TempDir()
+	md := filepath.Join(dir, "deploy.md")
+	writeCacheTestFile(t, md, cacheTestCheat)
+	cachePath := filepath.Join(t.TempDir(), "cache.gob")
+
+	p1 := NewParserWithCache(cachePath)
+	if _, err := p1.ParseDirectory(dir); err != nil {
+		t.Fatalf("ParseDirectory: %v", err)
+	}
+
+	// Rewrite with different content and force the mtime forward, since
+	// some filesystems have coarse mtime resolution and a same-tick
+	// rewrite could otherwise keep the old fingerprint.
+	updated := "## Deploy\n\n```bash\ndeploy $env --force\n```\n\n<!-- cheat\nvar env = echo prod\n-->\n"
+	writeCacheTestFile(t, md, updated)
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(md, future, future); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+
+	p2 := NewParserWithCache(cachePath)
+	idx2, err := p2.ParseDirectory(dir)
+	if err != nil {
+		t.Fatalf("ParseDirectory: %v", err)
+	}
+	if len(idx2.Cheats) != 1 {
+		t.Fatalf("got %d cheats, want 1: %+v", len(idx2.Cheats), idx2.Cheats)
+	}
+	if idx2.Cheats[0].Command != "deploy $env --force" {
+		t.Errorf("Command = %q, want the updated command (cache should have been invalidated)", idx2.Cheats[0].Command)
+	}
+}
+
+func TestIncludesUnchangedDetectsIncludeEdit(t *testing.T) {
+	dir := t.TempDir()
+	inc := filepath.Join(dir, "shared.md")
+	writeCacheTestFile(t, inc, "x")
+	info, err := os.Stat(inc)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+
+	entry := cacheEntry{IncludeFingerprints: map[string]fileFingerprint{inc: fingerprintOf(info)}}
+	if !includesUnchanged(entry) {
+		t.Fatal("includesUnchanged = false right after stamping the fingerprint, want true")
+	}
+
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(inc, future, future); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+	writeCacheTestFile(t, inc, "xy")
+	if includesUnchanged(entry) {
+		t.Fatal("includesUnchanged = true after the included file changed, want false")
+	}
+}
+
+func TestSaveAndLoadIndexRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	md := filepath.Join(dir, "deploy.md")
+	writeCacheTestFile(t, md, cacheTestCheat)
+	cachePath := filepath.Join(t.TempDir(), "cache.gob")
+
+	p1 := NewParserWithCache(cachePath)
+	if _, err := p1.ParseDirectory(dir); err != nil {
+		t.Fatalf("ParseDirectory: %v", err)
+	}
+	if err := p1.SaveIndex(cachePath); err != nil {
+		t.Fatalf("SaveIndex: %v", err)
+	}
+
+	p2 := NewParser()
+	idx, err := p2.LoadIndex(cachePath)
+	if err != nil {
+		t.Fatalf("LoadIndex: %v", err)
+	}
+	if len(idx.Cheats) != 1 {
+		t.Fatalf("got %d cheats from LoadIndex, want 1: %+v", len(idx.Cheats), idx.Cheats)
+	}
+}
@@ -0,0 +1,57 @@
+package parser
+
+// ============================================================================
+// Parse Diagnostics
+// ============================================================================
+//
+// Malformed input used to vanish silently - an unknown DSL line was just
+// skipped, an unclosed cheat block quietly dropped whatever it had
+// buffered, an unresolved import failed only much later at execution time
+// with no file/line to point at. Diagnostic gives every one of those a
+// place to surface to instead, collected on CheatIndex.Diagnostics so a
+// caller can print them, and (with Parser.SetStrict) turn any of them into
+// a hard parse error.
+
+// Severity classifies how serious a Diagnostic is.
+type Severity int
+
+const (
+	// SeverityWarning flags something recoverable: parsing continued, but
+	// the author probably didn't get the result they intended.
+	SeverityWarning Severity = iota
+	// SeverityError flags something that cost data: a block, var, or
+	// reference was dropped rather than merely suspicious.
+	SeverityError
+)
+
+// String renders s for display, e.g. in a CLI diagnostic line.
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Diagnostic codes, one per distinct condition below.
+const (
+	CodeUnterminatedBlock = "unterminated-block"
+	CodeUnknownStatement  = "unknown-statement"
+	CodeUnmatchedElif     = "unmatched-elif"
+	CodeUnmatchedElse     = "unmatched-else"
+	CodeUnmatchedFi       = "unmatched-fi"
+	CodeUnclosedIf        = "unclosed-if"
+	CodeDuplicateExport   = "duplicate-export"
+	CodeUnresolvedImport  = "unresolved-import"
+	CodeIOError           = "io-error"
+)
+
+// Diagnostic is one issue found while parsing, pointing at the file/line
+// (and, where meaningful, column) it came from.
+type Diagnostic struct {
+	File     string
+	Line     int
+	Col      int
+	Severity Severity
+	Code     string
+	Message  string
+}
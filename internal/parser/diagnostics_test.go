@@ -0,0 +1,79 @@
+package parser
+
+import "testing"
+
+func TestSeverityString(t *testing.T) {
+	tests := []struct {
+		sev  Severity
+		want string
+	}{
+		{SeverityWarning, "warning"},
+		{SeverityError, "error"},
+	}
+	for _, tt := range tests {
+		if got := tt.sev.String(); got != tt.want {
+			t.Errorf("Severity(%d).String() = %q, want %q", tt.sev, got, tt.want)
+		}
+	}
+}
+
+func TestAddModuleFlagsDuplicateExport(t *testing.T) {
+	idx := NewCheatIndex()
+	idx.addModule("deploy", &Module{Name: "deploy", File: "a.md"})
+	idx.addModule("deploy", &Module{Name: "deploy", File: "b.md"})
+
+	if len(idx.Duplicates) != 1 {
+		t.Fatalf("got %d duplicates, want 1: %+v", len(idx.Duplicates), idx.Duplicates)
+	}
+	if idx.Duplicates[0].File1 != "a.md" || idx.Duplicates[0].File2 != "b.md" {
+		t.Errorf("Duplicates[0] = %+v, want File1=a.md File2=b.md", idx.Duplicates[0])
+	}
+
+	var found bool
+	for _, d := range idx.Diagnostics {
+		if d.Code == CodeDuplicateExport {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Diagnostics = %+v, want a %s entry", idx.Diagnostics, CodeDuplicateExport)
+	}
+}
+
+func TestAddModuleSameFileIsNotADuplicate(t *testing.T) {
+	idx := NewCheatIndex()
+	idx.addModule("deploy", &Module{Name: "deploy", File: "a.md"})
+	idx.addModule("deploy", &Module{Name: "deploy", File: "a.md"})
+
+	if len(idx.Duplicates) != 0 {
+		t.Errorf("got %d duplicates for re-registering the same file, want 0: %+v", len(idx.Duplicates), idx.Duplicates)
+	}
+}
+
+func TestCheckImportsFlagsUnresolvedImport(t *testing.T) {
+	idx := NewCheatIndex()
+	cheat := NewCheat("a.md", "Deploy")
+	cheat.Imports = []string{"missing"}
+	idx.AddCheat(cheat)
+
+	idx.checkImports()
+
+	if len(idx.Diagnostics) != 1 || idx.Diagnostics[0].Code != CodeUnresolvedImport {
+		t.Fatalf("Diagnostics = %+v, want exactly one %s", idx.Diagnostics, CodeUnresolvedImport)
+	}
+}
+
+func TestCheckImportsAllowsResolvedImport(t *testing.T) {
+	idx := NewCheatIndex()
+	idx.addModule("shared", &Module{Name: "shared", File: "shared.md"})
+
+	cheat := NewCheat("a.md", "Deploy")
+	cheat.Imports = []string{"shared"}
+	idx.AddCheat(cheat)
+
+	idx.checkImports()
+
+	if len(idx.Diagnostics) != 0 {
+		t.Errorf("Diagnostics = %+v, want none for a resolved import", idx.Diagnostics)
+	}
+}
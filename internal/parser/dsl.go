@@ -0,0 +1,605 @@
+package parser
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Every concrete Node implementation has to be registered with gob so a
+// Cheat.Body slice (stored as the Node interface) survives the on-disk
+// parse cache's SaveIndex/LoadIndex round trip.
+func init() {
+	gob.Register(&AssignNode{})
+	gob.Register(&ImportNode{})
+	gob.Register(&ExportNode{})
+	gob.Register(&HookNode{})
+	gob.Register(&PipelineNode{})
+	gob.Register(&InputNode{})
+	gob.Register(&OutputNode{})
+	gob.Register(&IfNode{})
+}
+
+// ============================================================================
+// Cheat Block AST
+// ============================================================================
+//
+// parseCheatDSL used to track a single flat "if ... fi" window and stamp its
+// condition string onto every VarDef declared inside, which meant
+// "if $env == prod elif $env == staging else ... fi" and nested ifs weren't
+// representable. parseCheatBlock instead builds a real block AST - IfNode
+// with Then/Elifs/Else arms, tracked via an ifStack exactly like a makefile
+// parser's conditional directives - and flattenBody walks it to populate
+// Cheat.Vars/Export/Imports/etc. for backwards compatibility. Evaluators
+// that want to skip an untaken branch's Shell generators entirely (rather
+// than evaluating VarDef.Condition per var) can walk Cheat.Body instead.
+
+// Node is one statement inside a cheat block.
+type Node interface {
+	Line() int
+}
+
+// baseNode carries the 1-based source line, in the node's owning file, that
+// every node is attached to, for downstream diagnostics.
+type baseNode struct {
+	line int
+}
+
+// Line returns the 1-based source line this node started on.
+func (b baseNode) Line() int { return b.line }
+
+// AssignNode is a "var NAME[:type] = ...", ":= ...", "@ ..." or bare
+// prompt-only "var NAME" declaration.
+type AssignNode struct {
+	baseNode
+	Var VarDef
+}
+
+// ImportNode is an "import MODULE" line.
+type ImportNode struct {
+	baseNode
+	Module string
+}
+
+// Unlike ImportNode (which references another cheat's exported module by
+// name at resolve time), "include RELATIVE-PATH" is expanded at parse
+// time: parseCheatBlock splices the target file's own cheat-block nodes in
+// at this position (see blockBuilder.handleInclude), so there's no
+// dedicated IncludeNode - an include never itself survives into Cheat.Body.
+
+// ExportNode is an "export NAME" line.
+type ExportNode struct {
+	baseNode
+	Name string
+}
+
+// HookNode is a "pre_hook ..." or "post_hook ..." line.
+type HookNode struct {
+	baseNode
+	Post    bool
+	Command string
+}
+
+// PipelineNode is a standalone "pipeline" line, marking the enclosing
+// cheat's Command as a newline-separated chain of steps (see
+// Cheat.Pipeline) rather than one opaque script. It carries no data of its
+// own - flattenBody just sets Cheat.Pipeline to true.
+type PipelineNode struct {
+	baseNode
+}
+
+// InputNode is an "input NAME type=TYPE" line.
+type InputNode struct {
+	baseNode
+	Input InputDef
+}
+
+// OutputNode is an "output NAME type=TYPE [path=PATH]" line.
+type OutputNode struct {
+	baseNode
+	Output OutputDef
+}
+
+// ElifBranch is one "elif COND" arm of an IfNode.
+type ElifBranch struct {
+	Cond string
+	Body []Node
+}
+
+// IfNode is an "if COND ... elif COND ... else ... fi" block, arbitrarily
+// nestable. Then holds the nodes under the initial condition; Elifs holds
+// zero or more "elif" arms in source order; Else is nil if there was no
+// "else" arm (as opposed to an empty one).
+type IfNode struct {
+	baseNode
+	Cond  string
+	Then  []Node
+	Elifs []ElifBranch
+	Else  []Node
+}
+
+// ----------------------------------------------------------------------------
+// Block parser
+// ----------------------------------------------------------------------------
+
+// ifFrame tracks one open "if" while parseCheatBlock walks lines: node is
+// the IfNode being built, and current points at whichever of its
+// Then/Elifs[i].Body/Else is the active target for appendNode.
+type ifFrame struct {
+	node    *IfNode
+	current *[]Node
+}
+
+// blockBuilder accumulates nodes for one cheat block's DSL content.
+type blockBuilder struct {
+	body  []Node
+	stack []*ifFrame
+}
+
+// appendNode adds n to whichever list is currently open: the innermost
+// if/elif/else arm, or the top-level body if there's no open if.
+func (b *blockBuilder) appendNode(n Node) {
+	if len(b.stack) == 0 {
+		b.body = append(b.body, n)
+		return
+	}
+	top := b.stack[len(b.stack)-1]
+	*top.current = append(*top.current, n)
+}
+
+// currentList returns the list appendNode would add to, for the
+// default/validate/secret directives to search backwards through.
+func (b *blockBuilder) currentList() []Node {
+	if len(b.stack) == 0 {
+		return b.body
+	}
+	return *b.stack[len(b.stack)-1].current
+}
+
+// setVarField applies set to the most recently declared var named name in
+// the currently open list, letting a "default"/"validate"/"secret"
+// directive modify the var declaration that precedes it without its own
+// assignment syntax. A directive can only reach a var in the same branch
+// it was written in - reaching across an "if" would make takenness
+// ambiguous.
+func (b *blockBuilder) setVarField(name string, set func(*VarDef)) {
+	list := b.currentList()
+	for i := len(list) - 1; i >= 0; i-- {
+		if a, ok := list[i].(*AssignNode); ok && a.Var.Name == name {
+			set(&a.Var)
+			return
+		}
+	}
+}
+
+// dslContext carries per-file state across an "include" chain: path
+// resolves relative include targets, onStack detects cycles (a file
+// currently being expanded including itself, directly or transitively),
+// seen dedupes the accumulated includes list, includes collects every
+// successfully-included file so the caller (Cheat.Includes) can use it to
+// invalidate a cache entry when one of them changes, blockLine is the file
+// line the current cheat block's opening tag started on, or the line before
+// it for a single-line block, so that DSL content line N is file line
+// blockLine+N (per-statement line numbers are reported in absolute,
+// file-relative terms), and diags
+// is where parseCheatBlock appends any diagnostics it finds along the way.
+type dslContext struct {
+	path      string
+	onStack   map[string]bool
+	seen      map[string]bool
+	includes  *[]string
+	blockLine int
+	diags     *[]Diagnostic
+}
+
+// diag appends a Diagnostic at the given absolute file line against
+// ctx.path. A nil ctx.diags (e.g. a Parser built without an index wired up
+// yet) makes this a no-op rather than a nil-pointer panic.
+func (ctx *dslContext) diag(line int, severity Severity, code, message string) {
+	if ctx.diags == nil {
+		return
+	}
+	*ctx.diags = append(*ctx.diags, Diagnostic{
+		File: ctx.path, Line: line, Severity: severity, Code: code, Message: message,
+	})
+}
+
+// handleInclude expands an "include REL" line: it resolves rel against
+// ctx.path's directory, reads the target's first cheat block, and splices
+// its parsed nodes directly into the current position - so included
+// var/import declarations behave exactly as if they were written inline,
+// including inheriting whatever if/elif/else branch the include itself is
+// in. A cycle or an unreadable target is dropped silently, without a
+// diagnostic.
+func (b *blockBuilder) handleInclude(ctx *dslContext, rel string) {
+	target := filepath.Clean(filepath.Join(filepath.Dir(ctx.path), rel))
+	if ctx.onStack[target] {
+		return
+	}
+	content, blockLine, err := firstCheatBlockContent(target)
+	if err != nil {
+		return
+	}
+	if !ctx.seen[target] {
+		ctx.seen[target] = true
+		*ctx.includes = append(*ctx.includes, target)
+	}
+
+	ctx.onStack[target] = true
+	nested := parseCheatBlock(content, &dslContext{path: target, onStack: ctx.onStack, seen: ctx.seen, includes: ctx.includes, blockLine: blockLine, diags: ctx.diags})
+	delete(ctx.onStack, target)
+
+	for _, n := range nested {
+		b.appendNode(n)
+	}
+}
+
+// firstCheatBlockContent reads path and returns the DSL content of its
+// first <!-- cheat --> block (single-line or multi-line), independent of
+// whatever code block it might otherwise be attached to, along with the
+// file line its opening tag started on (for diagnostics raised while
+// parsing the included content) - or, for a single-line block whose
+// content sits on the tag's own line, the line before it, matching
+// processCheatComment. This is how "include" locates a shared block of
+// var/import declarations in another file.
+func firstCheatBlockContent(path string) (string, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, err
+	}
+	lines := strings.Split(string(data), "\n")
+	for i, raw := range lines {
+		line := []byte(strings.TrimRight(raw, "\r"))
+		if content, ok := parseCheatSingleLine(line); ok {
+			return content, i, nil
+		}
+		if !isCheatStart(line) {
+			continue
+		}
+		var buf strings.Builder
+		for _, raw := range lines[i+1:] {
+			l := []byte(strings.TrimRight(raw, "\r"))
+			if isCheatEnd(l) {
+				return buf.String(), i + 1, nil
+			}
+			buf.Write(l)
+			buf.WriteByte('\n')
+		}
+		return buf.String(), i + 1, nil // unterminated block - best effort with what was collected
+	}
+	return "", 0, fmt.Errorf("parser: no cheat block found in %s", path)
+}
+
+// parseCheatBlock parses a cheat block's DSL content into an AST,
+// expanding any "include" directives inline via ctx.
+func parseCheatBlock(content string, ctx *dslContext) []Node {
+	lines, lineNos := joinContinuationLines(strings.Split(content, "\n"))
+	b := &blockBuilder{}
+
+	for i, raw := range lines {
+		line := strings.TrimSpace(raw)
+		lineNo := ctx.blockLine + lineNos[i]
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if matches := patterns.ifStart.FindStringSubmatch(line); matches != nil {
+			node := &IfNode{baseNode: baseNode{lineNo}, Cond: strings.TrimSpace(matches[1])}
+			b.appendNode(node)
+			b.stack = append(b.stack, &ifFrame{node: node, current: &node.Then})
+			continue
+		}
+
+		if matches := patterns.elifStart.FindStringSubmatch(line); matches != nil {
+			if len(b.stack) == 0 {
+				ctx.diag(lineNo, SeverityError, CodeUnmatchedElif, "elif with no open if")
+				continue
+			}
+			top := b.stack[len(b.stack)-1]
+			top.node.Elifs = append(top.node.Elifs, ElifBranch{Cond: strings.TrimSpace(matches[1])})
+			top.current = &top.node.Elifs[len(top.node.Elifs)-1].Body
+			continue
+		}
+
+		if patterns.elseStart.MatchString(line) {
+			if len(b.stack) == 0 {
+				ctx.diag(lineNo, SeverityError, CodeUnmatchedElse, "else with no open if")
+				continue
+			}
+			top := b.stack[len(b.stack)-1]
+			top.node.Else = []Node{}
+			top.current = &top.node.Else
+			continue
+		}
+
+		if patterns.ifEnd.MatchString(line) {
+			if len(b.stack) > 0 {
+				b.stack = b.stack[:len(b.stack)-1]
+			} else {
+				ctx.diag(lineNo, SeverityError, CodeUnmatchedFi, "fi with no open if")
+			}
+			continue
+		}
+
+		if matches := patterns.export.FindStringSubmatch(line); matches != nil {
+			b.appendNode(&ExportNode{baseNode: baseNode{lineNo}, Name: matches[1]})
+			continue
+		}
+
+		if matches := patterns.importStmt.FindStringSubmatch(line); matches != nil {
+			b.appendNode(&ImportNode{baseNode: baseNode{lineNo}, Module: matches[1]})
+			continue
+		}
+
+		if matches := patterns.includeStmt.FindStringSubmatch(line); matches != nil {
+			b.handleInclude(ctx, strings.TrimSpace(matches[1]))
+			continue
+		}
+
+		if matches := patterns.preHook.FindStringSubmatch(line); matches != nil {
+			b.appendNode(&HookNode{baseNode: baseNode{lineNo}, Command: strings.TrimSpace(matches[1])})
+			continue
+		}
+
+		if matches := patterns.postHook.FindStringSubmatch(line); matches != nil {
+			b.appendNode(&HookNode{baseNode: baseNode{lineNo}, Post: true, Command: strings.TrimSpace(matches[1])})
+			continue
+		}
+
+		if matches := patterns.inputDef.FindStringSubmatch(line); matches != nil {
+			b.appendNode(&InputNode{baseNode: baseNode{lineNo}, Input: InputDef{Name: matches[1], Type: matches[2]}})
+			continue
+		}
+
+		if matches := patterns.outputDef.FindStringSubmatch(line); matches != nil {
+			b.appendNode(&OutputNode{baseNode: baseNode{lineNo}, Output: OutputDef{Name: matches[1], Type: matches[2], Path: matches[3]}})
+			continue
+		}
+
+		// Check for secret-backed vars (@) before shell/literal assignment
+		if matches := patterns.varDefSource.FindStringSubmatch(line); matches != nil {
+			ref, transforms := splitTransforms(matches[3])
+			b.appendNode(&AssignNode{baseNode: baseNode{lineNo}, Var: VarDef{
+				Name:       matches[1],
+				Type:       matches[2],
+				Source:     strings.TrimSpace(ref),
+				Transforms: transforms,
+			}})
+			continue
+		}
+
+		// Check for literal assignment first (:=) before shell assignment (=)
+		if matches := patterns.varDefLiteral.FindStringSubmatch(line); matches != nil {
+			b.appendNode(&AssignNode{baseNode: baseNode{lineNo}, Var: ParseVarDefWithCondition(matches[1], matches[3], "", matches[2], true)})
+			continue
+		}
+
+		if matches := patterns.varDef.FindStringSubmatch(line); matches != nil {
+			b.appendNode(&AssignNode{baseNode: baseNode{lineNo}, Var: ParseVarDefWithCondition(matches[1], matches[3], "", matches[2], false)})
+			continue
+		}
+
+		// default/validate/secret modify the most recently declared var with
+		// that name, so they're written just below its "var" line, e.g.:
+		//   var env:choice = echo dev staging prod
+		//   default env dev
+		//   validate env ^(dev|staging|prod)$
+		if matches := patterns.varDefault.FindStringSubmatch(line); matches != nil {
+			b.setVarField(matches[1], func(v *VarDef) { v.Default = strings.TrimSpace(matches[2]) })
+			continue
+		}
+
+		if matches := patterns.varValidate.FindStringSubmatch(line); matches != nil {
+			b.setVarField(matches[1], func(v *VarDef) { v.Validate = strings.TrimSpace(matches[2]) })
+			continue
+		}
+
+		if matches := patterns.varSecret.FindStringSubmatch(line); matches != nil {
+			b.setVarField(matches[1], func(v *VarDef) { v.Masked = true })
+			continue
+		}
+
+		// choices/range constrain a "type: enum"/"type: int|float" var, written
+		// alongside default/validate/secret, e.g.:
+		//   var env:enum = echo dev staging prod
+		//   choices env dev,staging,prod
+		if matches := patterns.varChoices.FindStringSubmatch(line); matches != nil {
+			choices := strings.Split(matches[2], ",")
+			for i := range choices {
+				choices[i] = strings.TrimSpace(choices[i])
+			}
+			b.setVarField(matches[1], func(v *VarDef) { v.Choices = choices })
+			continue
+		}
+
+		if matches := patterns.varRange.FindStringSubmatch(line); matches != nil {
+			min, max := matches[2], matches[3]
+			b.setVarField(matches[1], func(v *VarDef) { v.Min, v.Max = min, max })
+			continue
+		}
+
+		// "pipeline" marks the cheat's Command as an ordered chain of shell
+		// steps (one per line) instead of one opaque script; see
+		// Cheat.Pipeline and ui.executePipelineSteps.
+		if patterns.pipelineMarker.MatchString(line) {
+			b.appendNode(&PipelineNode{baseNode: baseNode{lineNo}})
+			continue
+		}
+
+		// Check for prompt-only var (no assignment)
+		if matches := patterns.varDefPrompt.FindStringSubmatch(line); matches != nil {
+			var transforms []string
+			if matches[3] != "" {
+				transforms = strings.Split(matches[3], "|")
+				for i, t := range transforms {
+					transforms[i] = strings.TrimSpace(t)
+				}
+			}
+			b.appendNode(&AssignNode{baseNode: baseNode{lineNo}, Var: VarDef{
+				Name:       matches[1],
+				Type:       matches[2],
+				Transforms: transforms,
+				// Shell and Literal both empty = prompt-only
+			}})
+			continue
+		}
+
+		ctx.diag(lineNo, SeverityWarning, CodeUnknownStatement, fmt.Sprintf("unrecognized cheat block statement: %q", line))
+	}
+
+	for _, frame := range b.stack {
+		ctx.diag(frame.node.Line(), SeverityError, CodeUnclosedIf, "if with no matching fi")
+	}
+
+	return b.body
+}
+
+// ----------------------------------------------------------------------------
+// Flattening
+// ----------------------------------------------------------------------------
+
+// flattenBody walks nodes and populates cheat's flattened fields (Vars,
+// Export, Imports, PreHook, PostHook, Inputs, Outputs), combining ambient -
+// the conjunction of every enclosing if/elif/else condition - onto each
+// AssignNode's Condition so existing per-var evaluators keep working
+// unchanged.
+func flattenBody(nodes []Node, ambient []string, cheat *Cheat) {
+	cond := joinConditions(ambient)
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *AssignNode:
+			v := n.Var
+			v.Condition = cond
+			cheat.Vars = append(cheat.Vars, v)
+		case *ExportNode:
+			cheat.Export = n.Name
+		case *ImportNode:
+			cheat.Imports = append(cheat.Imports, n.Module)
+		case *HookNode:
+			if n.Post {
+				cheat.PostHook = n.Command
+			} else {
+				cheat.PreHook = n.Command
+			}
+		case *InputNode:
+			cheat.Inputs = append(cheat.Inputs, n.Input)
+		case *OutputNode:
+			cheat.Outputs = append(cheat.Outputs, n.Output)
+		case *PipelineNode:
+			cheat.Pipeline = true
+		case *IfNode:
+			flattenIf(n, ambient, cheat)
+		}
+	}
+}
+
+// flattenIf flattens one if/elif/else: the Then branch is ambient && Cond,
+// each elif is ambient && (negation of every earlier cond in this chain) &&
+// its own cond, and else is ambient && negation of every cond in the chain -
+// the usual exclusive elif/else semantics, expressed in condexpr syntax so
+// Evaluate doesn't need to change.
+func flattenIf(n *IfNode, ambient []string, cheat *Cheat) {
+	flattenBody(n.Then, withConds(ambient, n.Cond), cheat)
+
+	negated := []string{negateCond(n.Cond)}
+	for _, elif := range n.Elifs {
+		flattenBody(elif.Body, withConds(ambient, append(append([]string{}, negated...), elif.Cond)...), cheat)
+		negated = append(negated, negateCond(elif.Cond))
+	}
+
+	if n.Else != nil {
+		flattenBody(n.Else, withConds(ambient, negated...), cheat)
+	}
+}
+
+// importLines walks nodes (a Cheat.Body) and returns the source line of
+// each "import MODULE" statement, keyed by module name, for attaching an
+// accurate line number to an unresolved-import diagnostic.
+func importLines(nodes []Node) map[string]int {
+	lines := make(map[string]int)
+	var walk func([]Node)
+	walk = func(nodes []Node) {
+		for _, node := range nodes {
+			switch n := node.(type) {
+			case *ImportNode:
+				lines[n.Module] = n.Line()
+			case *IfNode:
+				walk(n.Then)
+				for _, elif := range n.Elifs {
+					walk(elif.Body)
+				}
+				walk(n.Else)
+			}
+		}
+	}
+	walk(nodes)
+	return lines
+}
+
+// withConds returns a new slice combining ambient with extra, without
+// mutating ambient's backing array (which other branches also read from).
+func withConds(ambient []string, extra ...string) []string {
+	out := make([]string, 0, len(ambient)+len(extra))
+	out = append(out, ambient...)
+	out = append(out, extra...)
+	return out
+}
+
+// negateCond wraps cond in condexpr's "!(...)" negation.
+func negateCond(cond string) string {
+	if cond == "" {
+		return ""
+	}
+	return "!(" + cond + ")"
+}
+
+// joinConditions ANDs every non-empty condition fragment together,
+// parenthesizing each so condexpr's precedence can't be changed by
+// whatever operators the fragment itself contains.
+func joinConditions(conds []string) string {
+	var wrapped []string
+	for _, c := range conds {
+		if c == "" {
+			continue
+		}
+		wrapped = append(wrapped, "("+c+")")
+	}
+	return strings.Join(wrapped, " && ")
+}
+
+// joinContinuationLines joins lines that end with backslash (line
+// continuation), returning the joined lines together with the 1-based
+// source line each one started at, so nodes can carry accurate positions.
+func joinContinuationLines(lines []string) ([]string, []int) {
+	var result []string
+	var starts []int
+	var current strings.Builder
+	start := 0
+
+	for i, line := range lines {
+		if current.Len() == 0 {
+			start = i + 1
+		}
+		trimmed := strings.TrimRight(line, " \t")
+		if strings.HasSuffix(trimmed, "\\") {
+			// Line continues - remove backslash and append
+			current.WriteString(strings.TrimSuffix(trimmed, "\\"))
+		} else {
+			// Line ends - append and flush
+			current.WriteString(line)
+			result = append(result, current.String())
+			starts = append(starts, start)
+			current.Reset()
+		}
+	}
+
+	// Don't forget any remaining content
+	if current.Len() > 0 {
+		result = append(result, current.String())
+		starts = append(starts, start)
+	}
+
+	return result, starts
+}
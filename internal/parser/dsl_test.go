@@ -0,0 +1,141 @@
+package parser
+
+import "testing"
+
+// parseBlock is a small helper wrapping parseCheatBlock with a bare
+// dslContext, for tests that only care about the resulting AST/diagnostics
+// and don't exercise "include".
+func parseBlock(t *testing.T, content string) ([]Node, []Diagnostic) {
+	t.Helper()
+	var diags []Diagnostic
+	nodes := parseCheatBlock(content, &dslContext{diags: &diags})
+	return nodes, diags
+}
+
+func TestParseCheatBlockIfElifElse(t *testing.T) {
+	content := `
+var env:choice = echo dev staging prod
+if $env == prod
+var replicas = echo 5
+elif $env == staging
+var replicas = echo 2
+else
+var replicas = echo 1
+fi
+`
+	nodes, diags := parseBlock(t, content)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", diags)
+	}
+	if len(nodes) != 2 {
+		t.Fatalf("got %d top-level nodes, want 2 (var env, if): %+v", len(nodes), nodes)
+	}
+
+	ifNode, ok := nodes[1].(*IfNode)
+	if !ok {
+		t.Fatalf("nodes[1] = %T, want *IfNode", nodes[1])
+	}
+	if ifNode.Cond != "$env == prod" {
+		t.Errorf("ifNode.Cond = %q, want %q", ifNode.Cond, "$env == prod")
+	}
+	if len(ifNode.Then) != 1 || len(ifNode.Elifs) != 1 || len(ifNode.Else) != 1 {
+		t.Fatalf("ifNode = %+v, want 1 Then/1 Elif/1 Else node", ifNode)
+	}
+	if ifNode.Elifs[0].Cond != "$env == staging" {
+		t.Errorf("ifNode.Elifs[0].Cond = %q, want %q", ifNode.Elifs[0].Cond, "$env == staging")
+	}
+}
+
+func TestParseCheatBlockNestedIf(t *testing.T) {
+	content := `
+if $env == prod
+if $region == us
+var zone = echo us-east
+fi
+fi
+`
+	nodes, _ := parseBlock(t, content)
+	if len(nodes) != 1 {
+		t.Fatalf("got %d top-level nodes, want 1", len(nodes))
+	}
+	outer, ok := nodes[0].(*IfNode)
+	if !ok {
+		t.Fatalf("nodes[0] = %T, want *IfNode", nodes[0])
+	}
+	if len(outer.Then) != 1 {
+		t.Fatalf("outer.Then = %+v, want 1 nested IfNode", outer.Then)
+	}
+	if _, ok := outer.Then[0].(*IfNode); !ok {
+		t.Fatalf("outer.Then[0] = %T, want *IfNode", outer.Then[0])
+	}
+}
+
+func TestParseCheatBlockUnmatchedElifElseFi(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		code    string
+	}{
+		{"elif with no if", "elif $env == prod\n", CodeUnmatchedElif},
+		{"else with no if", "else\n", CodeUnmatchedElse},
+		{"fi with no if", "fi\n", CodeUnmatchedFi},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, diags := parseBlock(t, tt.content)
+			if len(diags) != 1 || diags[0].Code != tt.code {
+				t.Fatalf("diags = %+v, want exactly one %s", diags, tt.code)
+			}
+		})
+	}
+}
+
+func TestParseCheatBlockUnclosedIf(t *testing.T) {
+	_, diags := parseBlock(t, "if $env == prod\nvar x = echo 1\n")
+	if len(diags) != 1 || diags[0].Code != CodeUnclosedIf {
+		t.Fatalf("diags = %+v, want exactly one %s", diags, CodeUnclosedIf)
+	}
+}
+
+func TestFlattenIfElifElseConditions(t *testing.T) {
+	content := `
+if $env == prod
+var replicas = echo 5
+elif $env == staging
+var replicas = echo 2
+else
+var replicas = echo 1
+fi
+`
+	nodes, _ := parseBlock(t, content)
+	cheat := &Cheat{}
+	flattenBody(nodes, nil, cheat)
+
+	if len(cheat.Vars) != 3 {
+		t.Fatalf("got %d vars, want 3: %+v", len(cheat.Vars), cheat.Vars)
+	}
+
+	want := []string{
+		"($env == prod)",
+		"(!($env == prod)) && ($env == staging)",
+		"(!($env == prod)) && (!($env == staging))",
+	}
+	for i, v := range cheat.Vars {
+		if v.Condition != want[i] {
+			t.Errorf("cheat.Vars[%d].Condition = %q, want %q", i, v.Condition, want[i])
+		}
+	}
+}
+
+func TestFlattenIfNoElse(t *testing.T) {
+	nodes, _ := parseBlock(t, "if $env == prod\nvar replicas = echo 5\nfi\n")
+	cheat := &Cheat{}
+	flattenBody(nodes, nil, cheat)
+
+	if len(cheat.Vars) != 1 {
+		t.Fatalf("got %d vars, want 1 (no else arm => no var): %+v", len(cheat.Vars), cheat.Vars)
+	}
+	if cheat.Vars[0].Condition != "($env == prod)" {
+		t.Errorf("Condition = %q, want %q", cheat.Vars[0].Condition, "($env == prod)")
+	}
+}
@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIncludeSplicesTargetVars(t *testing.T) {
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "shared.md")
+	writeCacheTestFile(t, shared, "<!-- cheat\nvar region = echo us-east\n-->\n")
+
+	main := filepath.Join(dir, "main.md")
+	writeCacheTestFile(t, main, "## Deploy\n\n```bash\ndeploy $env $region\n```\n\n<!-- cheat\nvar env = echo prod\ninclude shared.md\n-->\n")
+
+	idx, err := NewParser().ParseSingleFile(main)
+	if err != nil {
+		t.Fatalf("ParseSingleFile: %v", err)
+	}
+	if len(idx.Cheats) != 1 {
+		t.Fatalf("got %d cheats, want 1: %+v", len(idx.Cheats), idx.Cheats)
+	}
+	cheat := idx.Cheats[0]
+
+	var names []string
+	for _, v := range cheat.Vars {
+		names = append(names, v.Name)
+	}
+	if len(names) != 2 || names[0] != "env" || names[1] != "region" {
+		t.Fatalf("Vars = %+v, want [env region]", names)
+	}
+	if len(cheat.Includes) != 1 || cheat.Includes[0] != shared {
+		t.Errorf("Includes = %+v, want [%s]", cheat.Includes, shared)
+	}
+}
+
+func TestIncludeCycleIsDroppedNotInfinite(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.md")
+	b := filepath.Join(dir, "b.md")
+	writeCacheTestFile(t, a, "## A\n\n```bash\ncmd\n```\n\n<!-- cheat\nvar x = echo 1\ninclude b.md\n-->\n")
+	writeCacheTestFile(t, b, "<!-- cheat\nvar y = echo 2\ninclude a.md\n-->\n")
+
+	idx, err := NewParser().ParseSingleFile(a)
+	if err != nil {
+		t.Fatalf("ParseSingleFile: %v", err)
+	}
+	if len(idx.Cheats) != 1 {
+		t.Fatalf("got %d cheats, want 1: %+v", len(idx.Cheats), idx.Cheats)
+	}
+
+	var names []string
+	for _, v := range idx.Cheats[0].Vars {
+		names = append(names, v.Name)
+	}
+	if len(names) != 2 || names[0] != "x" || names[1] != "y" {
+		t.Fatalf("Vars = %+v, want [x y] (a's own include of b, cycling back into a, dropped)", names)
+	}
+}
+
+func TestIncludeMissingTargetIsDroppedSilently(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.md")
+	writeCacheTestFile(t, main, "## Deploy\n\n```bash\ndeploy $env\n```\n\n<!-- cheat\nvar env = echo prod\ninclude nope.md\n-->\n")
+
+	idx, err := NewParser().ParseSingleFile(main)
+	if err != nil {
+		t.Fatalf("ParseSingleFile: %v", err)
+	}
+	if len(idx.Cheats) != 1 {
+		t.Fatalf("got %d cheats, want 1: %+v", len(idx.Cheats), idx.Cheats)
+	}
+	if len(idx.Cheats[0].Vars) != 1 || idx.Cheats[0].Vars[0].Name != "env" {
+		t.Fatalf("Vars = %+v, want just [env] (missing include dropped)", idx.Cheats[0].Vars)
+	}
+	if len(idx.Cheats[0].Includes) != 0 {
+		t.Errorf("Includes = %+v, want none for an unreadable target", idx.Cheats[0].Includes)
+	}
+}
@@ -0,0 +1,33 @@
+package parser
+
+import "testing"
+
+func TestIsMarkdownFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		expected bool
+	}{
+		{name: "standard extension", path: "README.md", expected: true},
+		{name: "uppercase extension", path: "README.MD", expected: true},
+		{name: "mixed case extension", path: "Foo.MarkDown", expected: true},
+		{name: "mdown extension", path: "notes.mdown", expected: true},
+		{name: "mkd extension", path: "notes.mkd", expected: true},
+		{name: "mkdn extension", path: "notes.mkdn", expected: true},
+		{name: "mdwn extension", path: "notes.mdwn", expected: true},
+		{name: "short name", path: "a.md", expected: true},
+		{name: "no extension", path: "md", expected: false},
+		{name: "dotfile with markdown extension", path: ".md", expected: true},
+		{name: "path with no extension at all", path: "docs/README", expected: false},
+		{name: "unrelated extension", path: "notes.txt", expected: false},
+		{name: "nested path", path: "a/b/c/guide.Markdown", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isMarkdownFile(tt.path); got != tt.expected {
+				t.Errorf("isMarkdownFile(%q) = %v, want %v", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package parser
 
 import (
 	"bytes"
+	"encoding/gob"
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -69,6 +71,31 @@ type Cheat struct {
 	Vars          []VarDef          // Variable definitions
 	Scope         map[string]string // Resolved values at runtime
 	HasCheatBlock bool              // Whether this cheat has a <!-- cheat --> block
+	PreHook       string            // Per-cheat override for config.GetPreHook()
+	PostHook      string            // Per-cheat override for config.GetPostHook()
+	Inputs        []InputDef        // Structured input declarations ("input NAME type=...")
+	Outputs       []OutputDef       // Structured output declarations ("output NAME type=...")
+	Body          []Node            // Cheat block DSL as an AST; Vars above is its flattened form
+	Includes      []string          // Files pulled in via "include" directives, for cache invalidation
+	Pipeline      bool              // Whether Command is a "pipeline" block: one shell command per line, run in order
+}
+
+// InputDef declares a structured, typed input a cheat resolves before
+// substitution - the DSL's "input NAME type=TYPE" line. Type is one of
+// "dir", "file", "container", "url"; see executor.ResolveInputs.
+type InputDef struct {
+	Name string
+	Type string
+}
+
+// OutputDef declares how a cheat's result is captured for post_hook and for
+// a later cheat in an executor.Pipeline - the DSL's
+// "output NAME type=TYPE [path=PATH]" line. Type is "stdout" (the command's
+// captured stdout) or "file" (the contents of Path after the command runs).
+type OutputDef struct {
+	Name string
+	Type string
+	Path string
 }
 
 // NewCheat creates a new Cheat
@@ -82,16 +109,43 @@ func NewCheat(file, header string) *Cheat {
 
 // VarDef represents a variable definition
 type VarDef struct {
-	Name      string // Variable name
-	Shell     string // Shell command to generate values (for = syntax)
-	Literal   string // Literal value with var substitution (for := syntax)
-	Args      string // Selector arguments after ---
-	Condition string // Conditional expression: "$var == value" or "$var != value"
+	Name       string   // Variable name
+	Shell      string   // Shell command to generate values (for = syntax)
+	Literal    string   // Literal value with var substitution (for := syntax)
+	Args       string   // Selector arguments after ---
+	Condition  string   // Conditional expression: "$var == value" or "$var != value"
+	Source     string   // External secret reference, e.g. "env:NAME" (for @ syntax)
+	Transforms []string // Post-processing filters applied in order, e.g. ["base64d", "trim"]
+
+	Type     string   // Declared type via "var name:type": "string" (default), "int", "float", "bool", "enum", "path", "file", "dir", "date", "regex", "secret"
+	Default  string   // Fallback value when resolution comes back empty (set by a "default NAME value" line)
+	Validate string   // Regex a resolved value must match (set by a "validate NAME pattern" line)
+	Masked   bool     // Input is hidden and OutputPrint masks the value (set by a "secret NAME" line)
+	Choices  []string // Allowed values for Type == "enum" (set by a "choices NAME a,b,c" line)
+	Min      string   // Lower bound for Type == "int"/"float" (set by a "range NAME min max" line)
+	Max      string   // Upper bound for Type == "int"/"float" (set by a "range NAME min max" line)
+}
+
+// IsMultiSelect reports whether this variable's selector Args request
+// multi-select (the "--multi" flag), so resolveVar knows to collect several
+// picks instead of one.
+func (v VarDef) IsMultiSelect() bool {
+	return strings.Contains(v.Args, "--multi")
+}
+
+// IsSecret reports whether this variable is backed by an external secret
+// store rather than a shell command or literal value.
+func (v VarDef) IsSecret() bool {
+	return v.Source != ""
 }
 
-// ParseVarDef parses a variable definition from name and value (shell command)
+// ParseVarDef parses a variable definition from name and value (shell command).
+// A trailing "| filter | filter" chain is peeled off as Transforms first, so
+// it doesn't get confused with a shell command's own pipes (see
+// splitTransforms).
 func ParseVarDef(name, value string) VarDef {
-	v := VarDef{Name: name}
+	value, transforms := splitTransforms(value)
+	v := VarDef{Name: name, Transforms: transforms}
 	if idx := strings.Index(value, "---"); idx != -1 {
 		v.Shell = strings.TrimSpace(value[:idx])
 		v.Args = strings.TrimSpace(value[idx+3:])
@@ -103,7 +157,8 @@ func ParseVarDef(name, value string) VarDef {
 
 // ParseVarDefLiteral parses a literal variable definition (no shell, just substitution)
 func ParseVarDefLiteral(name, value string) VarDef {
-	v := VarDef{Name: name}
+	value, transforms := splitTransforms(value)
+	v := VarDef{Name: name, Transforms: transforms}
 	if idx := strings.Index(value, "---"); idx != -1 {
 		v.Literal = strings.TrimSpace(value[:idx])
 		v.Args = strings.TrimSpace(value[idx+3:])
@@ -113,8 +168,10 @@ func ParseVarDefLiteral(name, value string) VarDef {
 	return v
 }
 
-// ParseVarDefWithCondition parses a variable definition with an optional condition
-func ParseVarDefWithCondition(name, value, condition string, isLiteral bool) VarDef {
+// ParseVarDefWithCondition parses a variable definition with an optional
+// condition and type (the ":type" suffix on a "var name:type = ..." line -
+// see patterns.varDef/varDefLiteral).
+func ParseVarDefWithCondition(name, value, condition, varType string, isLiteral bool) VarDef {
 	var v VarDef
 	if isLiteral {
 		v = ParseVarDefLiteral(name, value)
@@ -122,6 +179,7 @@ func ParseVarDefWithCondition(name, value, condition string, isLiteral bool) Var
 		v = ParseVarDef(name, value)
 	}
 	v.Condition = condition
+	v.Type = varType
 	return v
 }
 
@@ -158,9 +216,10 @@ type DuplicateExport struct {
 
 // CheatIndex holds all parsed cheats and modules
 type CheatIndex struct {
-	Cheats     []*Cheat
-	Modules    map[string]*Module
-	Duplicates []DuplicateExport
+	Cheats      []*Cheat
+	Modules     map[string]*Module
+	Duplicates  []DuplicateExport
+	Diagnostics []Diagnostic
 }
 
 // NewCheatIndex creates an empty cheat index
@@ -182,14 +241,47 @@ func (idx *CheatIndex) RegisterModule(cheat *Cheat) {
 	if cheat.Export == "" {
 		return
 	}
-	if existing, ok := idx.Modules[cheat.Export]; ok {
+	idx.addModule(cheat.Export, NewModule(cheat))
+}
+
+// addModule registers mod under name, recording a DuplicateExport if name
+// was already registered from a different file. Shared by RegisterModule
+// and the result-merging paths (parallel parse, cache reuse) so duplicate
+// detection works the same regardless of where a module came from.
+func (idx *CheatIndex) addModule(name string, mod *Module) {
+	if existing, ok := idx.Modules[name]; ok && existing.File != mod.File {
 		idx.Duplicates = append(idx.Duplicates, DuplicateExport{
-			Name:  cheat.Export,
+			Name:  name,
 			File1: existing.File,
-			File2: cheat.File,
+			File2: mod.File,
 		})
+		idx.Diagnostics = append(idx.Diagnostics, Diagnostic{
+			File: mod.File, Severity: SeverityWarning, Code: CodeDuplicateExport,
+			Message: fmt.Sprintf("export %q already defined in %s", name, existing.File),
+		})
+	}
+	idx.Modules[name] = mod
+}
+
+// checkImports walks every cheat's imports and records a diagnostic for any
+// that doesn't resolve to a registered module, using importLines to recover
+// the source line of each "import" statement from the cheat's AST.
+func (idx *CheatIndex) checkImports() {
+	for _, cheat := range idx.Cheats {
+		if len(cheat.Imports) == 0 {
+			continue
+		}
+		lines := importLines(cheat.Body)
+		for _, mod := range cheat.Imports {
+			if _, ok := idx.Modules[mod]; ok {
+				continue
+			}
+			idx.Diagnostics = append(idx.Diagnostics, Diagnostic{
+				File: cheat.File, Line: lines[mod], Severity: SeverityError,
+				Code: CodeUnresolvedImport, Message: fmt.Sprintf("import %q does not match any export", mod),
+			})
+		}
 	}
-	idx.Modules[cheat.Export] = NewModule(cheat)
 }
 
 // ============================================================================
@@ -204,10 +296,24 @@ var patterns = struct {
 	cheatSingleLine *regexp.Regexp
 	export          *regexp.Regexp
 	importStmt      *regexp.Regexp
+	includeStmt     *regexp.Regexp
+	preHook         *regexp.Regexp
+	postHook        *regexp.Regexp
+	inputDef        *regexp.Regexp
+	outputDef       *regexp.Regexp
 	varDef          *regexp.Regexp
 	varDefLiteral   *regexp.Regexp
 	varDefPrompt    *regexp.Regexp
+	varDefSource    *regexp.Regexp
+	varDefault      *regexp.Regexp
+	varValidate     *regexp.Regexp
+	varSecret       *regexp.Regexp
+	varChoices      *regexp.Regexp
+	varRange        *regexp.Regexp
+	pipelineMarker  *regexp.Regexp
 	ifStart         *regexp.Regexp
+	elifStart       *regexp.Regexp
+	elseStart       *regexp.Regexp
 	ifEnd           *regexp.Regexp
 }{
 	header:          regexp.MustCompile(`^(#{1,6})\s+(.+)$`),
@@ -217,10 +323,24 @@ var patterns = struct {
 	cheatSingleLine: regexp.MustCompile(`(?i)^<!--\s*cheat\s*(.*?)\s*-->$`),
 	export:          regexp.MustCompile(`^export\s+(\S+)$`),
 	importStmt:      regexp.MustCompile(`^import\s+(\S+)$`),
-	varDef:          regexp.MustCompile(`^var\s+(\w+)\s*=\s*(.+)$`),
-	varDefLiteral:   regexp.MustCompile(`^var\s+(\w+)\s*:=\s*(.+)$`),
-	varDefPrompt:    regexp.MustCompile(`^var\s+(\w+)\s*$`),
+	includeStmt:     regexp.MustCompile(`^include\s+(\S+)$`),
+	preHook:         regexp.MustCompile(`^pre_hook\s+(.+)$`),
+	postHook:        regexp.MustCompile(`^post_hook\s+(.+)$`),
+	inputDef:        regexp.MustCompile(`^input\s+(\w+)\s+type=(\S+)$`),
+	outputDef:       regexp.MustCompile(`^output\s+(\w+)\s+type=(\S+)(?:\s+path=(\S+))?$`),
+	varDef:          regexp.MustCompile(`^var\s+(\w+)(?::(\w+))?\s*=\s*(.+)$`),
+	varDefLiteral:   regexp.MustCompile(`^var\s+(\w+)(?::(\w+))?\s*:=\s*(.+)$`),
+	varDefPrompt:    regexp.MustCompile(`^var\s+(\w+)(?::(\w+))?\s*(?:\|\s*(.+))?$`),
+	varDefSource:    regexp.MustCompile(`^var\s+(\w+)(?::(\w+))?\s*@\s*(.+)$`),
+	varDefault:      regexp.MustCompile(`^default\s+(\w+)\s+(.+)$`),
+	varValidate:     regexp.MustCompile(`^validate\s+(\w+)\s+(.+)$`),
+	varSecret:       regexp.MustCompile(`^secret\s+(\w+)$`),
+	varChoices:      regexp.MustCompile(`^choices\s+(\w+)\s+(.+)$`),
+	varRange:        regexp.MustCompile(`^range\s+(\w+)\s+(\S+)\s+(\S+)$`),
+	pipelineMarker:  regexp.MustCompile(`^pipeline$`),
 	ifStart:         regexp.MustCompile(`^if\s+(.+)$`),
+	elifStart:       regexp.MustCompile(`^elif\s+(.+)$`),
+	elseStart:       regexp.MustCompile(`^else$`),
 	ifEnd:           regexp.MustCompile(`^fi$`),
 }
 
@@ -243,6 +363,28 @@ func IsShellLanguage(lang string) bool {
 type Parser struct {
 	index         *CheatIndex
 	pathTagsCache map[string][]string // cache tags per directory
+	cachePath     string              // on-disk parse cache, set by NewParserWithCache
+	fileCache     map[string]cacheEntry
+	strict        bool // set by SetStrict; turns any collected Diagnostic into a hard error
+}
+
+// SetStrict controls whether ParseDirectory/ParseSingleFile return an error
+// when the resulting CheatIndex.Diagnostics is non-empty. Off by default -
+// cheatsheets with the odd malformed line still load, with the issue
+// surfaced via Diagnostics rather than refusing to run at all.
+func (p *Parser) SetStrict(strict bool) {
+	p.strict = strict
+}
+
+// checkStrict returns an error naming the first diagnostic if strict mode
+// is on and any diagnostic was collected, otherwise nil.
+func (p *Parser) checkStrict() error {
+	if !p.strict || len(p.index.Diagnostics) == 0 {
+		return nil
+	}
+	d := p.index.Diagnostics[0]
+	return fmt.Errorf("parser: strict mode: %s:%d: %s: %s (and %d more diagnostic(s))",
+		d.File, d.Line, d.Code, d.Message, len(p.index.Diagnostics)-1)
 }
 
 // NewParser creates a new parser
@@ -253,16 +395,78 @@ func NewParser() *Parser {
 	}
 }
 
-// ParseDirectory recursively parses all markdown files in a directory
+// NewParserWithCache creates a Parser that persists its parse results to
+// cachePath and reuses them on a later ParseDirectory call: any discovered
+// file whose fingerprint (mtime + size) still matches the cache is reused
+// as-is instead of being re-parsed. Pass "" (or use NewParser) to disable
+// caching.
+func NewParserWithCache(cachePath string) *Parser {
+	p := NewParser()
+	p.cachePath = cachePath
+	return p
+}
+
+// ParseDirectory recursively parses all markdown files in a directory. If
+// the parser was created with NewParserWithCache, unchanged files are
+// loaded from the on-disk cache instead of being re-parsed, and the cache
+// is rewritten at the end to reflect the new set of files.
 func (p *Parser) ParseDirectory(dir string) (*CheatIndex, error) {
 	files, err := collectMarkdownFiles(dir)
 	if err != nil {
 		return nil, err
 	}
 
-	results := parseFilesParallel(files)
+	var priorCache map[string]cacheEntry
+	if p.cachePath != "" {
+		if dc, err := loadDiskCache(p.cachePath); err == nil {
+			priorCache = dc.Files
+		}
+	}
+
+	fresh := make(map[string]cacheEntry, len(files))
+	var stale []string
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		fp := fingerprintOf(info)
+		if entry, ok := priorCache[f]; ok && entry.Fingerprint == fp && includesUnchanged(entry) {
+			fresh[f] = entry
+			p.index.Cheats = append(p.index.Cheats, entry.Cheats...)
+			for name, mod := range entry.Modules {
+				p.index.addModule(name, mod)
+			}
+			continue
+		}
+		stale = append(stale, f)
+	}
+
+	results := parseFilesParallel(stale)
 	p.mergeResults(results)
 
+	if p.cachePath != "" {
+		for _, path := range stale {
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			cheats := cheatsFromFile(p.index.Cheats, path)
+			fresh[path] = cacheEntry{
+				Fingerprint:         fingerprintOf(info),
+				Cheats:              cheats,
+				Modules:             modulesFromFile(p.index.Modules, path),
+				IncludeFingerprints: includeFingerprints(cheats),
+			}
+		}
+		p.fileCache = fresh
+		_ = saveDiskCache(p.cachePath, fresh) // best-effort: a failed write shouldn't fail the parse
+	}
+
+	p.index.checkImports()
+	if err := p.checkStrict(); err != nil {
+		return p.index, err
+	}
 	return p.index, nil
 }
 
@@ -285,6 +489,7 @@ func collectMarkdownFiles(dir string) ([]string, error) {
 type parseResult struct {
 	cheats  []*Cheat
 	modules map[string]*Module
+	diags   []Diagnostic
 }
 
 // parseFilesParallel reads and parses files using a two-stage pipeline
@@ -301,6 +506,9 @@ func parseFilesParallel(files []string) []parseResult {
 	fileDataChan := make(chan fileData, numFiles)
 	fileChan := make(chan string, numFiles)
 
+	var ioMu sync.Mutex
+	var ioDiags []Diagnostic
+
 	var ioWg sync.WaitGroup
 	ioWorkers := min(numWorkers*2, numFiles)
 	for w := 0; w < ioWorkers; w++ {
@@ -308,7 +516,17 @@ func parseFilesParallel(files []string) []parseResult {
 		go func() {
 			defer ioWg.Done()
 			for path := range fileChan {
-				if data, err := os.ReadFile(path); err == nil && len(data) > 0 {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					ioMu.Lock()
+					ioDiags = append(ioDiags, Diagnostic{
+						File: path, Severity: SeverityError, Code: CodeIOError,
+						Message: fmt.Sprintf("reading file: %v", err),
+					})
+					ioMu.Unlock()
+					continue
+				}
+				if len(data) > 0 {
 					fileDataChan <- fileData{path: path, data: data}
 				}
 			}
@@ -335,16 +553,18 @@ func parseFilesParallel(files []string) []parseResult {
 			localParser := NewParser()
 			localCheats := make([]*Cheat, 0, estimatedCheats/numWorkers)
 			localModules := make(map[string]*Module)
+			var localDiags []Diagnostic
 
 			for fd := range fileDataChan {
 				localParser.index = NewCheatIndex()
 				localParser.parseLines(fd.path, fd.data)
 				localCheats = append(localCheats, localParser.index.Cheats...)
+				localDiags = append(localDiags, localParser.index.Diagnostics...)
 				for name, mod := range localParser.index.Modules {
 					localModules[name] = mod
 				}
 			}
-			resultChan <- parseResult{cheats: localCheats, modules: localModules}
+			resultChan <- parseResult{cheats: localCheats, modules: localModules, diags: localDiags}
 		}()
 	}
 
@@ -357,26 +577,23 @@ func parseFilesParallel(files []string) []parseResult {
 	for r := range resultChan {
 		results = append(results, r)
 	}
+	if len(ioDiags) > 0 {
+		results = append(results, parseResult{diags: ioDiags})
+	}
 	return results
 }
 
-// mergeResults combines parse results into the parser's index
+// mergeResults combines parse results into the parser's index. It appends
+// rather than replaces, so cache entries already merged into the index
+// (see ParseDirectory) survive a later call.
 func (p *Parser) mergeResults(results []parseResult) {
-	var totalCheats []*Cheat
 	for _, r := range results {
-		totalCheats = append(totalCheats, r.cheats...)
+		p.index.Cheats = append(p.index.Cheats, r.cheats...)
+		p.index.Diagnostics = append(p.index.Diagnostics, r.diags...)
 		for name, mod := range r.modules {
-			if existing, ok := p.index.Modules[name]; ok {
-				p.index.Duplicates = append(p.index.Duplicates, DuplicateExport{
-					Name:  name,
-					File1: existing.File,
-					File2: mod.File,
-				})
-			}
-			p.index.Modules[name] = mod
+			p.index.addModule(name, mod)
 		}
 	}
-	p.index.Cheats = totalCheats
 }
 
 // ParseSingleFile parses a single markdown file
@@ -386,9 +603,168 @@ func (p *Parser) ParseSingleFile(path string) (*CheatIndex, error) {
 		return nil, err
 	}
 	p.parseLines(path, data)
+	p.index.checkImports()
+	if err := p.checkStrict(); err != nil {
+		return p.index, err
+	}
+	return p.index, nil
+}
+
+// ============================================================================
+// Parse Cache
+// ============================================================================
+
+// cacheFormatVersion is bumped whenever the on-disk shape of Cheat, VarDef,
+// InputDef, OutputDef, or Module changes, so a cache written by an older
+// binary is discarded instead of gob-decoding into mismatched structs.
+const cacheFormatVersion = 2
+
+// fileFingerprint identifies a file's content cheaply via mtime + size
+// rather than hashing it, trading a false negative on a pathological
+// same-mtime-same-size edit for not having to read every unchanged file.
+type fileFingerprint struct {
+	ModTime int64
+	Size    int64
+}
+
+func fingerprintOf(info os.FileInfo) fileFingerprint {
+	return fileFingerprint{ModTime: info.ModTime().UnixNano(), Size: info.Size()}
+}
+
+// cacheEntry is everything ParseDirectory produced from one source file,
+// so a later run can reuse it without re-invoking parseLines.
+type cacheEntry struct {
+	Fingerprint fileFingerprint
+	Cheats      []*Cheat
+	Modules     map[string]*Module
+
+	// IncludeFingerprints snapshots every file any cheat in this entry
+	// pulled in via "include" (see Cheat.Includes), so a changed include
+	// invalidates the entry even though the owning file itself didn't
+	// change - includesUnchanged re-stats each of these on the next run.
+	IncludeFingerprints map[string]fileFingerprint
+}
+
+// includeFingerprints stats every file referenced by cheats' Includes,
+// for storing alongside a fresh cacheEntry.
+func includeFingerprints(cheats []*Cheat) map[string]fileFingerprint {
+	var fps map[string]fileFingerprint
+	for _, c := range cheats {
+		for _, inc := range c.Includes {
+			if fps == nil {
+				fps = make(map[string]fileFingerprint)
+			}
+			if _, ok := fps[inc]; ok {
+				continue
+			}
+			if info, err := os.Stat(inc); err == nil {
+				fps[inc] = fingerprintOf(info)
+			}
+		}
+	}
+	return fps
+}
+
+// includesUnchanged reports whether every file entry's cheats included
+// still matches the fingerprint recorded when the entry was cached.
+func includesUnchanged(entry cacheEntry) bool {
+	for path, fp := range entry.IncludeFingerprints {
+		info, err := os.Stat(path)
+		if err != nil || fingerprintOf(info) != fp {
+			return false
+		}
+	}
+	return true
+}
+
+// diskCache is the gob-encoded structure persisted by SaveIndex and read
+// back by LoadIndex and ParseDirectory, keyed by absolute file path.
+type diskCache struct {
+	Version int
+	Files   map[string]cacheEntry
+}
+
+// SaveIndex serializes the parser's current file-level cache to path as
+// gob, so a Parser created with NewParserWithCache can pick it back up on
+// a later run. It's a no-op if the parser hasn't populated a cache yet
+// (e.g. it was built with NewParser, or ParseDirectory hasn't run).
+func (p *Parser) SaveIndex(path string) error {
+	if len(p.fileCache) == 0 {
+		return nil
+	}
+	return saveDiskCache(path, p.fileCache)
+}
+
+// LoadIndex loads a cache previously written by SaveIndex (or by
+// ParseDirectory against the same path) and merges every entry into the
+// parser's index, without stat-ing or re-parsing the originating files.
+// Callers that need stale files re-parsed should use ParseDirectory with
+// NewParserWithCache instead, which validates fingerprints itself.
+func (p *Parser) LoadIndex(path string) (*CheatIndex, error) {
+	dc, err := loadDiskCache(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range dc.Files {
+		p.index.Cheats = append(p.index.Cheats, entry.Cheats...)
+		for modName, mod := range entry.Modules {
+			p.index.addModule(modName, mod)
+		}
+	}
+	p.fileCache = dc.Files
 	return p.index, nil
 }
 
+func saveDiskCache(path string, files map[string]cacheEntry) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(diskCache{Version: cacheFormatVersion, Files: files}); err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+func loadDiskCache(path string) (diskCache, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return diskCache{}, err
+	}
+	var dc diskCache
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&dc); err != nil {
+		return diskCache{}, err
+	}
+	if dc.Version != cacheFormatVersion {
+		return diskCache{}, fmt.Errorf("parser: cache at %s is format version %d, want %d", path, dc.Version, cacheFormatVersion)
+	}
+	return dc, nil
+}
+
+// cheatsFromFile returns the subset of cheats whose File matches path.
+func cheatsFromFile(cheats []*Cheat, path string) []*Cheat {
+	var out []*Cheat
+	for _, c := range cheats {
+		if c.File == path {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// modulesFromFile returns the subset of modules whose File matches path.
+func modulesFromFile(modules map[string]*Module, path string) map[string]*Module {
+	out := make(map[string]*Module)
+	for name, mod := range modules {
+		if mod.File == path {
+			out[name] = mod
+		}
+	}
+	return out
+}
+
 // ============================================================================
 // Parse State
 // ============================================================================
@@ -400,8 +776,10 @@ type parseState struct {
 	codeBlockLang     string
 	codeBlockDesc     string
 	codeBlockBuf      []byte // direct byte buffer, no Builder overhead
+	codeBlockStart    int    // line the open code block's ``` started on, for an unterminated-block diagnostic
 	inCheatBlock      bool
 	cheatBlockBuf     []byte
+	cheatBlockStart   int // line the open cheat block's <!-- cheat started on
 	pendingCodeBlocks []codeBlock
 }
 
@@ -436,8 +814,10 @@ func getParseState() *parseState {
 	s.codeBlockLang = ""
 	s.codeBlockDesc = ""
 	s.codeBlockBuf = s.codeBlockBuf[:0]
+	s.codeBlockStart = 0
 	s.inCheatBlock = false
 	s.cheatBlockBuf = s.cheatBlockBuf[:0]
+	s.cheatBlockStart = 0
 	s.pendingCodeBlocks = s.pendingCodeBlocks[:0]
 	return s
 }
@@ -463,6 +843,7 @@ func (p *Parser) parseLines(path string, data []byte) {
 	defer putParseState(state)
 
 	// Process line by line without allocating []string
+	lineNo := 0
 	start := 0
 	for i := 0; i <= len(data); i++ {
 		if i == len(data) || data[i] == '\n' {
@@ -470,17 +851,33 @@ func (p *Parser) parseLines(path string, data []byte) {
 			if end > start && data[end-1] == '\r' {
 				end--
 			}
-			p.parseLine(path, data[start:end], state)
+			lineNo++
+			p.parseLine(path, data[start:end], state, lineNo)
 			start = i + 1
 		}
 	}
 
 	// Process remaining pending blocks
 	p.processPendingBlocks(path, state.currentHeader, state.pendingCodeBlocks)
+
+	// A ``` or <!-- cheat that never closed is a diagnostic, not a silent
+	// drop - the content buffered so far just vanished from the index.
+	if state.inCodeBlock {
+		p.index.Diagnostics = append(p.index.Diagnostics, Diagnostic{
+			File: path, Line: state.codeBlockStart, Severity: SeverityError,
+			Code: CodeUnterminatedBlock, Message: "code block opened with ``` was never closed",
+		})
+	}
+	if state.inCheatBlock {
+		p.index.Diagnostics = append(p.index.Diagnostics, Diagnostic{
+			File: path, Line: state.cheatBlockStart, Severity: SeverityError,
+			Code: CodeUnterminatedBlock, Message: "cheat block opened with <!-- cheat was never closed with -->",
+		})
+	}
 }
 
 // parseLine processes a single line (as bytes, no allocation)
-func (p *Parser) parseLine(path string, line []byte, s *parseState) {
+func (p *Parser) parseLine(path string, line []byte, s *parseState, lineNo int) {
 	// Fast path: inside code block - just accumulate
 	if s.inCodeBlock {
 		if len(line) == 3 && line[0] == '`' && line[1] == '`' && line[2] == '`' {
@@ -506,7 +903,7 @@ func (p *Parser) parseLine(path string, line []byte, s *parseState) {
 		if len(line) >= 2 && line[0] == '-' && line[1] == '-' {
 			if isCheatEnd(line) {
 				s.inCheatBlock = false
-				p.processCheatBlock(path, s)
+				p.processCheatBlock(path, s, lineNo)
 				return
 			}
 		}
@@ -538,6 +935,7 @@ func (p *Parser) parseLine(path string, line []byte, s *parseState) {
 			s.codeBlockLang = lang
 			s.codeBlockDesc = desc
 			s.codeBlockBuf = s.codeBlockBuf[:0]
+			s.codeBlockStart = lineNo
 			return
 		}
 	}
@@ -546,13 +944,14 @@ func (p *Parser) parseLine(path string, line []byte, s *parseState) {
 	if first == '<' {
 		// Single-line cheat comment: <!-- cheat ... -->
 		if content, ok := parseCheatSingleLine(line); ok {
-			p.processCheatComment(path, s, content)
+			p.processCheatComment(path, s, content, lineNo)
 			return
 		}
 		// Multi-line cheat block start: <!-- cheat
 		if isCheatStart(line) {
 			s.inCheatBlock = true
 			s.cheatBlockBuf = s.cheatBlockBuf[:0]
+			s.cheatBlockStart = lineNo
 			return
 		}
 	}
@@ -677,32 +1076,35 @@ func trimSpaceBytes(b []byte) []byte {
 }
 
 // processCheatComment handles single-line <!-- cheat ... --> comments
-func (p *Parser) processCheatComment(path string, s *parseState, content string) {
+func (p *Parser) processCheatComment(path string, s *parseState, content string, lineNo int) {
 	if len(s.pendingCodeBlocks) == 0 {
 		return
 	}
 	lastIdx := len(s.pendingCodeBlocks) - 1
 	block := s.pendingCodeBlocks[lastIdx]
-	cheat := p.createCheat(path, s.currentHeader, block.description, block.content, content, true)
+	// Single-line comment's DSL content lives on the comment's own line, so
+	// blockLine is lineNo-1 to keep createCheat's "content starts at
+	// blockLine+1" convention consistent with the multi-line case.
+	cheat := p.createCheat(path, s.currentHeader, block.description, block.content, content, true, lineNo-1)
 	p.index.AddCheat(cheat)
 	p.index.RegisterModule(cheat)
 	s.pendingCodeBlocks = s.pendingCodeBlocks[:lastIdx]
 }
 
 // processCheatBlock handles multi-line cheat blocks
-func (p *Parser) processCheatBlock(path string, s *parseState) {
+func (p *Parser) processCheatBlock(path string, s *parseState, lineNo int) {
 	content := string(s.cheatBlockBuf)
 
 	if len(s.pendingCodeBlocks) > 0 {
 		lastIdx := len(s.pendingCodeBlocks) - 1
 		block := s.pendingCodeBlocks[lastIdx]
-		cheat := p.createCheat(path, s.currentHeader, block.description, block.content, content, true)
+		cheat := p.createCheat(path, s.currentHeader, block.description, block.content, content, true, s.cheatBlockStart)
 		p.index.AddCheat(cheat)
 		p.index.RegisterModule(cheat)
 		s.pendingCodeBlocks = s.pendingCodeBlocks[:lastIdx]
 	} else {
 		// Standalone cheat block (module definition)
-		cheat := p.createCheat(path, s.currentHeader, "", "", content, true)
+		cheat := p.createCheat(path, s.currentHeader, "", "", content, true, s.cheatBlockStart)
 		if cheat.Export != "" {
 			p.index.RegisterModule(cheat)
 		}
@@ -713,7 +1115,7 @@ func (p *Parser) processCheatBlock(path string, s *parseState) {
 func (p *Parser) processPendingBlocks(path, header string, blocks []codeBlock) {
 	for _, block := range blocks {
 		if IsShellLanguage(block.lang) && block.content != "" {
-			cheat := p.createCheat(path, header, block.description, block.content, "", false)
+			cheat := p.createCheat(path, header, block.description, block.content, "", false, 0)
 			p.index.AddCheat(cheat)
 		}
 	}
@@ -723,8 +1125,10 @@ func (p *Parser) processPendingBlocks(path, header string, blocks []codeBlock) {
 // Cheat Creation
 // ============================================================================
 
-// createCheat creates a new cheat from parsed data
-func (p *Parser) createCheat(path, header, description, command, cheatBlock string, hasCheatBlock bool) *Cheat {
+// createCheat creates a new cheat from parsed data. blockLine is the file
+// line the cheat block's opening tag started on (0 if hasCheatBlock is
+// false), used to attach absolute line numbers to any DSL diagnostics.
+func (p *Parser) createCheat(path, header, description, command, cheatBlock string, hasCheatBlock bool, blockLine int) *Cheat {
 	cheat := NewCheat(path, header)
 	cheat.Description = strings.TrimSpace(description)
 	cheat.Command = command
@@ -732,7 +1136,12 @@ func (p *Parser) createCheat(path, header, description, command, cheatBlock stri
 	cheat.Tags = p.getTagsForPath(path, header)
 
 	if cheatBlock != "" {
-		parseCheatDSL(cheat, cheatBlock)
+		ctx := &dslContext{
+			path: path, onStack: map[string]bool{path: true}, seen: map[string]bool{},
+			includes: &cheat.Includes, blockLine: blockLine, diags: &p.index.Diagnostics,
+		}
+		cheat.Body = parseCheatBlock(cheatBlock, ctx)
+		flattenBody(cheat.Body, nil, cheat)
 	}
 
 	return cheat
@@ -763,98 +1172,30 @@ func (p *Parser) getTagsForPath(path, header string) []string {
 	return pathTags
 }
 
-// parseCheatDSL parses the DSL content within a cheat block
-func parseCheatDSL(cheat *Cheat, content string) {
-	// First, join lines that end with backslash (line continuation)
-	lines := joinContinuationLines(strings.Split(content, "\n"))
-
-	// Track current condition for if/fi blocks
-	var currentCondition string
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		// Handle if/fi blocks
-		if matches := patterns.ifStart.FindStringSubmatch(line); matches != nil {
-			currentCondition = strings.TrimSpace(matches[1])
-			continue
-		}
-
-		if patterns.ifEnd.MatchString(line) {
-			currentCondition = ""
-			continue
-		}
-
-		if matches := patterns.export.FindStringSubmatch(line); matches != nil {
-			cheat.Export = matches[1]
-			continue
-		}
-
-		if matches := patterns.importStmt.FindStringSubmatch(line); matches != nil {
-			cheat.Imports = append(cheat.Imports, matches[1])
-			continue
-		}
-
-		// Check for literal assignment first (:=) before shell assignment (=)
-		if matches := patterns.varDefLiteral.FindStringSubmatch(line); matches != nil {
-			cheat.Vars = append(cheat.Vars, ParseVarDefWithCondition(matches[1], matches[2], currentCondition, true))
-			continue
-		}
-
-		if matches := patterns.varDef.FindStringSubmatch(line); matches != nil {
-			cheat.Vars = append(cheat.Vars, ParseVarDefWithCondition(matches[1], matches[2], currentCondition, false))
-			continue
-		}
-
-		// Check for prompt-only var (no assignment)
-		if matches := patterns.varDefPrompt.FindStringSubmatch(line); matches != nil {
-			cheat.Vars = append(cheat.Vars, VarDef{
-				Name:      matches[1],
-				Condition: currentCondition,
-				// Shell and Literal both empty = prompt-only
-			})
-		}
-	}
-}
-
-// joinContinuationLines joins lines that end with backslash
-func joinContinuationLines(lines []string) []string {
-	var result []string
-	var current strings.Builder
-
-	for _, line := range lines {
-		trimmed := strings.TrimRight(line, " \t")
-		if strings.HasSuffix(trimmed, "\\") {
-			// Line continues - remove backslash and append
-			current.WriteString(strings.TrimSuffix(trimmed, "\\"))
-		} else {
-			// Line ends - append and flush
-			current.WriteString(line)
-			result = append(result, current.String())
-			current.Reset()
-		}
-	}
-
-	// Don't forget any remaining content
-	if current.Len() > 0 {
-		result = append(result, current.String())
-	}
-
-	return result
-}
-
 // ============================================================================
 // Helpers
 // ============================================================================
 
-// isMarkdownFile checks if a path is a markdown file
+// MarkdownExtensions lists the file extensions (including the leading dot)
+// treated as markdown by isMarkdownFile, matched case-insensitively via
+// strings.EqualFold - the same approach Gitea's renderer uses for README
+// matching. Callers embedding cheatmd can append project-specific suffixes
+// to this slice before parsing.
+var MarkdownExtensions = []string{".md", ".markdown", ".mdown", ".mkd", ".mkdn", ".mdwn"}
+
+// isMarkdownFile checks if path's extension (via filepath.Ext) is one of
+// MarkdownExtensions, compared case-insensitively. A path with no extension
+// (e.g. "md" or "docs/README") is never a match; a bare dotfile like ".md"
+// has ".md" as its extension and does match.
 func isMarkdownFile(path string) bool {
-	if len(path) < 3 {
+	ext := filepath.Ext(path)
+	if ext == "" {
 		return false
 	}
-	ext := path[len(path)-3:]
-	return ext == ".md" || ext == ".MD" || strings.EqualFold(path[len(path)-3:], ".md")
+	for _, candidate := range MarkdownExtensions {
+		if strings.EqualFold(ext, candidate) {
+			return true
+		}
+	}
+	return false
 }
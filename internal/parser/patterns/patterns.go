@@ -0,0 +1,72 @@
+// Package patterns provides a small, Grok-style catalogue of named regex
+// fragments ("%{IPV4}", "%{HOST}", ...) that cheat authors can reference to
+// tell the reverse-extraction matcher what shape a command-line variable
+// takes, e.g. annotating a variable as "$dc_ip:IPV4" instead of relying on a
+// single generic capture for every variable.
+package patterns
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// catalogue maps a pattern class name to its regex fragment. A fragment may
+// reference other classes via "%{NAME}"; Expand resolves these recursively.
+var catalogue = map[string]string{
+	"IPV4":     `\d{1,3}(?:\.\d{1,3}){3}`,
+	"HOST":     `%{IPV4}|[A-Za-z0-9][A-Za-z0-9\-.]*`,
+	"USERNAME": `[A-Za-z0-9_.\-\\]+`,
+	"NTLMHASH": `[0-9a-fA-F]{32}(?::[0-9a-fA-F]{32})?`,
+	"QUOTED":   `[^"]*`,
+	"PATH":     `[^\s"']+`,
+	"FLAGSET":  `.+?`,
+	"WORD":     `\S+`,
+}
+
+// Default is the pattern class used for a variable with no explicit
+// annotation - a non-greedy catch-all so the literal text surrounding it in
+// a command template drives how much it captures, rather than stopping at
+// the first space as a plain "\S+" would.
+const Default = "FLAGSET"
+
+// Register adds or replaces a named pattern class, letting module files or
+// third parties extend the catalogue beyond the default ones above.
+func Register(name, fragment string) {
+	catalogue[name] = fragment
+}
+
+// classRef matches a "%{NAME}" reference inside a fragment.
+var classRef = regexp.MustCompile(`%\{(\w+)\}`)
+
+// Expand recursively resolves the "%{NAME}" references in name's fragment
+// into a single regex source string, detecting reference cycles.
+func Expand(name string) (string, error) {
+	return expand(name, make(map[string]bool))
+}
+
+func expand(name string, seen map[string]bool) (string, error) {
+	if seen[name] {
+		return "", fmt.Errorf("patterns: cycle detected expanding %q", name)
+	}
+	fragment, ok := catalogue[name]
+	if !ok {
+		return "", fmt.Errorf("patterns: unknown pattern class %q", name)
+	}
+
+	seen[name] = true
+	defer delete(seen, name)
+
+	var expandErr error
+	expanded := classRef.ReplaceAllStringFunc(fragment, func(ref string) string {
+		inner, err := expand(classRef.FindStringSubmatch(ref)[1], seen)
+		if err != nil {
+			expandErr = err
+			return ref
+		}
+		return "(?:" + inner + ")"
+	})
+	if expandErr != nil {
+		return "", expandErr
+	}
+	return expanded, nil
+}
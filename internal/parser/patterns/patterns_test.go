@@ -0,0 +1,64 @@
+package patterns
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestExpandSimpleClass(t *testing.T) {
+	got, err := Expand("IPV4")
+	if err != nil {
+		t.Fatalf("Expand: %v", err)
+	}
+	re := regexp.MustCompile("^" + got + "$")
+	if !re.MatchString("10.0.0.1") {
+		t.Errorf("IPV4 fragment %q did not match %q", got, "10.0.0.1")
+	}
+	if re.MatchString("not-an-ip") {
+		t.Errorf("IPV4 fragment %q unexpectedly matched %q", got, "not-an-ip")
+	}
+}
+
+func TestExpandResolvesNestedReference(t *testing.T) {
+	got, err := Expand("HOST")
+	if err != nil {
+		t.Fatalf("Expand: %v", err)
+	}
+	if got == "%{IPV4}|[A-Za-z0-9][A-Za-z0-9\\-.]*" {
+		t.Errorf("Expand(%q) left the %%{IPV4} reference unexpanded: %q", "HOST", got)
+	}
+	re := regexp.MustCompile("^(?:" + got + ")$")
+	if !re.MatchString("10.0.0.1") {
+		t.Errorf("HOST fragment %q did not match an IPV4 address", got)
+	}
+	if !re.MatchString("example.com") {
+		t.Errorf("HOST fragment %q did not match a hostname", got)
+	}
+}
+
+func TestExpandUnknownClass(t *testing.T) {
+	if _, err := Expand("NOPE"); err == nil {
+		t.Fatal("Expand of an unregistered class returned nil error")
+	}
+}
+
+func TestExpandDetectsCycle(t *testing.T) {
+	Register("CYCLE_A", "%{CYCLE_B}")
+	Register("CYCLE_B", "%{CYCLE_A}")
+
+	if _, err := Expand("CYCLE_A"); err == nil {
+		t.Fatal("Expand of a cyclic pattern returned nil error")
+	}
+}
+
+func TestRegisterAddsClass(t *testing.T) {
+	Register("TESTCLASS", `test\d+`)
+
+	got, err := Expand("TESTCLASS")
+	if err != nil {
+		t.Fatalf("Expand: %v", err)
+	}
+	if got != `test\d+` {
+		t.Errorf("Expand(%q) = %q, want %q", "TESTCLASS", got, `test\d+`)
+	}
+}
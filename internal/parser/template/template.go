@@ -0,0 +1,535 @@
+// Package template implements the small expression layer used inside a
+// cheat's Command line: conditionals, default values, and iteration, on top
+// of the plain "$var" substitution the rest of the parser package already
+// understands. A template is tokenized, parsed into a tree of Nodes, and
+// then either evaluated against a scope (to build the real command) or
+// turned back into a regex skeleton (to reverse-extract a scope from a
+// typed-out command, the same job executor.BuildFinalCommand and
+// ui.buildMatchPattern do for a plain command).
+//
+// Supported syntax:
+//
+//	$name                                plain substitution
+//	${name:-default}                     default value when name is unset/empty
+//	${name|default}                      same as ${name:-default}, pipe spelling
+//	${name:upper} ${name:lower}          apply a fixed transform to name's value
+//	${name:quote} ${name:trim}            (the same vocabulary as VarDef.Transforms)
+//	${name?flag}                         literal "flag" when name is set, else ""
+//	{{#if cond}}...{{else}}...{{/if}}     branch on a Condition (see Evaluate)
+//	{{#each list}}...$it...{{/each}}      repeat body once per whitespace-separated
+//	                                      item in scope[list], binding "it"
+//	{{helper $a $b}}                      call a registered Helper
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gubarz/cheatmd/internal/parser/patterns"
+)
+
+// Node is one piece of a parsed template - a literal run of text, a
+// variable reference, a conditional branch, a loop, or a helper call.
+type Node interface {
+	Eval(scope map[string]string) string
+}
+
+// Template is a parsed command: a reusable AST plus its lazily-built reverse
+// regex, so repeated Eval/ReverseRegex calls on the same Command don't
+// re-tokenize it.
+type Template struct {
+	nodes []Node
+
+	reverseOnce bool
+	reverseRe   *regexp.Regexp
+	reverseVars []string
+	reverseErr  error
+}
+
+// Parse tokenizes and parses src into a Template. Plain "$var" templates
+// (the common case) parse into just text and variable nodes and evaluate
+// identically to the old direct string-replace substitution.
+func Parse(src string) (*Template, error) {
+	nodes, _, err := parseNodes(tokenize(src), false)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{nodes: nodes}, nil
+}
+
+// Eval renders the template against scope.
+func (t *Template) Eval(scope map[string]string) string {
+	var sb strings.Builder
+	for _, n := range t.nodes {
+		sb.WriteString(n.Eval(scope))
+	}
+	return sb.String()
+}
+
+// ReverseRegex derives a regex (with one capture group per variable
+// reference, in order) that can reverse-extract a scope from a realized
+// command, mirroring what a plain-$var command gets from
+// internal/ui's buildMatchPattern. Loop bodies ({{#each}}) can't be
+// reverse-extracted into individual iterations, so they collapse to a
+// single non-greedy, uncaptured ".*?" skeleton.
+func (t *Template) ReverseRegex() (*regexp.Regexp, []string, error) {
+	if !t.reverseOnce {
+		t.reverseOnce = true
+		frag, vars, err := reverseNodes(t.nodes)
+		if err == nil {
+			t.reverseRe, err = regexp.Compile(`^\s*` + frag + `\s*$`)
+		}
+		t.reverseVars, t.reverseErr = vars, err
+	}
+	return t.reverseRe, t.reverseVars, t.reverseErr
+}
+
+// ============================================================================
+// Nodes
+// ============================================================================
+
+// textNode is a literal run of text copied through unchanged.
+type textNode string
+
+func (n textNode) Eval(map[string]string) string { return string(n) }
+
+// varNode is a plain "$name" or a "${name:-default}" / "${name?flag}" form.
+type varNode struct {
+	name string
+	kind varKind
+	arg  string // default value, or the flag literal, depending on kind
+}
+
+type varKind int
+
+const (
+	varPlain varKind = iota
+	varDefault
+	varFlag
+)
+
+func (n varNode) Eval(scope map[string]string) string {
+	value, set := scope[n.name]
+	switch n.kind {
+	case varDefault:
+		if set && value != "" {
+			return value
+		}
+		return n.arg
+	case varFlag:
+		if set && value != "" {
+			return n.arg
+		}
+		return ""
+	default:
+		return value
+	}
+}
+
+// varTransformNode is a "${name:transform}" form applying a fixed,
+// VarDef.Transforms-style transform to name's scope value inline, so a
+// command doesn't need a separate "var x := $x | upper" just to reshape a
+// value it already has.
+type varTransformNode struct {
+	name      string
+	transform string
+}
+
+func (n varTransformNode) Eval(scope map[string]string) string {
+	value := scope[n.name]
+	switch n.transform {
+	case "upper":
+		return strings.ToUpper(value)
+	case "lower":
+		return strings.ToLower(value)
+	case "trim":
+		return strings.TrimSpace(value)
+	case "quote":
+		return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+	default:
+		return value
+	}
+}
+
+// ifNode branches on evaluating cond against the scope.
+type ifNode struct {
+	cond string
+	then []Node
+	els  []Node
+}
+
+func (n ifNode) Eval(scope map[string]string) string {
+	branch := n.then
+	if !Evaluate(n.cond, scope) {
+		branch = n.els
+	}
+	var sb strings.Builder
+	for _, child := range branch {
+		sb.WriteString(child.Eval(scope))
+	}
+	return sb.String()
+}
+
+// eachNode repeats body once per whitespace-separated item in
+// scope[listVar], binding "it" to the current item for the body's Eval.
+type eachNode struct {
+	listVar string
+	body    []Node
+}
+
+func (n eachNode) Eval(scope map[string]string) string {
+	items := strings.Fields(scope[n.listVar])
+	var sb strings.Builder
+	for i, item := range items {
+		if i > 0 {
+			sb.WriteString(" ")
+		}
+		iterScope := make(map[string]string, len(scope)+1)
+		for k, v := range scope {
+			iterScope[k] = v
+		}
+		iterScope["it"] = item
+		for _, child := range n.body {
+			sb.WriteString(child.Eval(iterScope))
+		}
+	}
+	return sb.String()
+}
+
+// helperNode calls a registered Helper with its evaluated arguments.
+type helperNode struct {
+	name string
+	args []Node
+}
+
+func (n helperNode) Eval(scope map[string]string) string {
+	fn, ok := helpers[n.name]
+	if !ok {
+		return ""
+	}
+	args := make([]string, len(n.args))
+	for i, a := range n.args {
+		args[i] = a.Eval(scope)
+	}
+	return fn(args...)
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// Helper formats args (already Eval'd against the scope) into a string.
+type Helper func(args ...string) string
+
+var helpers = map[string]Helper{
+	"default": func(args ...string) string {
+		for _, a := range args {
+			if a != "" {
+				return a
+			}
+		}
+		return ""
+	},
+	"upper": func(args ...string) string {
+		if len(args) == 0 {
+			return ""
+		}
+		return strings.ToUpper(args[0])
+	},
+	"shellquote": func(args ...string) string {
+		if len(args) == 0 {
+			return ""
+		}
+		return "'" + strings.ReplaceAll(args[0], "'", `'\''`) + "'"
+	},
+	"json": func(args ...string) string {
+		if len(args) == 0 {
+			return `""`
+		}
+		return strconv.Quote(args[0])
+	},
+}
+
+// RegisterHelper adds or replaces a named helper callable as "{{name args}}".
+func RegisterHelper(name string, fn Helper) {
+	helpers[name] = fn
+}
+
+// ============================================================================
+// Condition evaluation (shared with "{{#if}}" blocks)
+// ============================================================================
+
+// condVarRef matches a "$name" reference inside a condition.
+var condVarRef = regexp.MustCompile(`\$(\w+)`)
+
+// Evaluate evaluates a "$var == value" / "$var != value" / "$var" (truthy)
+// condition against scope, substituting scope values for "$name" first. A
+// name absent from scope substitutes as "" rather than being left as literal
+// text, so an unresolved var reads as falsy instead of as a non-empty string.
+func Evaluate(condition string, scope map[string]string) bool {
+	condition = strings.TrimSpace(condition)
+	condition = condVarRef.ReplaceAllStringFunc(condition, func(ref string) string {
+		return scope[ref[1:]]
+	})
+	condition = strings.Trim(condition, `"`)
+
+	if left, right, ok := strings.Cut(condition, "=="); ok {
+		return strings.TrimSpace(strings.Trim(left, `"`)) == strings.TrimSpace(strings.Trim(right, `"`))
+	}
+	if left, right, ok := strings.Cut(condition, "!="); ok {
+		return strings.TrimSpace(strings.Trim(left, `"`)) != strings.TrimSpace(strings.Trim(right, `"`))
+	}
+	return condition != ""
+}
+
+// ============================================================================
+// Tokenizer
+// ============================================================================
+
+type tokenKind int
+
+const (
+	tokText tokenKind = iota
+	tokIfStart
+	tokElse
+	tokIfEnd
+	tokEachStart
+	tokEachEnd
+	tokHelper
+	tokVarDefault
+	tokVarTransform
+	tokVarFlag
+	tokVarPlain
+)
+
+type token struct {
+	kind tokenKind
+	a, b string // meaning depends on kind: cond/listVar/name/default/flag/transform, plus raw args for helpers
+}
+
+// tagPattern recognizes every tag form in one pass; group numbers are
+// mapped to meaning in tokenize below.
+var tagPattern = regexp.MustCompile(
+	`\{\{#if\s+(.+?)\}\}` + `|` +
+		`\{\{else\}\}` + `|` +
+		`\{\{/if\}\}` + `|` +
+		`\{\{#each\s+\$?(\w+)\}\}` + `|` +
+		`\{\{/each\}\}` + `|` +
+		`\{\{(\w+)((?:\s+\S+)*)\}\}` + `|` +
+		`\$\{(\w+):-([^}]*)\}` + `|` +
+		`\$\{(\w+)\|([^}]*)\}` + `|` +
+		`\$\{(\w+):(upper|lower|quote|trim)\}` + `|` +
+		`\$\{(\w+)\?([^}]*)\}` + `|` +
+		`\$(\w+)`,
+)
+
+func tokenize(src string) []token {
+	var tokens []token
+	last := 0
+	for _, m := range tagPattern.FindAllStringSubmatchIndex(src, -1) {
+		if m[0] > last {
+			tokens = append(tokens, token{kind: tokText, a: src[last:m[0]]})
+		}
+		last = m[1]
+		tokens = append(tokens, classify(src, m))
+	}
+	if last < len(src) {
+		tokens = append(tokens, token{kind: tokText, a: src[last:]})
+	}
+	return tokens
+}
+
+// group returns submatch group i's text given FindAllStringSubmatchIndex's
+// flat []int for one match, or "" if that group didn't participate.
+func group(src string, m []int, i int) string {
+	if 2*i+1 >= len(m) || m[2*i] < 0 {
+		return ""
+	}
+	return src[m[2*i]:m[2*i+1]]
+}
+
+func classify(src string, m []int) token {
+	whole := src[m[0]:m[1]]
+	switch {
+	case m[2] >= 0: // group 1: #if cond
+		return token{kind: tokIfStart, a: group(src, m, 1)}
+	case whole == "{{else}}":
+		return token{kind: tokElse}
+	case whole == "{{/if}}":
+		return token{kind: tokIfEnd}
+	case m[4] >= 0: // group 2: #each listVar
+		return token{kind: tokEachStart, a: group(src, m, 2)}
+	case whole == "{{/each}}":
+		return token{kind: tokEachEnd}
+	case m[6] >= 0: // group 3/4: helper name + raw args
+		return token{kind: tokHelper, a: group(src, m, 3), b: strings.TrimSpace(group(src, m, 4))}
+	case m[10] >= 0: // group 5/6: ${name:-default}
+		return token{kind: tokVarDefault, a: group(src, m, 5), b: group(src, m, 6)}
+	case m[14] >= 0: // group 7/8: ${name|default}
+		return token{kind: tokVarDefault, a: group(src, m, 7), b: group(src, m, 8)}
+	case m[18] >= 0: // group 9/10: ${name:transform}
+		return token{kind: tokVarTransform, a: group(src, m, 9), b: group(src, m, 10)}
+	case m[22] >= 0: // group 11/12: ${name?flag}
+		return token{kind: tokVarFlag, a: group(src, m, 11), b: group(src, m, 12)}
+	default: // group 13: $name
+		return token{kind: tokVarPlain, a: group(src, m, 13)}
+	}
+}
+
+// ============================================================================
+// Parser
+// ============================================================================
+
+// parseNodes consumes tokens into a node list. When inBlock is true, parsing
+// stops (without consuming) at the first "{{else}}", "{{/if}}", or
+// "{{/each}}" token, returning the remaining tokens to the caller so it can
+// decide which terminator it saw.
+func parseNodes(tokens []token, inBlock bool) ([]Node, []token, error) {
+	var nodes []Node
+	for len(tokens) > 0 {
+		tok := tokens[0]
+		if inBlock && (tok.kind == tokElse || tok.kind == tokIfEnd || tok.kind == tokEachEnd) {
+			return nodes, tokens, nil
+		}
+		tokens = tokens[1:]
+
+		switch tok.kind {
+		case tokText:
+			nodes = append(nodes, textNode(tok.a))
+		case tokVarPlain:
+			nodes = append(nodes, varNode{name: tok.a, kind: varPlain})
+		case tokVarDefault:
+			nodes = append(nodes, varNode{name: tok.a, kind: varDefault, arg: tok.b})
+		case tokVarTransform:
+			nodes = append(nodes, varTransformNode{name: tok.a, transform: tok.b})
+		case tokVarFlag:
+			nodes = append(nodes, varNode{name: tok.a, kind: varFlag, arg: tok.b})
+		case tokHelper:
+			nodes = append(nodes, helperNode{name: tok.a, args: parseHelperArgs(tok.b)})
+		case tokIfStart:
+			thenNodes, rest, err := parseNodes(tokens, true)
+			if err != nil {
+				return nil, nil, err
+			}
+			var elseNodes []Node
+			if len(rest) > 0 && rest[0].kind == tokElse {
+				elseNodes, rest, err = parseNodes(rest[1:], true)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+			if len(rest) == 0 || rest[0].kind != tokIfEnd {
+				return nil, nil, fmt.Errorf("template: unterminated {{#if}}")
+			}
+			tokens = rest[1:]
+			nodes = append(nodes, ifNode{cond: tok.a, then: thenNodes, els: elseNodes})
+		case tokEachStart:
+			body, rest, err := parseNodes(tokens, true)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(rest) == 0 || rest[0].kind != tokEachEnd {
+				return nil, nil, fmt.Errorf("template: unterminated {{#each}}")
+			}
+			tokens = rest[1:]
+			nodes = append(nodes, eachNode{listVar: tok.a, body: body})
+		case tokElse, tokIfEnd, tokEachEnd:
+			return nil, nil, fmt.Errorf("template: unexpected closing tag outside a block")
+		}
+	}
+	return nodes, nil, nil
+}
+
+// parseHelperArgs splits a helper call's raw argument text (e.g.
+// `$credential "fallback"`) into argument Nodes.
+func parseHelperArgs(raw string) []Node {
+	if raw == "" {
+		return nil
+	}
+	var args []Node
+	for _, word := range strings.Fields(raw) {
+		switch {
+		case strings.HasPrefix(word, "$"):
+			args = append(args, varNode{name: strings.TrimPrefix(word, "$"), kind: varPlain})
+		default:
+			args = append(args, textNode(strings.Trim(word, `"`)))
+		}
+	}
+	return args
+}
+
+// ============================================================================
+// Reverse extraction
+// ============================================================================
+
+// reversePattern returns a regex fragment for n plus the variable names (in
+// capture-group order) it introduces.
+func reversePattern(n Node) (string, []string, error) {
+	switch v := n.(type) {
+	case textNode:
+		return regexp.QuoteMeta(string(v)), nil, nil
+	case varNode:
+		fragment, err := patterns.Expand(patterns.Default)
+		if err != nil {
+			return "", nil, err
+		}
+		return "(" + fragment + ")", []string{v.name}, nil
+	case varTransformNode:
+		// The transform reshapes name's value before it lands in the
+		// command, so there's no single pattern class to capture it as -
+		// match it uncaptured, like a helper's output.
+		fragment, err := patterns.Expand(patterns.Default)
+		if err != nil {
+			return "", nil, err
+		}
+		return "(?:" + fragment + ")", nil, nil
+	case ifNode:
+		thenFrag, thenVars, err := reverseNodes(v.then)
+		if err != nil {
+			return "", nil, err
+		}
+		elseFrag, elseVars, err := reverseNodes(v.els)
+		if err != nil {
+			return "", nil, err
+		}
+		return "(?:" + thenFrag + "|" + elseFrag + ")", append(thenVars, elseVars...), nil
+	case eachNode:
+		// Individual loop iterations aren't reverse-extractable; collapse the
+		// whole block to an opaque, uncaptured catch-all.
+		return `.*?`, nil, nil
+	case helperNode:
+		// A helper's output shape isn't statically known and there's no
+		// variable name to assign it to - match it without capturing so
+		// later capture groups keep their positions in sync with varNames.
+		fragment, err := patterns.Expand(patterns.Default)
+		if err != nil {
+			return "", nil, err
+		}
+		return "(?:" + fragment + ")", nil, nil
+	default:
+		return "", nil, fmt.Errorf("template: unknown node type %T", n)
+	}
+}
+
+func reverseNodes(nodes []Node) (string, []string, error) {
+	var sb strings.Builder
+	var vars []string
+	for _, n := range nodes {
+		frag, fragVars, err := reversePattern(n)
+		if err != nil {
+			return "", nil, err
+		}
+		sb.WriteString(frag)
+		vars = append(vars, fragVars...)
+	}
+	return sb.String(), vars, nil
+}
+
+// HasTemplateSyntax reports whether src uses any "{{...}}" or "${...}" form
+// beyond plain "$name" substitution, letting callers keep the fast, simple
+// substitution path for the common case.
+func HasTemplateSyntax(src string) bool {
+	return strings.Contains(src, "{{") || strings.Contains(src, "${")
+}
@@ -0,0 +1,225 @@
+package template
+
+import "testing"
+
+func evalSrc(t *testing.T, src string, scope map[string]string) string {
+	t.Helper()
+	tpl, err := Parse(src)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", src, err)
+	}
+	return tpl.Eval(scope)
+}
+
+func TestEvalPlainVar(t *testing.T) {
+	got := evalSrc(t, "deploy $env", map[string]string{"env": "prod"})
+	if got != "deploy prod" {
+		t.Errorf("got %q, want %q", got, "deploy prod")
+	}
+}
+
+func TestEvalVarDefault(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		scope map[string]string
+		want  string
+	}{
+		{"unset uses default", "${region:-us-east}", nil, "us-east"},
+		{"set overrides default", "${region:-us-east}", map[string]string{"region": "eu-west"}, "eu-west"},
+		{"empty uses default", "${region:-us-east}", map[string]string{"region": ""}, "us-east"},
+		{"pipe spelling", "${region|us-east}", nil, "us-east"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := evalSrc(t, tt.src, tt.scope); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalVarFlag(t *testing.T) {
+	if got := evalSrc(t, "${force?--force}", map[string]string{"force": "1"}); got != "--force" {
+		t.Errorf("got %q, want %q", got, "--force")
+	}
+	if got := evalSrc(t, "${force?--force}", map[string]string{"force": ""}); got != "" {
+		t.Errorf("got %q, want empty", got)
+	}
+	if got := evalSrc(t, "${force?--force}", nil); got != "" {
+		t.Errorf("got %q, want empty", got)
+	}
+}
+
+func TestEvalVarTransform(t *testing.T) {
+	tests := []struct {
+		transform string
+		value     string
+		want      string
+	}{
+		{"upper", "abc", "ABC"},
+		{"lower", "ABC", "abc"},
+		{"trim", "  abc  ", "abc"},
+		{"quote", "a'b", `'a'\''b'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.transform, func(t *testing.T) {
+			got := evalSrc(t, "${x:"+tt.transform+"}", map[string]string{"x": tt.value})
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalIfElse(t *testing.T) {
+	src := `{{#if $env == prod}}--prod{{else}}--dev{{/if}}`
+	if got := evalSrc(t, src, map[string]string{"env": "prod"}); got != "--prod" {
+		t.Errorf("got %q, want %q", got, "--prod")
+	}
+	if got := evalSrc(t, src, map[string]string{"env": "staging"}); got != "--dev" {
+		t.Errorf("got %q, want %q", got, "--dev")
+	}
+}
+
+func TestEvalIfNoElse(t *testing.T) {
+	src := `base{{#if $force}} --force{{/if}}`
+	if got := evalSrc(t, src, map[string]string{"force": "1"}); got != "base --force" {
+		t.Errorf("got %q, want %q", got, "base --force")
+	}
+	if got := evalSrc(t, src, nil); got != "base" {
+		t.Errorf("got %q, want %q", got, "base")
+	}
+}
+
+func TestEvalEach(t *testing.T) {
+	got := evalSrc(t, `{{#each hosts}}-t $it{{/each}}`, map[string]string{"hosts": "a b c"})
+	if got != "-t a -t b -t c" {
+		t.Errorf("got %q, want %q", got, "-t a -t b -t c")
+	}
+}
+
+func TestEvalHelper(t *testing.T) {
+	if got := evalSrc(t, `{{upper $env}}`, map[string]string{"env": "prod"}); got != "PROD" {
+		t.Errorf("got %q, want %q", got, "PROD")
+	}
+	if got := evalSrc(t, `{{default $region "us-east"}}`, nil); got != "us-east" {
+		t.Errorf("got %q, want %q", got, "us-east")
+	}
+}
+
+func TestRegisterHelper(t *testing.T) {
+	RegisterHelper("shout", func(args ...string) string {
+		if len(args) == 0 {
+			return ""
+		}
+		return args[0] + "!"
+	})
+	if got := evalSrc(t, `{{shout $env}}`, map[string]string{"env": "prod"}); got != "prod!" {
+		t.Errorf("got %q, want %q", got, "prod!")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name string
+		cond string
+		want bool
+	}{
+		{"equals true", `$env == prod`, true},
+		{"equals false", `$env == staging`, false},
+		{"not-equals true", `$env != staging`, true},
+		{"not-equals false", `$env != prod`, false},
+		{"truthy set", `$env`, true},
+		{"truthy unset", `$missing`, false},
+	}
+	scope := map[string]string{"env": "prod"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Evaluate(tt.cond, scope); got != tt.want {
+				t.Errorf("Evaluate(%q) = %v, want %v", tt.cond, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUnterminatedIf(t *testing.T) {
+	if _, err := Parse(`{{#if $env == prod}}--prod`); err == nil {
+		t.Fatal("Parse of an unterminated {{#if}} returned nil error")
+	}
+}
+
+func TestParseUnterminatedEach(t *testing.T) {
+	if _, err := Parse(`{{#each hosts}}-t $it`); err == nil {
+		t.Fatal("Parse of an unterminated {{#each}} returned nil error")
+	}
+}
+
+func TestParseUnexpectedClosingTag(t *testing.T) {
+	if _, err := Parse(`{{/if}}`); err == nil {
+		t.Fatal("Parse of a stray {{/if}} returned nil error")
+	}
+}
+
+func TestReverseRegexPlainVar(t *testing.T) {
+	tpl, err := Parse("deploy $env")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	re, vars, err := tpl.ReverseRegex()
+	if err != nil {
+		t.Fatalf("ReverseRegex: %v", err)
+	}
+	if len(vars) != 1 || vars[0] != "env" {
+		t.Fatalf("vars = %v, want [env]", vars)
+	}
+	m := re.FindStringSubmatch("deploy prod")
+	if m == nil || m[1] != "prod" {
+		t.Errorf("FindStringSubmatch = %v, want capture %q", m, "prod")
+	}
+}
+
+func TestReverseRegexEachCollapses(t *testing.T) {
+	tpl, err := Parse(`{{#each hosts}}-t $it{{/each}}`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	re, vars, err := tpl.ReverseRegex()
+	if err != nil {
+		t.Fatalf("ReverseRegex: %v", err)
+	}
+	if len(vars) != 0 {
+		t.Errorf("vars = %v, want none (loop bodies aren't reverse-extractable)", vars)
+	}
+	if !re.MatchString("-t a -t b -t c") {
+		t.Errorf("regex %q did not match an each-expanded command", re.String())
+	}
+}
+
+func TestReverseRegexIsMemoized(t *testing.T) {
+	tpl, err := Parse("deploy $env")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	re1, _, _ := tpl.ReverseRegex()
+	re2, _, _ := tpl.ReverseRegex()
+	if re1 != re2 {
+		t.Error("ReverseRegex recomputed the regex instead of reusing the cached one")
+	}
+}
+
+func TestHasTemplateSyntax(t *testing.T) {
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{"deploy $env", false},
+		{"${region:-us-east}", true},
+		{"{{#if $env}}x{{/if}}", true},
+	}
+	for _, tt := range tests {
+		if got := HasTemplateSyntax(tt.src); got != tt.want {
+			t.Errorf("HasTemplateSyntax(%q) = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
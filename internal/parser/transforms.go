@@ -0,0 +1,137 @@
+package parser
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// transformPattern matches a single recognized post-processing filter,
+// optionally carrying an argument after ':', e.g. "base64d" or
+// "regex:foo=bar". Only trailing "| name" segments that match this are
+// peeled off by splitTransforms - anything else (a real shell pipe) is left
+// as part of the underlying Shell/Literal/Source value.
+var transformPattern = regexp.MustCompile(`^(trim|lower|upper|base64|base64d|urlencode|urldecode|hex|hexd|quote|jsonpath:.+|regex:.+=.+)$`)
+
+// splitTransforms peels recognized "| filter" segments off the end of value,
+// stopping at the first trailing segment that isn't a known transform name.
+// This lets `var x = some_shell | grep foo | base64d | trim` keep
+// "some_shell | grep foo" as the shell command while still picking up
+// base64d and trim as Transforms.
+func splitTransforms(value string) (string, []string) {
+	if !strings.Contains(value, "|") {
+		return value, nil
+	}
+
+	segments := strings.Split(value, "|")
+	cut := len(segments)
+	for cut > 1 && transformPattern.MatchString(strings.TrimSpace(segments[cut-1])) {
+		cut--
+	}
+	if cut == len(segments) {
+		return value, nil
+	}
+
+	transforms := make([]string, 0, len(segments)-cut)
+	for _, seg := range segments[cut:] {
+		transforms = append(transforms, strings.TrimSpace(seg))
+	}
+	return strings.TrimSpace(strings.Join(segments[:cut], "|")), transforms
+}
+
+// ApplyTransforms runs value through each named transform in order, e.g.
+// []string{"base64d", "trim"} first base64-decodes then trims whitespace.
+// It stops and returns the error from the first transform that fails.
+func ApplyTransforms(value string, transforms []string) (string, error) {
+	for _, t := range transforms {
+		var err error
+		value, err = applyTransform(value, t)
+		if err != nil {
+			return value, fmt.Errorf("transform %q: %w", t, err)
+		}
+	}
+	return value, nil
+}
+
+func applyTransform(value, transform string) (string, error) {
+	name, arg, _ := strings.Cut(transform, ":")
+	switch name {
+	case "trim":
+		return strings.TrimSpace(value), nil
+	case "lower":
+		return strings.ToLower(value), nil
+	case "upper":
+		return strings.ToUpper(value), nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString([]byte(value)), nil
+	case "base64d":
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return value, err
+		}
+		return string(decoded), nil
+	case "urlencode":
+		return url.QueryEscape(value), nil
+	case "urldecode":
+		return url.QueryUnescape(value)
+	case "hex":
+		return hex.EncodeToString([]byte(value)), nil
+	case "hexd":
+		decoded, err := hex.DecodeString(value)
+		if err != nil {
+			return value, err
+		}
+		return string(decoded), nil
+	case "quote":
+		return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'", nil
+	case "jsonpath":
+		return applyJSONPath(value, arg)
+	case "regex":
+		pat, replace, ok := strings.Cut(arg, "=")
+		if !ok {
+			return value, fmt.Errorf("regex transform requires \"pat=replace\", got %q", arg)
+		}
+		re, err := regexp.Compile(pat)
+		if err != nil {
+			return value, err
+		}
+		return re.ReplaceAllString(value, replace), nil
+	default:
+		return value, fmt.Errorf("unknown transform %q", name)
+	}
+}
+
+// applyJSONPath extracts a dotted field path (e.g. "data.token") out of a
+// shell-produced JSON value. It follows nested object keys only - arrays
+// can't be indexed - not the full JSONPath grammar - enough to pull one field out of an API
+// response without module authors needing a separate jq call.
+func applyJSONPath(value, path string) (string, error) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(value), &data); err != nil {
+		return value, err
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		if key == "" {
+			continue
+		}
+		m, ok := data.(map[string]interface{})
+		if !ok {
+			return value, fmt.Errorf("jsonpath: %q is not an object", key)
+		}
+		data, ok = m[key]
+		if !ok {
+			return value, fmt.Errorf("jsonpath: key %q not found", key)
+		}
+	}
+
+	if s, ok := data.(string); ok {
+		return s, nil
+	}
+	encoded, err := json.Marshal(data)
+	return string(encoded), err
+}
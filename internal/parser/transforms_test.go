@@ -0,0 +1,112 @@
+package parser
+
+import "testing"
+
+func TestSplitTransformsNoPipe(t *testing.T) {
+	value, transforms := splitTransforms("echo hi")
+	if value != "echo hi" || transforms != nil {
+		t.Errorf("splitTransforms(no pipe) = %q, %v, want unchanged with no transforms", value, transforms)
+	}
+}
+
+func TestSplitTransformsPeelsTrailingFilters(t *testing.T) {
+	value, transforms := splitTransforms("some_shell | grep foo | base64d | trim")
+	if value != "some_shell | grep foo" {
+		t.Errorf("value = %q, want %q", value, "some_shell | grep foo")
+	}
+	if len(transforms) != 2 || transforms[0] != "base64d" || transforms[1] != "trim" {
+		t.Errorf("transforms = %v, want [base64d trim]", transforms)
+	}
+}
+
+func TestSplitTransformsLeavesRealPipeAlone(t *testing.T) {
+	value, transforms := splitTransforms("some_shell | grep foo")
+	if value != "some_shell | grep foo" || transforms != nil {
+		t.Errorf("splitTransforms = %q, %v, want the whole value left as shell with no transforms", value, transforms)
+	}
+}
+
+func TestSplitTransformsRegexAndJSONPathArgs(t *testing.T) {
+	value, transforms := splitTransforms("echo hi | jsonpath:data.token | regex:foo=bar")
+	if value != "echo hi" {
+		t.Errorf("value = %q, want %q", value, "echo hi")
+	}
+	if len(transforms) != 2 || transforms[0] != "jsonpath:data.token" || transforms[1] != "regex:foo=bar" {
+		t.Errorf("transforms = %v, want [jsonpath:data.token regex:foo=bar]", transforms)
+	}
+}
+
+func TestApplyTransformsChainsInOrder(t *testing.T) {
+	got, err := ApplyTransforms("aGVsbG8gd29ybGQ=  ", []string{"trim", "base64d"})
+	if err != nil {
+		t.Fatalf("ApplyTransforms: %v", err)
+	}
+	if got != "hello world" {
+		t.Errorf("got %q, want %q", got, "hello world")
+	}
+}
+
+func TestApplyTransformsStopsOnFirstError(t *testing.T) {
+	_, err := ApplyTransforms("hello", []string{"base64d", "trim"})
+	if err == nil {
+		t.Fatal("ApplyTransforms with invalid base64 returned nil error")
+	}
+}
+
+func TestApplyTransformCases(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		transform string
+		want      string
+	}{
+		{"lower", "HELLO", "lower", "hello"},
+		{"upper", "hello", "upper", "HELLO"},
+		{"base64", "hi", "base64", "aGk="},
+		{"urlencode", "a b", "urlencode", "a+b"},
+		{"urldecode", "a+b", "urldecode", "a b"},
+		{"hex", "hi", "hex", "6869"},
+		{"hexd", "6869", "hexd", "hi"},
+		{"quote", "a'b", "quote", `'a'\''b'`},
+		{"regex", "foo123", "regex:[0-9]+=XXX", "fooXXX"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyTransform(tt.value, tt.transform)
+			if err != nil {
+				t.Fatalf("applyTransform: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("applyTransform(%q, %q) = %q, want %q", tt.value, tt.transform, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyTransformUnknown(t *testing.T) {
+	if _, err := applyTransform("x", "nope"); err == nil {
+		t.Fatal("applyTransform with an unknown filter name returned nil error")
+	}
+}
+
+func TestApplyJSONPathNestedField(t *testing.T) {
+	got, err := applyJSONPath(`{"data":{"token":"abc123"}}`, "data.token")
+	if err != nil {
+		t.Fatalf("applyJSONPath: %v", err)
+	}
+	if got != "abc123" {
+		t.Errorf("got %q, want %q", got, "abc123")
+	}
+}
+
+func TestApplyJSONPathMissingKey(t *testing.T) {
+	if _, err := applyJSONPath(`{"data":{}}`, "data.token"); err == nil {
+		t.Fatal("applyJSONPath with a missing key returned nil error")
+	}
+}
+
+func TestApplyJSONPathInvalidJSON(t *testing.T) {
+	if _, err := applyJSONPath("not json", "data.token"); err == nil {
+		t.Fatal("applyJSONPath with invalid JSON input returned nil error")
+	}
+}
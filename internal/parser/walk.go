@@ -0,0 +1,187 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ============================================================================
+// Directory Walking
+// ============================================================================
+//
+// ParseDirectory's collectMarkdownFiles walks a tree and hands every
+// markdown file straight to the parser in filesystem order, which is fine
+// for "parse everything" but not for callers that want to render a
+// directory as a navigable cheatsheet: they need include/exclude filtering,
+// a depth bound, control over symlinks, and READMEs pulled to the top of
+// their directory the way a Gitea-style repo browser does. Walk builds that
+// ordered list of sources without touching the parser itself.
+
+// WalkOptions controls how Walk discovers markdown sources under a root.
+type WalkOptions struct {
+	// Include, if non-empty, restricts results to files matching at least
+	// one glob pattern (via filepath.Match) against either the file's
+	// basename or its slash-normalized path relative to root.
+	Include []string
+	// Exclude drops any file matching at least one glob pattern, checked
+	// the same way as Include. Exclude wins over Include.
+	Exclude []string
+	// FollowSymlinks makes Walk descend into symlinked directories and
+	// include symlinked files. Off by default to avoid cycles.
+	FollowSymlinks bool
+	// MaxDepth bounds recursion: 0 means unlimited, 1 means root's direct
+	// children only, and so on.
+	MaxDepth int
+}
+
+// Source is one markdown file discovered by Walk.
+type Source struct {
+	// Path is the file's path, rooted the same way the root argument to
+	// Walk was (absolute in, absolute out; relative in, relative out).
+	Path string
+	// RelPath is Path relative to root, slash-normalized.
+	RelPath string
+	// IsReadme reports whether the file's basename (ignoring extension) is
+	// "readme", case-insensitively.
+	IsReadme bool
+}
+
+// Walk recursively discovers markdown files (per isMarkdownFile's extension
+// set) under root and returns them in stable lexical order, with each
+// directory's README hoisted above its siblings. Hidden entries (name
+// starting with "."), directories and files alike, are always skipped; see
+// WalkOptions for symlink and depth handling.
+func Walk(root string, opts WalkOptions) ([]Source, error) {
+	visited := make(map[string]bool)
+	var out []Source
+	if err := walkDir(root, root, 1, opts, visited, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func walkDir(dir, root string, depth int, opts WalkOptions, visited map[string]bool, out *[]Source) error {
+	if opts.MaxDepth > 0 && depth > opts.MaxDepth {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var files, subdirs []os.DirEntry
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		isDir := e.IsDir()
+		if e.Type()&os.ModeSymlink != 0 {
+			if !opts.FollowSymlinks {
+				continue
+			}
+			info, err := os.Stat(filepath.Join(dir, name))
+			if err != nil {
+				continue
+			}
+			isDir = info.IsDir()
+		}
+
+		if isDir {
+			subdirs = append(subdirs, e)
+		} else if isMarkdownFile(name) {
+			files = append(files, e)
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Name() < files[j].Name() })
+	hoistReadme(files)
+
+	for _, f := range files {
+		path := filepath.Join(dir, f.Name())
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			rel = path
+		}
+		relSlash := filepath.ToSlash(rel)
+
+		cleanPath, err := filepath.Abs(path)
+		if err != nil {
+			cleanPath = filepath.Clean(path)
+		}
+		if visited[cleanPath] {
+			continue
+		}
+		if !matchesFilters(opts, f.Name(), relSlash) {
+			continue
+		}
+		visited[cleanPath] = true
+
+		*out = append(*out, Source{
+			Path:     path,
+			RelPath:  relSlash,
+			IsReadme: isReadmeName(f.Name()),
+		})
+	}
+
+	sort.Slice(subdirs, func(i, j int) bool { return subdirs[i].Name() < subdirs[j].Name() })
+	for _, d := range subdirs {
+		if err := walkDir(filepath.Join(dir, d.Name()), root, depth+1, opts, visited, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hoistReadme moves the first README entry in files (already sorted
+// lexically) to the front, preserving the relative order of the rest.
+func hoistReadme(files []os.DirEntry) {
+	for i, f := range files {
+		if isReadmeName(f.Name()) {
+			readme := files[i]
+			copy(files[1:i+1], files[:i])
+			files[0] = readme
+			return
+		}
+	}
+}
+
+// isReadmeName reports whether name's basename (ignoring extension) is
+// "readme", case-insensitively - the same check Gitea's renderer uses to
+// pick a directory's landing file.
+func isReadmeName(name string) bool {
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	return strings.EqualFold(base, "readme")
+}
+
+// matchesFilters reports whether a file should be included given opts,
+// checking both patterns against name (the basename) and relSlash (the
+// slash-normalized path relative to root).
+func matchesFilters(opts WalkOptions, name, relSlash string) bool {
+	for _, pattern := range opts.Exclude {
+		if globMatches(pattern, name, relSlash) {
+			return false
+		}
+	}
+	if len(opts.Include) == 0 {
+		return true
+	}
+	for _, pattern := range opts.Include {
+		if globMatches(pattern, name, relSlash) {
+			return true
+		}
+	}
+	return false
+}
+
+func globMatches(pattern, name, relSlash string) bool {
+	if ok, err := filepath.Match(pattern, name); err == nil && ok {
+		return true
+	}
+	ok, err := filepath.Match(pattern, relSlash)
+	return err == nil && ok
+}
@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll(%q): %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte("# doc\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile(%q): %v", path, err)
+	}
+}
+
+func TestWalkHoistsReadmeAndIgnoresHiddenDirs(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "zebra.md"))
+	writeTestFile(t, filepath.Join(root, "README.md"))
+	writeTestFile(t, filepath.Join(root, "apple.md"))
+	writeTestFile(t, filepath.Join(root, ".git", "ignored.md"))
+
+	sources, err := Walk(root, WalkOptions{})
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+
+	if len(sources) != 3 {
+		t.Fatalf("got %d sources, want 3: %+v", len(sources), sources)
+	}
+	if !sources[0].IsReadme || filepath.Base(sources[0].Path) != "README.md" {
+		t.Errorf("sources[0] = %+v, want README.md hoisted first", sources[0])
+	}
+	if filepath.Base(sources[1].Path) != "apple.md" || filepath.Base(sources[2].Path) != "zebra.md" {
+		t.Errorf("remaining sources not lexically sorted: %+v", sources[1:])
+	}
+}
+
+func TestWalkMaxDepth(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "top.md"))
+	writeTestFile(t, filepath.Join(root, "sub", "nested.md"))
+
+	sources, err := Walk(root, WalkOptions{MaxDepth: 1})
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	if len(sources) != 1 || filepath.Base(sources[0].Path) != "top.md" {
+		t.Errorf("MaxDepth=1: got %+v, want only top.md", sources)
+	}
+}
+
+func TestWalkIncludeExclude(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "keep.md"))
+	writeTestFile(t, filepath.Join(root, "skip.md"))
+	writeTestFile(t, filepath.Join(root, "drafts", "wip.md"))
+
+	sources, err := Walk(root, WalkOptions{
+		Include: []string{"*.md"},
+		Exclude: []string{"skip.md", "drafts/*"},
+	})
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	if len(sources) != 1 || filepath.Base(sources[0].Path) != "keep.md" {
+		t.Errorf("got %+v, want only keep.md", sources)
+	}
+}
+
+func TestWalkKeepsSymlinkedFileAsDistinctPath(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "real.md"))
+	if err := os.Symlink(filepath.Join(root, "real.md"), filepath.Join(root, "alias.md")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	sources, err := Walk(root, WalkOptions{FollowSymlinks: true})
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	if len(sources) != 2 {
+		t.Errorf("got %d sources, want 2 (symlinks are distinct paths): %+v", len(sources), sources)
+	}
+}
@@ -0,0 +1,155 @@
+// Package secrets resolves credential-typed variable values from pluggable
+// external backends (environment, file, OS keyring, or a third-party vault)
+// so plaintext credentials never need to live in a cheat file or in shell
+// history.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Store looks up and enumerates secret values for a single backend scheme.
+type Store interface {
+	// Lookup resolves ref (the part of the reference after "scheme:") to its
+	// value. It is only ever called at command-run time.
+	Lookup(ctx context.Context, ref string) (string, error)
+	// List returns known reference names under prefix so the UI can offer
+	// them as choices. Backends that cannot enumerate return (nil, nil).
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// registry maps a scheme (e.g. "env", "file", "keyring") to the Store that
+// handles it. Third parties add backends (vault, op, azure keyvault, ...) by
+// calling Register from an init().
+var registry = map[string]Store{}
+
+// Register adds or replaces the Store used for scheme.
+func Register(scheme string, store Store) {
+	registry[scheme] = store
+}
+
+func init() {
+	Register("env", EnvStore{})
+	Register("file", FileStore{})
+	Register("keyring", KeyringStore{})
+}
+
+// Ref is a parsed secret reference, e.g. "vault:path#field" parses to
+// Scheme="vault", Rest="path#field".
+type Ref struct {
+	Scheme string
+	Rest   string
+}
+
+// ParseRef splits a "scheme:rest" reference. The "scheme://rest" form used by
+// op:// references is also accepted.
+func ParseRef(s string) (Ref, error) {
+	if idx := strings.Index(s, "://"); idx != -1 {
+		return Ref{Scheme: s[:idx], Rest: s[idx+3:]}, nil
+	}
+	idx := strings.Index(s, ":")
+	if idx == -1 {
+		return Ref{}, fmt.Errorf("secrets: invalid reference %q: missing scheme", s)
+	}
+	return Ref{Scheme: s[:idx], Rest: s[idx+1:]}, nil
+}
+
+// Lookup resolves a "scheme:rest" reference via its registered backend.
+func Lookup(ctx context.Context, ref string) (string, error) {
+	r, err := ParseRef(ref)
+	if err != nil {
+		return "", err
+	}
+	store, ok := registry[r.Scheme]
+	if !ok {
+		return "", fmt.Errorf("secrets: no backend registered for scheme %q", r.Scheme)
+	}
+	return store.Lookup(ctx, r.Rest)
+}
+
+// List enumerates references under prefix for the named scheme.
+func List(ctx context.Context, scheme, prefix string) ([]string, error) {
+	store, ok := registry[scheme]
+	if !ok {
+		return nil, fmt.Errorf("secrets: no backend registered for scheme %q", scheme)
+	}
+	return store.List(ctx, prefix)
+}
+
+// EnvStore resolves secrets from environment variables: "env:NAME".
+type EnvStore struct{}
+
+// Lookup returns the named environment variable's value.
+func (EnvStore) Lookup(_ context.Context, ref string) (string, error) {
+	v, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("secrets: environment variable %q not set", ref)
+	}
+	return v, nil
+}
+
+// List returns environment variable names starting with prefix.
+func (EnvStore) List(_ context.Context, prefix string) ([]string, error) {
+	var names []string
+	for _, kv := range os.Environ() {
+		name, _, ok := strings.Cut(kv, "=")
+		if ok && strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// FileStore resolves secrets from file contents: "file:/path/to/secret".
+type FileStore struct{}
+
+// Lookup reads and trims the contents of the file at ref.
+func (FileStore) Lookup(_ context.Context, ref string) (string, error) {
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		return "", fmt.Errorf("secrets: reading %q: %w", ref, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// List returns the files directly inside the directory named by prefix.
+func (FileStore) List(_ context.Context, prefix string) ([]string, error) {
+	entries, err := os.ReadDir(prefix)
+	if err != nil {
+		return nil, nil
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, prefix+"/"+e.Name())
+		}
+	}
+	return names, nil
+}
+
+// KeyringStore resolves secrets from the OS keyring via the "secret-tool"
+// (libsecret) CLI. Reference format: "keyring:service/account".
+type KeyringStore struct{}
+
+// Lookup shells out to secret-tool to fetch the stored secret.
+func (KeyringStore) Lookup(ctx context.Context, ref string) (string, error) {
+	service, account, ok := strings.Cut(ref, "/")
+	if !ok {
+		return "", fmt.Errorf("secrets: keyring reference %q must be service/account", ref)
+	}
+	cmd := exec.CommandContext(ctx, "secret-tool", "lookup", "service", service, "account", account)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("secrets: keyring lookup for %q: %w", ref, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// List is unsupported: secret-tool has no API to enumerate stored items.
+func (KeyringStore) List(_ context.Context, _ string) ([]string, error) {
+	return nil, nil
+}
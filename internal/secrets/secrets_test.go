@@ -0,0 +1,121 @@
+package secrets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		name       string
+		in         string
+		wantScheme string
+		wantRest   string
+		wantErr    bool
+	}{
+		{"scheme:rest", "env:DB_PASSWORD", "env", "DB_PASSWORD", false},
+		{"scheme with path and field in rest", "vault:secret/data#field", "vault", "secret/data#field", false},
+		{"scheme://rest", "op://vault/item/field", "op", "vault/item/field", false},
+		{"no scheme", "just-a-name", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRef(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseRef(%q) = %+v, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRef(%q): %v", tt.in, err)
+			}
+			if got.Scheme != tt.wantScheme || got.Rest != tt.wantRest {
+				t.Errorf("ParseRef(%q) = %+v, want Scheme=%q Rest=%q", tt.in, got, tt.wantScheme, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestLookupUnknownScheme(t *testing.T) {
+	if _, err := Lookup(context.Background(), "nope:x"); err == nil {
+		t.Fatal("Lookup with an unregistered scheme returned nil error")
+	}
+}
+
+func TestListUnknownScheme(t *testing.T) {
+	if _, err := List(context.Background(), "nope", ""); err == nil {
+		t.Fatal("List with an unregistered scheme returned nil error")
+	}
+}
+
+func TestEnvStoreLookup(t *testing.T) {
+	t.Setenv("CHEATMD_TEST_SECRET", "hunter2")
+
+	v, err := EnvStore{}.Lookup(context.Background(), "CHEATMD_TEST_SECRET")
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	if v != "hunter2" {
+		t.Errorf("Lookup = %q, want %q", v, "hunter2")
+	}
+
+	if _, err := (EnvStore{}).Lookup(context.Background(), "CHEATMD_TEST_SECRET_UNSET"); err == nil {
+		t.Error("Lookup of an unset env var returned nil error")
+	}
+}
+
+func TestEnvStoreList(t *testing.T) {
+	t.Setenv("CHEATMD_TEST_PREFIX_A", "1")
+	t.Setenv("CHEATMD_TEST_PREFIX_B", "2")
+
+	names, err := EnvStore{}.List(context.Background(), "CHEATMD_TEST_PREFIX_")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(names) != 2 {
+		t.Fatalf("got %d names, want 2: %+v", len(names), names)
+	}
+}
+
+func TestFileStoreLookup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secret.txt")
+	if err := os.WriteFile(path, []byte("s3cr3t\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	v, err := FileStore{}.Lookup(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	if v != "s3cr3t" {
+		t.Errorf("Lookup = %q, want trimmed %q", v, "s3cr3t")
+	}
+
+	if _, err := (FileStore{}).Lookup(context.Background(), filepath.Join(dir, "missing.txt")); err == nil {
+		t.Error("Lookup of a missing file returned nil error")
+	}
+}
+
+func TestFileStoreList(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o600); err != nil {
+			t.Fatalf("WriteFile(%q): %v", name, err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+
+	names, err := FileStore{}.List(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(names) != 2 {
+		t.Fatalf("got %d names, want 2 (subdirectory excluded): %+v", len(names), names)
+	}
+}
@@ -0,0 +1,179 @@
+// Package shellparse finds and substitutes "$var" / "${var}" references in a
+// cheat's Shell/Literal/Condition text using a real shell-syntax parser
+// (mvdan.cc/sh/v3/syntax) instead of hand-rolled quote/escape tracking. That
+// gets brace form, ${var:-default} expressions, and quoting context (is this
+// reference sitting inside double quotes?) right for free - a parser already
+// knows single-quoted text never expands and that "$(...)" opens a fresh
+// quoting context, cases the old byte-by-byte scanners kept getting wrong.
+package shellparse
+
+import (
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// VarRef describes one parameter expansion found while walking cmd.
+type VarRef struct {
+	Name        string // bare variable name, no leading $ or braces
+	Quoted      bool   // true if the reference sits inside double quotes
+	BraceForm   bool   // true for "${var}", false for bare "$var"
+	DefaultExpr string // the word after ":-" in "${var:-default}", if any
+}
+
+// span is a half-open byte range [start, end) into the original source.
+type span struct {
+	start, end int
+}
+
+// parse parses cmd as a single shell command line. cmd doesn't have to be a
+// complete script - "kubectl get pods -n $ns" parses fine - but text that
+// isn't valid shell syntax at all (a stray unmatched quote, say) returns a
+// nil file so callers can fall back to leaving it untouched.
+func parse(cmd string) *syntax.File {
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(cmd), "")
+	if err != nil {
+		return nil
+	}
+	return file
+}
+
+// paramExps walks file and returns every *syntax.ParamExp found, along with
+// the byte spans of every double-quoted region, so callers can tell which
+// expansions sit inside double quotes without threading quoting state
+// through a hand-written traversal of every statement form (if/case/subshells/...).
+func paramExps(file *syntax.File) (params []*syntax.ParamExp, quotedSpans []span) {
+	syntax.Walk(file, func(n syntax.Node) bool {
+		switch x := n.(type) {
+		case *syntax.DblQuoted:
+			quotedSpans = append(quotedSpans, span{int(x.Pos().Offset()), int(x.End().Offset())})
+		case *syntax.ParamExp:
+			params = append(params, x)
+		}
+		return true
+	})
+	return params, quotedSpans
+}
+
+// insideAny reports whether offset falls strictly inside one of spans.
+func insideAny(offset int, spans []span) bool {
+	for _, s := range spans {
+		if offset > s.start && offset < s.end {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultWord renders the literal text of a ${var:-default}-style default
+// expression. Defaults that embed their own expansions (${var:-$other})
+// aren't resolved here - only the literal parts are kept, which is enough
+// for the common "treat this as a prefill" case.
+func defaultWord(w *syntax.Word) string {
+	if w == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, part := range w.Parts {
+		if lit, ok := part.(*syntax.Lit); ok {
+			b.WriteString(lit.Value)
+		}
+	}
+	return b.String()
+}
+
+// ReferencedVars returns every variable referenced in cmd, in first-occurrence
+// order. Variables inside single quotes are never returned, since the shell
+// never expands them there - the parser simply doesn't produce a ParamExp for
+// that text.
+func ReferencedVars(cmd string) []VarRef {
+	file := parse(cmd)
+	if file == nil {
+		return nil
+	}
+
+	params, quotedSpans := paramExps(file)
+
+	var refs []VarRef
+	seen := make(map[string]bool)
+	for _, pe := range params {
+		if pe.Param == nil || seen[pe.Param.Value] {
+			continue
+		}
+		seen[pe.Param.Value] = true
+
+		ref := VarRef{
+			Name:      pe.Param.Value,
+			Quoted:    insideAny(int(pe.Pos().Offset()), quotedSpans),
+			BraceForm: !pe.Short,
+		}
+		if pe.Exp != nil {
+			ref.DefaultExpr = defaultWord(pe.Exp.Word)
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// SubstituteVars replaces every "$var"/"${var}" reference in cmd whose name
+// is in scope with its value. When shellQuote is true, values are quoted so
+// they survive as a single shell word: single-quoted at a bare (unquoted)
+// reference, or backslash-escaped for the double-quoted metacharacters
+// ("\"$`\\") at a reference that already sits inside double quotes. Names not
+// present in scope are left as-is.
+func SubstituteVars(cmd string, scope map[string]string, shellQuote bool) string {
+	file := parse(cmd)
+	if file == nil {
+		// Not parseable as shell syntax - fall back to the old best-effort
+		// behavior rather than give up on substitution entirely.
+		result := cmd
+		for name, value := range scope {
+			result = strings.ReplaceAll(result, "$"+name, value)
+		}
+		return result
+	}
+
+	params, quotedSpans := paramExps(file)
+
+	var b strings.Builder
+	pos := 0
+	for _, pe := range params {
+		if pe.Param == nil {
+			continue
+		}
+		value, ok := scope[pe.Param.Value]
+		if !ok {
+			continue
+		}
+
+		start, end := int(pe.Pos().Offset()), int(pe.End().Offset())
+		if start < pos {
+			continue // nested inside an expansion already rewritten above
+		}
+
+		quoted := insideAny(start, quotedSpans)
+		if shellQuote {
+			value = quoteValue(value, quoted)
+		}
+
+		b.WriteString(cmd[pos:start])
+		b.WriteString(value)
+		pos = end
+	}
+	b.WriteString(cmd[pos:])
+	return b.String()
+}
+
+// quoteValue quotes value so it survives substitution as a single word.
+// Inside double quotes only the double-quote metacharacters need escaping;
+// everywhere else the whole value is wrapped in single quotes.
+func quoteValue(value string, quoted bool) string {
+	if quoted {
+		r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+		return r.Replace(value)
+	}
+	if value == "" {
+		return "''"
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,361 @@
+package ui
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/gubarz/cheatmd/internal/config"
+	"github.com/gubarz/cheatmd/internal/parser"
+)
+
+// ============================================================================
+// Key-bindable action registry (fzf's --bind model)
+//
+// handleCheatSelectKey and handleVarResolveKey no longer switch on key
+// chords directly: each phase has a default chord -> action-name map, a
+// config.GetKeybindings() entry for the same chord overrides (or adds to)
+// it, and dispatchAction runs the bound action(s), chained with "+" (e.g.
+// "toggle-select+down"). An action name that isn't in the phase's registry
+// and isn't "execute(...)" is silently ignored, same as an unbound key
+// chord always was.
+// ============================================================================
+
+// actionFunc performs one named action against the model, returning a
+// tea.Cmd the same way the old per-key switch-case bodies did.
+type actionFunc func(m *mainModel) tea.Cmd
+
+// defaultCheatSelectActions are the built-in chord -> action bindings for
+// phaseCheatSelect.
+var defaultCheatSelectActions = map[string]string{
+	"ctrl+c":     "cancel",
+	"esc":        "cancel",
+	"enter":      "accept",
+	"up":         "up",
+	"ctrl+p":     "up",
+	"down":       "down",
+	"ctrl+n":     "down",
+	"pgup":       "page-up",
+	"pgdown":     "page-down",
+	"home":       "home",
+	"ctrl+a":     "home",
+	"end":        "end",
+	"ctrl+e":     "end",
+	"ctrl+o":     "open-in-editor",
+	"ctrl+/":     "toggle-preview",
+	"shift+up":   "scroll-preview-up",
+	"shift+down": "scroll-preview-down",
+	"tab":        "toggle-select+down",
+	"shift+tab":  "toggle-select+up",
+	"ctrl+r":     "reload",
+	"ctrl+y":     "copy-command",
+	"ctrl+t":     "cycle-output",
+}
+
+// defaultVarResolveActions are the built-in chord -> action bindings for
+// phaseVarResolve.
+var defaultVarResolveActions = map[string]string{
+	"ctrl+c": "cancel",
+	"esc":    "back",
+	"enter":  "accept",
+	"up":     "up",
+	"down":   "down",
+	"ctrl+p": "preview-up",
+	"ctrl+n": "preview-down",
+	"ctrl+/": "toggle-preview",
+	"pgup":   "page-up",
+	"pgdown": "page-down",
+	"tab":    "toggle-select",
+	"ctrl+a": "select-all",
+	"ctrl+d": "deselect-all",
+	"ctrl+o": "open-in-editor",
+	"ctrl+r": "reload",
+}
+
+// cheatSelectActions implements each name used by defaultCheatSelectActions
+// (or a user keybinding targeting phaseCheatSelect).
+var cheatSelectActions = map[string]actionFunc{
+	"cancel": func(m *mainModel) tea.Cmd {
+		m.quitting = true
+		return tea.Quit
+	},
+	"accept": func(m *mainModel) tea.Cmd {
+		if config.GetMulti() && len(m.multiOrder) > 0 {
+			m.multiActive = true
+			m.multiQueue = append([]*parser.Cheat(nil), m.multiOrder...)
+			m.selected = m.multiQueue[0]
+			m.multiQueue = m.multiQueue[1:]
+			return m.startVarResolution()
+		}
+		if m.cursor < len(m.filtered) {
+			m.selected = m.filtered[m.cursor].cheat
+			return m.startVarResolution()
+		}
+		return nil
+	},
+	"up":   func(m *mainModel) tea.Cmd { m.moveCursor(-1); return nil },
+	"down": func(m *mainModel) tea.Cmd { m.moveCursor(1); return nil },
+	"toggle-select": func(m *mainModel) tea.Cmd {
+		if !config.GetMulti() || m.cursor >= len(m.filtered) {
+			return nil
+		}
+		cheat := m.filtered[m.cursor].cheat
+		if m.multiSelected == nil {
+			m.multiSelected = make(map[*parser.Cheat]bool)
+		}
+		if m.multiSelected[cheat] {
+			delete(m.multiSelected, cheat)
+			for i, c := range m.multiOrder {
+				if c == cheat {
+					m.multiOrder = append(m.multiOrder[:i], m.multiOrder[i+1:]...)
+					break
+				}
+			}
+			return nil
+		}
+		if max := config.GetMultiMax(); max > 0 && len(m.multiOrder) >= max {
+			return nil
+		}
+		m.multiSelected[cheat] = true
+		m.multiOrder = append(m.multiOrder, cheat)
+		return nil
+	},
+	"page-up":   func(m *mainModel) tea.Cmd { m.moveCursor(-10); return nil },
+	"page-down": func(m *mainModel) tea.Cmd { m.moveCursor(10); return nil },
+	"home":      func(m *mainModel) tea.Cmd { m.cursor = 0; return nil },
+	"end":       func(m *mainModel) tea.Cmd { m.cursor = max(0, len(m.filtered)-1); return nil },
+	"open-in-editor": func(m *mainModel) tea.Cmd {
+		if m.cursor < len(m.filtered) {
+			openFileInViewer(m.filtered[m.cursor].cheat.File)
+		}
+		return nil
+	},
+	"toggle-preview": func(m *mainModel) tea.Cmd {
+		m.showCheatPreview = !m.showCheatPreview
+		return nil
+	},
+	"scroll-preview-up": func(m *mainModel) tea.Cmd {
+		if m.showCheatPreview {
+			m.cheatPreviewScroll = maxInt(0, m.cheatPreviewScroll-1)
+		}
+		return nil
+	},
+	"scroll-preview-down": func(m *mainModel) tea.Cmd {
+		if m.showCheatPreview {
+			m.cheatPreviewScroll++
+		}
+		return nil
+	},
+	"reload": func(m *mainModel) tea.Cmd { return m.doReload() },
+	"copy-command": func(m *mainModel) tea.Cmd {
+		if m.cursor < len(m.filtered) {
+			_ = m.executor.CopyToClipboard(m.filtered[m.cursor].cheat.Command)
+		}
+		return nil
+	},
+	"cycle-output": func(m *mainModel) tea.Cmd {
+		m.outputOverride = nextOutputMode(m.outputOverride)
+		return nil
+	},
+}
+
+// outputModeCycle is the order ctrl+t (the "cycle-output" action) steps
+// through, overriding config.GetOutput() for just the cheat about to run.
+var outputModeCycle = []string{"print", "copy", "exec"}
+
+// nextOutputMode returns outputModeCycle's entry after current ("" counts as
+// config.GetOutput() - not yet overridden - so the first press starts from
+// "print" regardless of the configured default).
+func nextOutputMode(current string) string {
+	for i, mode := range outputModeCycle {
+		if mode == current {
+			return outputModeCycle[(i+1)%len(outputModeCycle)]
+		}
+	}
+	return outputModeCycle[0]
+}
+
+// varResolveActions implements each name used by defaultVarResolveActions
+// (or a user keybinding targeting phaseVarResolve).
+var varResolveActions = map[string]actionFunc{
+	"cancel": func(m *mainModel) tea.Cmd {
+		m.quitting = true
+		m.selected = nil
+		return tea.Quit
+	},
+	"back": func(m *mainModel) tea.Cmd {
+		if m.varState.currentIdx > 0 {
+			m.varState.currentIdx--
+			vs := &m.varState.vars[m.varState.currentIdx]
+			vs.resolved = false
+			vs.value = ""
+			vs.skipAutoCont = true
+			m.textInput.SetValue("")
+			m.cursor = 0
+			m.offset = 0
+			return m.prepareCurrentVar()
+		}
+		m.phase = phaseCheatSelect
+		m.varState = nil
+		m.selected = nil
+		m.textInput.SetValue("")
+		m.textInput.Placeholder = "Type to search..."
+		m.cursor = 0
+		m.offset = 0
+		return nil
+	},
+	"accept": func(m *mainModel) tea.Cmd { return m.acceptVarValue() },
+	"up": func(m *mainModel) tea.Cmd {
+		if !m.varState.isPromptOnly {
+			m.moveVarCursor(-1)
+		}
+		return nil
+	},
+	"down": func(m *mainModel) tea.Cmd {
+		if !m.varState.isPromptOnly {
+			m.moveVarCursor(1)
+		}
+		return nil
+	},
+	"preview-up": func(m *mainModel) tea.Cmd {
+		if m.varState.showPreview && m.varState.selectOpts.PreviewCmd != "" {
+			m.varState.previewScroll = maxInt(0, m.varState.previewScroll-1)
+		} else if !m.varState.isPromptOnly {
+			m.moveVarCursor(-1)
+		}
+		return nil
+	},
+	"preview-down": func(m *mainModel) tea.Cmd {
+		if m.varState.showPreview && m.varState.selectOpts.PreviewCmd != "" {
+			m.varState.previewScroll++
+		} else if !m.varState.isPromptOnly {
+			m.moveVarCursor(1)
+		}
+		return nil
+	},
+	"toggle-preview": func(m *mainModel) tea.Cmd {
+		m.varState.showPreview = !m.varState.showPreview
+		return nil
+	},
+	"page-up": func(m *mainModel) tea.Cmd {
+		if !m.varState.isPromptOnly {
+			m.moveVarCursor(-10)
+		}
+		return nil
+	},
+	"page-down": func(m *mainModel) tea.Cmd {
+		if !m.varState.isPromptOnly {
+			m.moveVarCursor(10)
+		}
+		return nil
+	},
+	"toggle-select": func(m *mainModel) tea.Cmd {
+		if m.varState.isPromptOnly {
+			return nil
+		}
+		if m.varState.selectOpts.Multi {
+			m.toggleVarCursor()
+		} else if m.cursor < len(m.varState.filtered) {
+			m.textInput.SetValue(m.varState.filtered[m.cursor].display)
+		}
+		return nil
+	},
+	"select-all": func(m *mainModel) tea.Cmd {
+		if !m.varState.isPromptOnly && m.varState.selectOpts.Multi {
+			m.selectAllVarOptions()
+		}
+		return nil
+	},
+	"deselect-all": func(m *mainModel) tea.Cmd {
+		if !m.varState.isPromptOnly && m.varState.selectOpts.Multi {
+			m.deselectAllVarOptions()
+		}
+		return nil
+	},
+	"open-in-editor": func(m *mainModel) tea.Cmd {
+		if m.varState != nil && m.varState.cheat != nil {
+			openFileInViewer(m.varState.cheat.File)
+		}
+		return nil
+	},
+	"reload": func(m *mainModel) tea.Cmd { return m.reloadCurrentVar() },
+}
+
+// resolveActionChord returns the action string bound to key for the given
+// phase, config.GetKeybindings() (keyed fzf-style, e.g. "ctrl-y") taking
+// priority over the phase's default bindings.
+func resolveActionChord(phase uiPhase, key string) (string, bool) {
+	if action, ok := config.GetKeybindings()[strings.ReplaceAll(key, "+", "-")]; ok {
+		return action, true
+	}
+	defaults := defaultCheatSelectActions
+	if phase == phaseVarResolve {
+		defaults = defaultVarResolveActions
+	}
+	action, ok := defaults[key]
+	return action, ok
+}
+
+// dispatchAction runs actionStr's "+"-chained action names (fzf's --bind
+// chain syntax, e.g. "toggle-select+down") against m in order, batching any
+// returned tea.Cmd. A name absent from the phase's registry is ignored,
+// matching the old switch statements' default no-op.
+func dispatchAction(m *mainModel, phase uiPhase, actionStr string) tea.Cmd {
+	actions := cheatSelectActions
+	if phase == phaseVarResolve {
+		actions = varResolveActions
+	}
+
+	var cmds []tea.Cmd
+	for _, name := range strings.Split(actionStr, "+") {
+		name = strings.TrimSpace(name)
+		if execCmd, ok := strings.CutPrefix(name, "execute("); ok {
+			cmds = append(cmds, executeAction(m, strings.TrimSuffix(execCmd, ")")))
+			continue
+		}
+		if fn, ok := actions[name]; ok {
+			cmds = append(cmds, fn(m))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// executeAction runs cmdStr through the configured shell without leaving
+// the TUI, fzf's execute() style: "{}" is replaced by the current
+// selection (the cursored cheat's command in phaseCheatSelect, or the
+// highlighted option/typed value in phaseVarResolve) and "$VAR_NAME"
+// placeholders are replaced by already-resolved variables from the
+// cheat's scope.
+func executeAction(m *mainModel, cmdStr string) tea.Cmd {
+	return func() tea.Msg {
+		var cheat *parser.Cheat
+		selection := ""
+
+		switch m.phase {
+		case phaseCheatSelect:
+			if m.cursor < len(m.filtered) {
+				cheat = m.filtered[m.cursor].cheat
+				selection = cheat.Command
+			}
+		case phaseVarResolve:
+			if m.varState != nil {
+				cheat = m.varState.cheat
+				selection = m.currentVarCandidate()
+			}
+		}
+
+		rendered := strings.ReplaceAll(cmdStr, "{}", shellQuote(selection))
+		if cheat != nil {
+			for name, value := range cheat.Scope {
+				rendered = strings.ReplaceAll(rendered, "$"+name, shellQuote(value))
+			}
+		}
+
+		cmd := exec.Command(config.GetShell(), "-c", rendered)
+		cmd.Stdin = os.Stdin
+		_ = cmd.Run()
+		return nil
+	}
+}
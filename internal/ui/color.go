@@ -0,0 +1,259 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// ColorProfile is how many colors the active terminal (or a --force-color
+// override) can render, coarsest to richest.
+type ColorProfile int
+
+const (
+	ColorProfileNone ColorProfile = iota
+	ColorProfileANSI16
+	ColorProfile256
+	ColorProfileTrueColor
+)
+
+// forcedColorProfile, when non-nil, overrides DetectColorProfile - set by
+// the --force-color flag via SetForcedColorProfile.
+var forcedColorProfile *ColorProfile
+
+// SetForcedColorProfile parses --force-color's value ("truecolor", "256",
+// "16", "none", or "" to clear an override) and, if recognized, makes every
+// subsequent DetectColorProfile call (and so every color resolved through
+// resolveColor, and every renderer configured via applyColorProfile) use it
+// instead of sniffing the terminal. Returns an error for anything else.
+func SetForcedColorProfile(value string) error {
+	switch value {
+	case "":
+		forcedColorProfile = nil
+		return nil
+	case "truecolor":
+		return setForced(ColorProfileTrueColor)
+	case "256":
+		return setForced(ColorProfile256)
+	case "16":
+		return setForced(ColorProfileANSI16)
+	case "none":
+		return setForced(ColorProfileNone)
+	default:
+		return fmt.Errorf("unknown --force-color value %q (want truecolor, 256, 16, or none)", value)
+	}
+}
+
+func setForced(p ColorProfile) error {
+	forcedColorProfile = &p
+	return nil
+}
+
+// DetectColorProfile returns the forced profile if --force-color set one,
+// otherwise sniffs COLORTERM/TERM/NO_COLOR the way most terminal apps do:
+// NO_COLOR (https://no-color.org) disables color outright, a TERM of "" or
+// "dumb" does the same, COLORTERM of "truecolor" or "24bit" means full RGB,
+// a TERM containing "256color" means the xterm-256 palette, and anything
+// else still attempting color falls back to the 16-color ANSI palette.
+func DetectColorProfile() ColorProfile {
+	if forcedColorProfile != nil {
+		return *forcedColorProfile
+	}
+	if _, noColor := os.LookupEnv("NO_COLOR"); noColor {
+		return ColorProfileNone
+	}
+
+	term := os.Getenv("TERM")
+	if term == "" || term == "dumb" {
+		return ColorProfileNone
+	}
+
+	switch os.Getenv("COLORTERM") {
+	case "truecolor", "24bit":
+		return ColorProfileTrueColor
+	}
+	if strings.Contains(term, "256color") {
+		return ColorProfile256
+	}
+	return ColorProfileANSI16
+}
+
+// applyColorProfile sets r's rendering color profile to DetectColorProfile,
+// so every lipgloss.Style rendered through r - not just colors resolved by
+// resolveColor - is downsampled (or stripped entirely) the same way.
+func applyColorProfile(r *lipgloss.Renderer) {
+	switch DetectColorProfile() {
+	case ColorProfileTrueColor:
+		r.SetColorProfile(termenv.TrueColor)
+	case ColorProfile256:
+		r.SetColorProfile(termenv.ANSI256)
+	case ColorProfileANSI16:
+		r.SetColorProfile(termenv.ANSI)
+	default:
+		r.SetColorProfile(termenv.Ascii)
+	}
+}
+
+// ansiSGRCodes maps the 16 basic ANSI SGR codes cheatmd's defaults and
+// existing configs use (e.g. "36" for cyan) to their ANSI-16 palette index.
+var ansiSGRCodes = map[string]int{
+	"30": 0, "31": 1, "32": 2, "33": 3,
+	"34": 4, "35": 5, "36": 6, "37": 7,
+	"90": 8, "91": 9, "92": 10, "93": 11,
+	"94": 12, "95": 13, "96": 14, "97": 15,
+}
+
+// ansi16RGB is the standard xterm RGB approximation for the 16 ANSI colors,
+// indexed the same way as ansiSGRCodes' values and a bare "0"-"15" index.
+var ansi16RGB = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// namedWebColors is the common subset of CSS/web color names cheat authors
+// are likely to reach for in a theme file, rather than the full 140-name
+// list.
+var namedWebColors = map[string][3]int{
+	"black": {0, 0, 0}, "white": {255, 255, 255}, "red": {255, 0, 0},
+	"green": {0, 128, 0}, "blue": {0, 0, 255}, "yellow": {255, 255, 0},
+	"cyan": {0, 255, 255}, "magenta": {255, 0, 255}, "gray": {128, 128, 128},
+	"grey": {128, 128, 128}, "orange": {255, 165, 0}, "purple": {128, 0, 128},
+	"pink": {255, 192, 203}, "brown": {165, 42, 42}, "navy": {0, 0, 128},
+	"teal": {0, 128, 128}, "olive": {128, 128, 0}, "maroon": {128, 0, 0},
+	"silver": {192, 192, 192}, "gold": {255, 215, 0}, "indigo": {75, 0, 130},
+	"violet": {238, 130, 238}, "coral": {255, 127, 80}, "salmon": {250, 128, 114},
+	"khaki": {240, 230, 140}, "crimson": {220, 20, 60}, "turquoise": {64, 224, 208},
+	"lavender": {230, 230, 250}, "beige": {245, 245, 220}, "chocolate": {210, 105, 30},
+}
+
+var (
+	hexColorRE = regexp.MustCompile(`^#([0-9a-fA-F]{6})$`)
+	rgbColorRE = regexp.MustCompile(`^rgb\(\s*(\d{1,3})\s*,\s*(\d{1,3})\s*,\s*(\d{1,3})\s*\)$`)
+)
+
+// parseRGB parses value as "#rrggbb", "rgb(r,g,b)", or a namedWebColors
+// entry (case-insensitive), returning ok=false if it's none of those - e.g.
+// a bare ANSI SGR code or an already-indexed 256-color string, which the
+// caller handles separately.
+func parseRGB(value string) (r, g, b int, ok bool) {
+	if m := hexColorRE.FindStringSubmatch(value); m != nil {
+		n, _ := strconv.ParseInt(m[1], 16, 32)
+		return int(n >> 16), int((n >> 8) & 0xff), int(n & 0xff), true
+	}
+	if m := rgbColorRE.FindStringSubmatch(strings.ToLower(strings.TrimSpace(value))); m != nil {
+		r, _ := strconv.Atoi(m[1])
+		g, _ := strconv.Atoi(m[2])
+		b, _ := strconv.Atoi(m[3])
+		return r, g, b, true
+	}
+	if rgb, found := namedWebColors[strings.ToLower(value)]; found {
+		return rgb[0], rgb[1], rgb[2], true
+	}
+	return 0, 0, 0, false
+}
+
+// xterm256RGB returns the RGB approximation for xterm-256 palette index i
+// (0-255): 0-15 is the ANSI-16 palette, 16-231 the 6x6x6 color cube, and
+// 232-255 the grayscale ramp - the standard xterm256 layout, computed
+// rather than hardcoded as a 256-entry table.
+func xterm256RGB(i int) (r, g, b int) {
+	switch {
+	case i < 16:
+		return ansi16RGB[i][0], ansi16RGB[i][1], ansi16RGB[i][2]
+	case i < 232:
+		levels := [6]int{0, 95, 135, 175, 215, 255}
+		i -= 16
+		return levels[i/36], levels[(i/6)%6], levels[i%6]
+	default:
+		gray := 8 + 10*(i-232)
+		return gray, gray, gray
+	}
+}
+
+// colorDistance is the "redmean" weighted-RGB approximation of perceptual
+// color distance - cheap enough to run per render, and much closer to human
+// perception than plain Euclidean RGB distance.
+func colorDistance(r1, g1, b1, r2, g2, b2 int) float64 {
+	rmean := float64(r1+r2) / 2
+	dr := float64(r1 - r2)
+	dg := float64(g1 - g2)
+	db := float64(b1 - b2)
+	return math.Sqrt((2+rmean/256)*dr*dr + 4*dg*dg + (2+(255-rmean)/256)*db*db)
+}
+
+// nearestANSI16 returns the ANSI-16 index whose xterm RGB approximation is
+// closest to (r, g, b).
+func nearestANSI16(r, g, b int) int {
+	best, bestDist := 0, math.MaxFloat64
+	for i, rgb := range ansi16RGB {
+		if d := colorDistance(r, g, b, rgb[0], rgb[1], rgb[2]); d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// nearestXterm256 returns the xterm-256 palette index closest to (r, g, b).
+func nearestXterm256(r, g, b int) int {
+	best, bestDist := 0, math.MaxFloat64
+	for i := 0; i < 256; i++ {
+		pr, pg, pb := xterm256RGB(i)
+		if d := colorDistance(r, g, b, pr, pg, pb); d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// resolveColor turns a config/theme color string into a lipgloss.Color
+// appropriate for profile: a bare ANSI SGR code (e.g. "36") or an
+// already-indexed "0"-"255" string is downsampled only if profile can't
+// render it directly, while "#rrggbb", "rgb(r,g,b)", and namedWebColors
+// entries are downsampled whenever profile is below ColorProfileTrueColor.
+// profile == ColorProfileNone returns lipgloss.NoColor{}, matching NO_COLOR
+// semantics.
+func resolveColor(value string, profile ColorProfile) lipgloss.TerminalColor {
+	if value == "" {
+		return lipgloss.NoColor{}
+	}
+	if profile == ColorProfileNone {
+		return lipgloss.NoColor{}
+	}
+
+	if idx, ok := ansiSGRCodes[value]; ok {
+		if profile == ColorProfileANSI16 {
+			return lipgloss.Color(strconv.Itoa(idx))
+		}
+		return lipgloss.Color(strconv.Itoa(idx))
+	}
+
+	if r, g, b, ok := parseRGB(value); ok {
+		switch profile {
+		case ColorProfileTrueColor:
+			return lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", r, g, b))
+		case ColorProfile256:
+			return lipgloss.Color(strconv.Itoa(nearestXterm256(r, g, b)))
+		default: // ColorProfileANSI16
+			return lipgloss.Color(strconv.Itoa(nearestANSI16(r, g, b)))
+		}
+	}
+
+	// Already an xterm-256 index (e.g. "240") or a name lipgloss itself
+	// understands - pass it through as-is for truecolor/256 terminals, and
+	// downsample a numeric index for ANSI16-only ones.
+	if profile == ColorProfileANSI16 {
+		if idx, err := strconv.Atoi(value); err == nil && idx >= 0 && idx <= 255 {
+			r, g, b := xterm256RGB(idx)
+			return lipgloss.Color(strconv.Itoa(nearestANSI16(r, g, b)))
+		}
+	}
+	return lipgloss.Color(value)
+}
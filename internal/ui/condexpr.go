@@ -0,0 +1,431 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ============================================================================
+// Condition Expression Language
+// ============================================================================
+//
+// var conditions started as a single "$var == value" / "$var != value" /
+// truthy check (see the old evaluateCondition). Real cheatsheets want to
+// combine several of those, e.g. `$env == prod && $region != us-east-1` or
+// match a pattern with `$image =~ ^ghcr.io/`. This file adds a small
+// expression language for that: a tokenizer, a precedence-climbing parser
+// producing an AST, and an evaluator. selectVariant calls Evaluate;
+// collectVariables calls conditionVars for dependency tracking instead of
+// findAllVars.
+
+// ----------------------------------------------------------------------------
+// AST
+// ----------------------------------------------------------------------------
+
+// Node is one node of a parsed condition expression.
+type Node interface {
+	node()
+}
+
+// BinOp is a binary operator: "==", "!=", "=~", "!~", "&&", or "||".
+type BinOp struct {
+	Op          string
+	Left, Right Node
+}
+
+// UnaryOp is currently just logical negation, "!".
+type UnaryOp struct {
+	Op string
+	X  Node
+}
+
+// VarRef is a "$name" operand, resolved against scope at eval time.
+type VarRef struct {
+	Name string
+}
+
+// Literal is a quoted string, bare word, or number operand.
+type Literal struct {
+	Value string
+}
+
+func (*BinOp) node()   {}
+func (*UnaryOp) node() {}
+func (*VarRef) node()  {}
+func (*Literal) node() {}
+
+// ----------------------------------------------------------------------------
+// Tokenizer
+// ----------------------------------------------------------------------------
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokVar
+	tokWord
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+}
+
+// condOps lists recognized operators, longest first so "==" is matched before
+// a bare "=" would be (which isn't itself a valid operator here).
+var condOps = []string{"==", "!=", "=~", "!~", "&&", "||", "!"}
+
+// matchOp returns the operator s starts with, or "" if none match.
+func matchOp(s string) string {
+	for _, op := range condOps {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// isWordBoundary reports whether s (from the current position) starts a new
+// token - whitespace, a paren, a quote, or a recognized operator - so a bare
+// word scan knows where to stop.
+func isWordBoundary(s string) bool {
+	if s == "" {
+		return true
+	}
+	c := s[0]
+	if c == ' ' || c == '\t' || c == '(' || c == ')' || c == '"' || c == '\'' {
+		return true
+	}
+	return matchOp(s) != ""
+}
+
+// tokenizeCondition breaks a condition expression into tokens.
+func tokenizeCondition(s string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(':
+			toks = append(toks, token{kind: tokLParen})
+			i++
+		case c == ')':
+			toks = append(toks, token{kind: tokRParen})
+			i++
+		case c == '$':
+			j := i + 1
+			for j < len(s) && isIdentByte(s[j], j == i+1) {
+				j++
+			}
+			if j == i+1 {
+				return nil, fmt.Errorf("condexpr: bare '$' at offset %d", i)
+			}
+			toks = append(toks, token{kind: tokVar, value: s[i+1 : j]})
+			i = j
+		case c == '"' || c == '\'':
+			value, next, err := scanQuoted(s, i)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, token{kind: tokWord, value: value})
+			i = next
+		case matchOp(s[i:]) != "":
+			op := matchOp(s[i:])
+			toks = append(toks, token{kind: tokOp, value: op})
+			i += len(op)
+		default:
+			j := i
+			for j < len(s) && !isWordBoundary(s[j:]) {
+				j++
+			}
+			if j == i {
+				return nil, fmt.Errorf("condexpr: unexpected character %q at offset %d", c, i)
+			}
+			toks = append(toks, token{kind: tokWord, value: s[i:j]})
+			i = j
+		}
+	}
+	toks = append(toks, token{kind: tokEOF})
+	return toks, nil
+}
+
+// scanQuoted reads a '...' or "..." string starting at i (s[i] is the quote
+// char), honoring "\\" escapes of the quote character itself. It returns the
+// unquoted value and the index just past the closing quote.
+func scanQuoted(s string, i int) (string, int, error) {
+	quote := s[i]
+	var b strings.Builder
+	j := i + 1
+	for j < len(s) {
+		if s[j] == '\\' && j+1 < len(s) && s[j+1] == quote {
+			b.WriteByte(quote)
+			j += 2
+			continue
+		}
+		if s[j] == quote {
+			return b.String(), j + 1, nil
+		}
+		b.WriteByte(s[j])
+		j++
+	}
+	return "", 0, fmt.Errorf("condexpr: unterminated quote starting at offset %d", i)
+}
+
+// isIdentByte reports whether c is valid in a $var name.
+func isIdentByte(c byte, first bool) bool {
+	if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' {
+		return true
+	}
+	return !first && c >= '0' && c <= '9'
+}
+
+// ----------------------------------------------------------------------------
+// Parser (precedence climbing: || < && < unary "!" < comparison < primary)
+// ----------------------------------------------------------------------------
+
+type condParser struct {
+	toks []token
+	pos  int
+}
+
+func (p *condParser) peek() token  { return p.toks[p.pos] }
+func (p *condParser) advance() token {
+	t := p.toks[p.pos]
+	if p.pos < len(p.toks)-1 {
+		p.pos++
+	}
+	return t
+}
+
+// parseCondition parses a full condition expression, erroring on trailing
+// tokens (e.g. a stray ")") or malformed operands.
+func parseCondition(expr string) (Node, error) {
+	toks, err := tokenizeCondition(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &condParser{toks: toks}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, fmt.Errorf("condexpr: unexpected trailing token %q", p.peek().value)
+	}
+	return node, nil
+}
+
+func (p *condParser) parseOr() (Node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().value == "||" {
+		p.advance()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinOp{Op: "||", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *condParser) parseAnd() (Node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().value == "&&" {
+		p.advance()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinOp{Op: "&&", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *condParser) parseUnary() (Node, error) {
+	if p.peek().kind == tokOp && p.peek().value == "!" {
+		p.advance()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryOp{Op: "!", X: x}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *condParser) parseComparison() (Node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind == tokOp && (t.value == "==" || t.value == "!=" || t.value == "=~" || t.value == "!~") {
+		p.advance()
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return &BinOp{Op: t.value, Left: left, Right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *condParser) parsePrimary() (Node, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokLParen:
+		p.advance()
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokRParen {
+			return nil, fmt.Errorf("condexpr: expected ')'")
+		}
+		p.advance()
+		return node, nil
+	case tokVar:
+		p.advance()
+		return &VarRef{Name: t.value}, nil
+	case tokWord:
+		p.advance()
+		return &Literal{Value: t.value}, nil
+	default:
+		return nil, fmt.Errorf("condexpr: unexpected token %q", t.value)
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Evaluator
+// ----------------------------------------------------------------------------
+
+var condRegexCache = struct {
+	mu    sync.Mutex
+	cache map[string]*regexp.Regexp
+}{cache: make(map[string]*regexp.Regexp)}
+
+// compiledCondRegex compiles pattern at most once, reusing it for every
+// subsequent "=~"/"!~" check against the same pattern.
+func compiledCondRegex(pattern string) (*regexp.Regexp, error) {
+	condRegexCache.mu.Lock()
+	defer condRegexCache.mu.Unlock()
+	if re, ok := condRegexCache.cache[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	condRegexCache.cache[pattern] = re
+	return re, nil
+}
+
+// evalValue resolves a node to its string value: a $var's scope value, a
+// literal's text, or "true"/"" for a nested boolean expression.
+func evalValue(n Node, scope map[string]string) string {
+	switch x := n.(type) {
+	case *VarRef:
+		return scope[x.Name]
+	case *Literal:
+		return x.Value
+	default:
+		if evalBool(n, scope) {
+			return "true"
+		}
+		return ""
+	}
+}
+
+// evalBool evaluates n as a boolean. Empty string is false for "!"; && and ||
+// short-circuit via Go's own operators.
+func evalBool(n Node, scope map[string]string) bool {
+	switch x := n.(type) {
+	case *BinOp:
+		switch x.Op {
+		case "&&":
+			return evalBool(x.Left, scope) && evalBool(x.Right, scope)
+		case "||":
+			return evalBool(x.Left, scope) || evalBool(x.Right, scope)
+		case "==":
+			return evalValue(x.Left, scope) == evalValue(x.Right, scope)
+		case "!=":
+			return evalValue(x.Left, scope) != evalValue(x.Right, scope)
+		case "=~", "!~":
+			re, err := compiledCondRegex(evalValue(x.Right, scope))
+			if err != nil {
+				return false
+			}
+			matched := re.MatchString(evalValue(x.Left, scope))
+			if x.Op == "!~" {
+				return !matched
+			}
+			return matched
+		}
+		return false
+	case *UnaryOp:
+		return !evalBool(x.X, scope)
+	default:
+		return evalValue(n, scope) != ""
+	}
+}
+
+// Evaluate parses and evaluates condition against scope. A condition that
+// fails to parse is treated as false, with the parse error logged to stderr
+// under CHEATMD_DEBUG rather than aborting resolution.
+func Evaluate(condition string, scope map[string]string) bool {
+	condition = strings.TrimSpace(condition)
+	if condition == "" {
+		return false
+	}
+	node, err := parseCondition(condition)
+	if err != nil {
+		if os.Getenv("CHEATMD_DEBUG") != "" {
+			fmt.Fprintf(os.Stderr, "[DEBUG] condexpr: %v\n", err)
+		}
+		return false
+	}
+	return evalBool(node, scope)
+}
+
+// conditionVars walks condition's AST and returns every $var it references,
+// in first-occurrence order. A condition that fails to parse yields no
+// dependencies rather than erroring.
+func conditionVars(condition string) []string {
+	node, err := parseCondition(strings.TrimSpace(condition))
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	var walk func(Node)
+	walk = func(n Node) {
+		switch x := n.(type) {
+		case *VarRef:
+			if !seen[x.Name] {
+				seen[x.Name] = true
+				names = append(names, x.Name)
+			}
+		case *UnaryOp:
+			walk(x.X)
+		case *BinOp:
+			walk(x.Left)
+			walk(x.Right)
+		}
+	}
+	walk(node)
+	return names
+}
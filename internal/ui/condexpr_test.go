@@ -0,0 +1,141 @@
+package ui
+
+import "testing"
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition string
+		scope     map[string]string
+		expected  bool
+	}{
+		{
+			name:      "equality match",
+			condition: "$env == prod",
+			scope:     map[string]string{"env": "prod"},
+			expected:  true,
+		},
+		{
+			name:      "equality mismatch",
+			condition: "$env == prod",
+			scope:     map[string]string{"env": "staging"},
+			expected:  false,
+		},
+		{
+			name:      "and of two comparisons",
+			condition: "$env == prod && $region != us-east-1",
+			scope:     map[string]string{"env": "prod", "region": "eu-west-1"},
+			expected:  true,
+		},
+		{
+			name:      "and short-circuits on false left operand",
+			condition: `$env == staging && $region =~ "("`,
+			scope:     map[string]string{"env": "prod", "region": "eu-west-1"},
+			expected:  false,
+		},
+		{
+			name:      "or with one true side",
+			condition: "$auth_method == kerberos || $auth_method == hash",
+			scope:     map[string]string{"auth_method": "hash"},
+			expected:  true,
+		},
+		{
+			name:      "negation of truthy var",
+			condition: "!$auth_flags",
+			scope:     map[string]string{"auth_flags": ""},
+			expected:  true,
+		},
+		{
+			name:      "regex match",
+			condition: "$image =~ ^ghcr.io/",
+			scope:     map[string]string{"image": "ghcr.io/foo/bar"},
+			expected:  true,
+		},
+		{
+			name:      "negated regex match",
+			condition: "$image !~ ^ghcr.io/",
+			scope:     map[string]string{"image": "docker.io/foo/bar"},
+			expected:  true,
+		},
+		{
+			name:      "parentheses override precedence",
+			condition: "$a == 1 && ($b == 2 || $b == 3)",
+			scope:     map[string]string{"a": "1", "b": "3"},
+			expected:  true,
+		},
+		{
+			name:      "quoted operand with spaces",
+			condition: `$name == "jane doe"`,
+			scope:     map[string]string{"name": "jane doe"},
+			expected:  true,
+		},
+		{
+			name:      "bare truthy check",
+			condition: "$credential",
+			scope:     map[string]string{"credential": "abc123"},
+			expected:  true,
+		},
+		{
+			name:      "invalid syntax returns false",
+			condition: "$env == (",
+			scope:     map[string]string{"env": "prod"},
+			expected:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := Evaluate(tt.condition, tt.scope); actual != tt.expected {
+				t.Errorf("Evaluate(%q, %v) = %v, want %v", tt.condition, tt.scope, actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConditionVars(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition string
+		expected  []string
+	}{
+		{
+			name:      "single var",
+			condition: "$auth_method == kerberos",
+			expected:  []string{"auth_method"},
+		},
+		{
+			name:      "multiple vars in order",
+			condition: "$env == prod && $region != us-east-1",
+			expected:  []string{"env", "region"},
+		},
+		{
+			name:      "duplicate var only listed once",
+			condition: "$env == prod || $env == staging",
+			expected:  []string{"env"},
+		},
+		{
+			name:      "negated var",
+			condition: "!$auth_flags",
+			expected:  []string{"auth_flags"},
+		},
+		{
+			name:      "invalid syntax yields no deps",
+			condition: "$env == (",
+			expected:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := conditionVars(tt.condition)
+			if len(actual) != len(tt.expected) {
+				t.Fatalf("conditionVars(%q) = %v, want %v", tt.condition, actual, tt.expected)
+			}
+			for i, name := range tt.expected {
+				if actual[i] != name {
+					t.Errorf("conditionVars(%q)[%d] = %q, want %q", tt.condition, i, actual[i], name)
+				}
+			}
+		})
+	}
+}
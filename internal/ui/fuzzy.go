@@ -0,0 +1,458 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/gubarz/cheatmd/internal/config"
+)
+
+// ============================================================================
+// Fuzzy Matching
+// ============================================================================
+//
+// filterOptions used to AND-match whitespace-separated words as plain
+// substrings via matchesAllWords, with no scoring - matches were kept in
+// whatever order options arrived in. This gives variable selectors an
+// fzf-style extended-search query language (bare words fuzzy-match, 'word
+// forces a substring match, ^word/word$ anchor to a prefix/suffix, !word
+// negates) plus a choice of scoring Algorithm, and sorts results by score so
+// the best match floats to the top instead of the first one typed.
+
+// Algorithm selects how a bare (unprefixed) query token is matched against a
+// candidate string.
+type Algorithm string
+
+const (
+	// AlgorithmSubstring matches a bare token as a plain case-insensitive
+	// substring, same as the matcher this replaces.
+	AlgorithmSubstring Algorithm = "substring"
+	// AlgorithmExact matches and scores a bare token the same way as
+	// AlgorithmSubstring (see matchFuzzyToken).
+	AlgorithmExact Algorithm = "exact"
+	// AlgorithmFuzzyV1 matches a bare token as a subsequence (characters in
+	// order, not necessarily contiguous), scored by gap size and bonuses.
+	AlgorithmFuzzyV1 Algorithm = "fuzzy-v1"
+	// AlgorithmFuzzyV2 is a fzf-style subsequence matcher that additionally
+	// favors consecutive runs and word-boundary starts via a small DP.
+	AlgorithmFuzzyV2 Algorithm = "fuzzy-v2"
+)
+
+// normalizeAlgorithm maps an unrecognized or empty value to the default
+// algorithm (fuzzy-v2), the same way an unrecognized config.GetSelector
+// value falls back to the built-in picker.
+func normalizeAlgorithm(s string) Algorithm {
+	switch Algorithm(s) {
+	case AlgorithmSubstring, AlgorithmExact, AlgorithmFuzzyV1, AlgorithmFuzzyV2:
+		return Algorithm(s)
+	default:
+		return AlgorithmFuzzyV2
+	}
+}
+
+// resolveAlgorithm returns explicit normalized, or falls back to the
+// configured default (CHEATMD_MATCH_ALGORITHM / "match_algorithm") when
+// explicit is empty - the same override order as other per-var selector
+// options like --delimiter layer over config.
+func resolveAlgorithm(explicit Algorithm) Algorithm {
+	if explicit == "" {
+		return normalizeAlgorithm(config.GetMatchAlgorithm())
+	}
+	return normalizeAlgorithm(string(explicit))
+}
+
+// diacriticFold maps common Latin accented lowercase letters to their
+// unaccented base letter, so a query like "sodanco" matches a candidate
+// containing "Só Danço". Built by hand rather than pulled in via
+// golang.org/x/text/unicode/norm - this repo shells out or hand-rolls
+// rather than add external dependencies (see loadDotEnv in config.go).
+// Keys are always lowercase; callers fold case before looking up.
+var diacriticFold = map[rune]rune{
+	'à': 'a', 'á': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a', 'å': 'a', 'ā': 'a',
+	'è': 'e', 'é': 'e', 'ê': 'e', 'ë': 'e', 'ē': 'e', 'ė': 'e', 'ę': 'e',
+	'ì': 'i', 'í': 'i', 'î': 'i', 'ï': 'i', 'ī': 'i',
+	'ò': 'o', 'ó': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o', 'ø': 'o', 'ō': 'o',
+	'ù': 'u', 'ú': 'u', 'û': 'u', 'ü': 'u', 'ū': 'u',
+	'ý': 'y', 'ÿ': 'y',
+	'ñ': 'n', 'ń': 'n',
+	'ç': 'c', 'ć': 'c', 'č': 'c',
+	'ß': 's', 'ś': 's', 'š': 's',
+	'ž': 'z', 'ź': 'z', 'ż': 'z',
+	'ł': 'l',
+	'đ': 'd',
+}
+
+// foldDiacritics rewrites each rune in s that has an entry in diacriticFold
+// to its unaccented base letter, leaving every other rune untouched.
+func foldDiacritics(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if base, ok := diacriticFold[r]; ok {
+			r = base
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// normalizeMatchText lowercases s and, unless config.GetLiteralMatch() opts
+// out via --literal, folds diacritics so accented and unaccented spellings
+// match each other. Used to normalize both the query and candidate fields
+// before scoring, so e.g. "café" and "cafe" are equivalent by default.
+func normalizeMatchText(s string) string {
+	s = strings.ToLower(s)
+	if config.GetLiteralMatch() {
+		return s
+	}
+	return foldDiacritics(s)
+}
+
+// matchTokenKind classifies one extended-search query token.
+type matchTokenKind int
+
+const (
+	tokenFuzzy matchTokenKind = iota
+	tokenExact
+	tokenPrefix
+	tokenSuffix
+	tokenNegate
+)
+
+// queryToken is one whitespace-separated piece of an extended-search query,
+// already lowercased.
+type queryToken struct {
+	kind matchTokenKind
+	text string
+}
+
+// parseQueryTokens splits a lowercased query on whitespace into fzf-style
+// extended-search tokens: 'word forces an exact substring match, ^word a
+// prefix match, word$ a suffix match, !word negates (candidate must NOT
+// contain word), and a bare word is matched per the active Algorithm.
+func parseQueryTokens(query string) []queryToken {
+	fields := strings.Fields(query)
+	tokens := make([]queryToken, 0, len(fields))
+	for _, f := range fields {
+		switch {
+		case strings.HasPrefix(f, "!") && len(f) > 1:
+			tokens = append(tokens, queryToken{tokenNegate, f[1:]})
+		case strings.HasPrefix(f, "'") && len(f) > 1:
+			tokens = append(tokens, queryToken{tokenExact, f[1:]})
+		case strings.HasPrefix(f, "^") && len(f) > 1:
+			tokens = append(tokens, queryToken{tokenPrefix, f[1:]})
+		case strings.HasSuffix(f, "$") && len(f) > 1:
+			tokens = append(tokens, queryToken{tokenSuffix, f[:len(f)-1]})
+		default:
+			tokens = append(tokens, queryToken{tokenFuzzy, f})
+		}
+	}
+	return tokens
+}
+
+// Scoring bonuses/penalties shared by the substring and fuzzy-v1/v2 token
+// matchers below.
+const (
+	scoreMatch         = 16
+	bonusBoundary      = 8
+	bonusConsecutive   = 4
+	gapPenaltyStart    = 3
+	gapPenaltyPerExtra = 1
+)
+
+// matchAll applies every token against candidate (already lowercased) under
+// algorithm, short-circuiting on the first failing token. On success it
+// returns the summed score and the sorted, de-duplicated rune positions
+// matched by fuzzy/exact/prefix/suffix tokens, for highlight rendering.
+func matchAll(algorithm Algorithm, tokens []queryToken, candidate string) (ok bool, score int, positions []int) {
+	runes := []rune(candidate)
+	seen := make(map[int]bool)
+
+	for _, tok := range tokens {
+		switch tok.kind {
+		case tokenNegate:
+			if strings.Contains(candidate, tok.text) {
+				return false, 0, nil
+			}
+		case tokenExact:
+			idx := runeIndex(runes, tok.text)
+			if idx < 0 {
+				return false, 0, nil
+			}
+			score += scoreSubstringAt(runes, idx, len([]rune(tok.text)))
+			markRunes(seen, idx, len([]rune(tok.text)))
+		case tokenPrefix:
+			if !strings.HasPrefix(candidate, tok.text) {
+				return false, 0, nil
+			}
+			score += scoreSubstringAt(runes, 0, len([]rune(tok.text))) + bonusBoundary
+			markRunes(seen, 0, len([]rune(tok.text)))
+		case tokenSuffix:
+			if !strings.HasSuffix(candidate, tok.text) {
+				return false, 0, nil
+			}
+			start := len(runes) - len([]rune(tok.text))
+			score += scoreSubstringAt(runes, start, len([]rune(tok.text)))
+			markRunes(seen, start, len([]rune(tok.text)))
+		case tokenFuzzy:
+			matched, s, pos := matchFuzzyToken(algorithm, tok.text, runes)
+			if !matched {
+				return false, 0, nil
+			}
+			score += s
+			for _, p := range pos {
+				seen[p] = true
+			}
+		}
+	}
+
+	positions = make([]int, 0, len(seen))
+	for p := range seen {
+		positions = append(positions, p)
+	}
+	sort.Ints(positions)
+	return true, score, positions
+}
+
+// matchFuzzyToken matches a single bare query token against candidate runes
+// per algorithm.
+func matchFuzzyToken(algorithm Algorithm, pattern string, candidate []rune) (bool, int, []int) {
+	switch algorithm {
+	case AlgorithmSubstring, AlgorithmExact:
+		idx := runeIndex(candidate, pattern)
+		if idx < 0 {
+			return false, 0, nil
+		}
+		n := len([]rune(pattern))
+		return true, scoreSubstringAt(candidate, idx, n), consecutivePositions(idx, n)
+	case AlgorithmFuzzyV1:
+		return fuzzyV1(pattern, candidate)
+	default:
+		return fuzzyV2(pattern, candidate)
+	}
+}
+
+// runeIndex is strings.Index over []rune operands.
+func runeIndex(text []rune, pattern string) int {
+	p := []rune(pattern)
+	if len(p) == 0 {
+		return 0
+	}
+	for i := 0; i+len(p) <= len(text); i++ {
+		match := true
+		for j, r := range p {
+			if text[i+j] != r {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
+func consecutivePositions(start, n int) []int {
+	positions := make([]int, n)
+	for i := range positions {
+		positions[i] = start + i
+	}
+	return positions
+}
+
+func markRunes(seen map[int]bool, start, n int) {
+	for i := 0; i < n; i++ {
+		seen[start+i] = true
+	}
+}
+
+// scoreSubstringAt scores a contiguous substring match starting at idx in
+// text: the earlier and more boundary-aligned the match, the higher.
+func scoreSubstringAt(text []rune, idx, n int) int {
+	score := scoreMatch * n
+	if isBoundary(text, idx) {
+		score += bonusBoundary
+	}
+	score -= idx // earlier matches score higher
+	return score
+}
+
+// isBoundary reports whether position i in text starts a "word": it is
+// position 0, immediately follows one of /_-. or space, or is an upper-case
+// letter immediately following a lower-case one (a camelCase transition).
+func isBoundary(text []rune, i int) bool {
+	if i <= 0 {
+		return true
+	}
+	switch text[i-1] {
+	case '/', '_', '-', '.', ' ':
+		return true
+	}
+	return unicode.IsUpper(text[i]) && unicode.IsLower(text[i-1])
+}
+
+// fuzzyV1 greedily matches pattern as a subsequence of candidate, scoring by
+// per-character boundary bonuses, a consecutive-run bonus, and a gap
+// penalty that grows with the distance since the previous matched rune.
+func fuzzyV1(pattern string, candidate []rune) (bool, int, []int) {
+	p := []rune(pattern)
+	if len(p) == 0 {
+		return true, 0, nil
+	}
+
+	positions := make([]int, 0, len(p))
+	pi := 0
+	lastMatch := -1
+	score := 0
+
+	for ci := 0; ci < len(candidate) && pi < len(p); ci++ {
+		if candidate[ci] != p[pi] {
+			continue
+		}
+		score += scoreMatch
+		if isBoundary(candidate, ci) {
+			score += bonusBoundary
+		}
+		if lastMatch == ci-1 {
+			score += bonusConsecutive
+		} else if lastMatch >= 0 {
+			gap := ci - lastMatch - 1
+			score -= gapPenaltyStart + gapPenaltyPerExtra*gap
+		}
+		positions = append(positions, ci)
+		lastMatch = ci
+		pi++
+	}
+
+	if pi < len(p) {
+		return false, 0, nil
+	}
+	return true, score, positions
+}
+
+// unreachedV2 marks a fuzzyV2 DP cell that no path has reached yet.
+const unreachedV2 = -1 << 30
+
+// fuzzyV2 matches pattern as a subsequence of candidate using two DP rows
+// of length len(pattern)+1, scanned once across candidate: H[i] holds the
+// best score matching pattern[:i] using candidate up to the current
+// position, C[i] the length of the consecutive run ending there, and
+// last[i] the candidate index that run ended at (used to compute the gap
+// since the previous match). pos[i] tracks the matched indices achieving
+// H[i], for highlight rendering.
+func fuzzyV2(pattern string, candidate []rune) (bool, int, []int) {
+	p := []rune(pattern)
+	n := len(p)
+	if n == 0 {
+		return true, 0, nil
+	}
+	if len(candidate) < n {
+		return false, 0, nil
+	}
+
+	H := make([]int, n+1)
+	C := make([]int, n+1)
+	last := make([]int, n+1)
+	pos := make([][]int, n+1)
+	for i := 1; i <= n; i++ {
+		H[i] = unreachedV2
+		last[i] = -1
+	}
+
+	for j, r := range candidate {
+		for i := n; i >= 1; i-- {
+			if r != p[i-1] || H[i-1] == unreachedV2 {
+				continue
+			}
+
+			gapPenalty := 0
+			consecutive := 1
+			if last[i-1] == j-1 {
+				consecutive = C[i-1] + 1
+			} else if last[i-1] >= 0 {
+				gap := j - last[i-1] - 1
+				gapPenalty = gapPenaltyStart + gapPenaltyPerExtra*gap
+			}
+
+			candidateScore := H[i-1] + scoreMatch - gapPenalty
+			if isBoundary(candidate, j) {
+				candidateScore += bonusBoundary
+			}
+			if consecutive > 1 {
+				candidateScore += bonusConsecutive
+			}
+
+			if last[i] < 0 || candidateScore > H[i] {
+				H[i] = candidateScore
+				C[i] = consecutive
+				last[i] = j
+				newPos := make([]int, len(pos[i-1])+1)
+				copy(newPos, pos[i-1])
+				newPos[len(newPos)-1] = j
+				pos[i] = newPos
+			}
+		}
+	}
+
+	if last[n] < 0 {
+		return false, 0, nil
+	}
+	return true, H[n], pos[n]
+}
+
+// scoreAndFilterOptions filters options down to those matching query under
+// opts.Algorithm (falling back to the configured default when unset),
+// applying opts.Delimiter/Column the same way the legacy AND-word matcher
+// did, and returns them sorted by score descending with ties broken by
+// original input order (sort.SliceStable).
+func scoreAndFilterOptions(options []string, query string, opts SelectOptions) []filteredOption {
+	algorithm := resolveAlgorithm(opts.Algorithm)
+	tokens := parseQueryTokens(normalizeMatchText(query))
+
+	matches := make([]filteredOption, 0, len(options))
+	for _, opt := range options {
+		display := getDisplayColumn(opt, opts.Delimiter, opts.Column)
+
+		if ok, score, positions := matchAll(algorithm, tokens, normalizeMatchText(display)); ok {
+			matches = append(matches, filteredOption{display: display, original: opt, score: score, positions: positions})
+			continue
+		}
+		// Fall back to matching the untransformed original value, so
+		// delimiter/column extraction never hides an otherwise-matching
+		// row - positions aren't carried over since they'd index into
+		// opt, not the display text actually rendered.
+		if ok, score, _ := matchAll(algorithm, tokens, normalizeMatchText(opt)); ok {
+			matches = append(matches, filteredOption{display: display, original: opt, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	return matches
+}
+
+// highlightMatches renders text with styles.Cursor applied to the runes at
+// positions (e.g. the match positions scoreAndFilterOptions or
+// scoreCheatItem returned) and base elsewhere.
+func highlightMatches(text string, positions []int, base lipgloss.Style) string {
+	if len(positions) == 0 {
+		return base.Render(text)
+	}
+	at := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		at[p] = true
+	}
+
+	var b strings.Builder
+	for i, r := range []rune(text) {
+		if at[i] {
+			b.WriteString(styles.Cursor.Render(string(r)))
+		} else {
+			b.WriteString(base.Render(string(r)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,143 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestParseQueryTokens(t *testing.T) {
+	tokens := parseQueryTokens("^git !checkout 'log word$ bare")
+	want := []queryToken{
+		{tokenPrefix, "git"},
+		{tokenNegate, "checkout"},
+		{tokenExact, "log"},
+		{tokenSuffix, "word"},
+		{tokenFuzzy, "bare"},
+	}
+	if len(tokens) != len(want) {
+		t.Fatalf("got %d tokens, want %d: %+v", len(tokens), len(want), tokens)
+	}
+	for i, tok := range tokens {
+		if tok != want[i] {
+			t.Errorf("token[%d] = %+v, want %+v", i, tok, want[i])
+		}
+	}
+}
+
+func TestMatchAllExtendedSyntax(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		candidate string
+		wantMatch bool
+	}{
+		{"prefix matches", "^git", "git commit", true},
+		{"prefix rejects mid-string", "^commit", "git commit", false},
+		{"suffix matches", "commit$", "git commit", true},
+		{"suffix rejects non-ending", "git$", "git commit", false},
+		{"negation excludes", "!checkout", "git checkout", false},
+		{"negation allows absence", "!checkout", "git commit", true},
+		{"exact requires literal substring", "'log", "git log --oneline", true},
+		{"exact rejects subsequence-only", "'gco", "git checkout", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := parseQueryTokens(tt.query)
+			ok, _, _ := matchAll(AlgorithmFuzzyV2, tokens, tt.candidate)
+			if ok != tt.wantMatch {
+				t.Errorf("matchAll(%q, %q) = %v, want %v", tt.query, tt.candidate, ok, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestFuzzyAlgorithmsAcceptSubsequence(t *testing.T) {
+	for _, algo := range []Algorithm{AlgorithmFuzzyV1, AlgorithmFuzzyV2} {
+		tokens := parseQueryTokens("gco")
+		ok, score, positions := matchAll(algo, tokens, "git checkout")
+		if !ok {
+			t.Errorf("%s: expected subsequence match for %q", algo, "git checkout")
+			continue
+		}
+		if score <= 0 {
+			t.Errorf("%s: expected positive score, got %d", algo, score)
+		}
+		if len(positions) != 3 {
+			t.Errorf("%s: expected 3 matched positions, got %v", algo, positions)
+		}
+	}
+}
+
+func TestSubstringAndExactRejectSubsequenceOnlyMatches(t *testing.T) {
+	for _, algo := range []Algorithm{AlgorithmSubstring, AlgorithmExact} {
+		tokens := parseQueryTokens("gco")
+		if ok, _, _ := matchAll(algo, tokens, "git checkout"); ok {
+			t.Errorf("%s: expected no match for non-contiguous pattern %q", algo, "gco")
+		}
+	}
+}
+
+func TestScoreAndFilterOptionsSortsByScore(t *testing.T) {
+	options := []string{"git checkout", "git commit", "git co"}
+	results := scoreAndFilterOptions(options, "gco", SelectOptions{Algorithm: AlgorithmFuzzyV2})
+
+	if len(results) == 0 {
+		t.Fatal("expected at least one match")
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i-1].score < results[i].score {
+			t.Errorf("results not sorted by score descending: %+v", results)
+		}
+	}
+
+	last := results[len(results)-1]
+	if last.display != "git checkout" {
+		t.Errorf("expected the least compact subsequence match last, got %+v", results)
+	}
+}
+
+func TestNormalizeMatchTextFoldsDiacritics(t *testing.T) {
+	got := normalizeMatchText("Só Danço Samba")
+	want := "so danco samba"
+	if got != want {
+		t.Errorf("normalizeMatchText(%q) = %q, want %q", "Só Danço Samba", got, want)
+	}
+}
+
+func TestNormalizeAlgorithmFallsBackToFuzzyV2(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Algorithm
+	}{
+		{"", AlgorithmFuzzyV2},
+		{"bogus", AlgorithmFuzzyV2},
+		{"substring", AlgorithmSubstring},
+		{"exact", AlgorithmExact},
+		{"fuzzy-v1", AlgorithmFuzzyV1},
+	}
+	for _, tt := range tests {
+		if got := normalizeAlgorithm(tt.in); got != tt.want {
+			t.Errorf("normalizeAlgorithm(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHighlightMatchesCoversEveryRune(t *testing.T) {
+	// Styling may wrap each rune in ANSI codes depending on the detected
+	// color profile, so just check every source rune still appears in
+	// order rather than asserting exact equality.
+	out := highlightMatches("abc", []int{0, 2}, lipgloss.NewStyle())
+	for _, r := range []rune("abc") {
+		if !strings.ContainsRune(out, r) {
+			t.Errorf("highlightMatches output %q missing rune %q", out, r)
+		}
+	}
+}
+
+func TestHighlightMatchesNoPositions(t *testing.T) {
+	if got := highlightMatches("", nil, lipgloss.NewStyle()); got != "" {
+		t.Errorf("highlightMatches(\"\", nil) = %q, want empty", got)
+	}
+}
@@ -0,0 +1,126 @@
+package ui
+
+import "strings"
+
+// ============================================================================
+// Preview Pane Syntax Highlighting
+// ============================================================================
+
+// highlightSource renders source with syntax highlighting for language,
+// falling back to the plain, unhighlighted text for any language we don't
+// recognize.
+//
+// This is a small hand-rolled tokenizer rather than a dependency on
+// github.com/alecthomas/chroma (see executor's disableEcho and config's
+// loadDotEnv for the same tradeoff). It
+// covers enough of shell syntax - comments, quoted strings, $vars, and
+// flags - to make the preview pane's command snippet easy to scan.
+func highlightSource(source, language string) string {
+	switch language {
+	case "", "bash", "sh", "shell", "zsh":
+		return highlightShell(source)
+	default:
+		return source
+	}
+}
+
+// highlightShell colors comments, quoted strings, $var/${var} references, and
+// -flags/--flags, line by line so a "#" only starts a comment for the rest of
+// its own line.
+func highlightShell(source string) string {
+	lines := strings.Split(source, "\n")
+	for i, line := range lines {
+		lines[i] = highlightShellLine(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func highlightShellLine(line string) string {
+	var b strings.Builder
+	atWordStart := true
+
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == '#':
+			b.WriteString(styles.Dim.Render(line[i:]))
+			return b.String()
+		case c == '\'' || c == '"':
+			end := shellQuoteEnd(line, i, c)
+			b.WriteString(styles.PreviewPath.Render(line[i:end]))
+			i = end
+			atWordStart = false
+		case c == '$':
+			end := shellVarEnd(line, i)
+			b.WriteString(styles.PreviewHeader.Render(line[i:end]))
+			i = end
+			atWordStart = false
+		case c == '-' && atWordStart:
+			end := shellWordEnd(line, i)
+			b.WriteString(styles.PreviewDesc.Render(line[i:end]))
+			i = end
+			atWordStart = false
+		case c == ' ' || c == '\t':
+			b.WriteByte(c)
+			i++
+			atWordStart = true
+		default:
+			end := shellWordEnd(line, i)
+			b.WriteString(line[i:end])
+			i = end
+			atWordStart = false
+		}
+	}
+
+	return b.String()
+}
+
+// shellQuoteEnd returns the index just past the quote matching quoteChar at
+// start (inclusive of both delimiters), or the end of line if unterminated.
+func shellQuoteEnd(line string, start int, quoteChar byte) int {
+	for i := start + 1; i < len(line); i++ {
+		if line[i] == quoteChar {
+			return i + 1
+		}
+	}
+	return len(line)
+}
+
+// shellVarEnd returns the index just past a "$name" or "${name}" reference
+// starting at start (the '$').
+func shellVarEnd(line string, start int) int {
+	if start+1 < len(line) && line[start+1] == '{' {
+		for i := start + 2; i < len(line); i++ {
+			if line[i] == '}' {
+				return i + 1
+			}
+		}
+		return len(line)
+	}
+
+	i := start + 1
+	for i < len(line) && isShellWordChar(line[i]) {
+		i++
+	}
+	if i == start+1 {
+		return start + 1 // bare "$" with nothing following
+	}
+	return i
+}
+
+// shellWordEnd returns the index of the next whitespace run (or end of line)
+// starting at start.
+func shellWordEnd(line string, start int) int {
+	i := start
+	for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+		i++
+	}
+	return i
+}
+
+func isShellWordChar(c byte) bool {
+	return c == '_' ||
+		(c >= 'a' && c <= 'z') ||
+		(c >= 'A' && c <= 'Z') ||
+		(c >= '0' && c <= '9')
+}
@@ -2,22 +2,30 @@ package ui
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/fsnotify/fsnotify"
 
 	"github.com/gubarz/cheatmd/internal/config"
 	"github.com/gubarz/cheatmd/internal/executor"
+	"github.com/gubarz/cheatmd/internal/history"
 	"github.com/gubarz/cheatmd/internal/parser"
+	"github.com/gubarz/cheatmd/internal/parser/patterns"
+	"github.com/gubarz/cheatmd/internal/parser/template"
 )
 
 // ============================================================================
@@ -46,11 +54,18 @@ func putBuilder(b *strings.Builder) {
 // Cheat Item
 // ============================================================================
 
-// cheatItem wraps a Cheat with display metadata
+// cheatItem wraps a Cheat with display metadata. score and headerPositions
+// are set by scoreCheatItem each time filterCheats runs and are only
+// meaningful for entries currently in mainModel.filtered: score ranks the
+// result (higher is a better match), headerPositions are the matched rune
+// indices into cheat.Header for renderListItem to highlight with
+// styles.Cursor.
 type cheatItem struct {
-	cheat  *parser.Cheat
-	folder string
-	file   string
+	cheat           *parser.Cheat
+	folder          string
+	file            string
+	score           int
+	headerPositions []int
 }
 
 // newCheatItem creates a cheatItem from a Cheat
@@ -65,47 +80,56 @@ func newCheatItem(cheat *parser.Cheat) cheatItem {
 	}
 }
 
-// matchesQuery checks if the cheat item matches all search words
-// Uses case-insensitive substring matching on original strings
-func (item *cheatItem) matchesQuery(words []string) bool {
-	for _, word := range words {
-		if !item.containsWord(word) {
-			return false
-		}
-	}
-	return true
-}
+// cheatItemFields returns item's searchable fields in the same
+// smaller-first priority order the old containsWord check used, so when a
+// folder/file/header hit and a body-text hit score the same, the earlier
+// field wins. Index cheatHeaderField identifies which one is cheat.Header, for
+// scoreCheatItem to know which positions to keep for highlighting.
+const cheatHeaderField = 2
 
-// containsWord checks if any field contains the word (case-insensitive)
-func (item *cheatItem) containsWord(word string) bool {
-	// Check smaller fields first for fast rejection
-	if containsIgnoreCase(item.folder, word) {
-		return true
-	}
-	if containsIgnoreCase(item.file, word) {
-		return true
-	}
-	if containsIgnoreCase(item.cheat.Header, word) {
-		return true
-	}
-	// Check larger fields only if needed
-	if containsIgnoreCase(item.cheat.Description, word) {
-		return true
-	}
-	if containsIgnoreCase(item.cheat.Command, word) {
-		return true
-	}
-	return false
+func cheatItemFields(item *cheatItem) [5]string {
+	return [5]string{item.folder, item.file, item.cheat.Header, item.cheat.Description, item.cheat.Command}
 }
 
-// containsIgnoreCase is a fast case-insensitive substring check
-func containsIgnoreCase(s, substr string) bool {
-	if len(substr) > len(s) {
-		return false
+// scoreCheatItem matches tokens (parsed by parseQueryTokens) against item
+// under algorithm using the fzf-style scorer in fuzzy.go, preserving the old
+// "every token must match somewhere, any field" semantics: each token is
+// matched against every field independently and the best-scoring field
+// wins, but a token with no match in any field disqualifies the whole item.
+// Scores are summed across tokens. Returns the matched rune positions within
+// cheat.Header specifically, since that's the only field renderListItem
+// highlights.
+func scoreCheatItem(tokens []queryToken, algorithm Algorithm, item *cheatItem) (ok bool, score int, headerPositions []int) {
+	fields := cheatItemFields(item)
+
+	for _, tok := range tokens {
+		single := []queryToken{tok}
+		best := -1
+		var bestScore int
+		var bestPositions []int
+
+		for i, field := range fields {
+			if field == "" {
+				continue
+			}
+			if fieldOK, s, pos := matchAll(algorithm, single, normalizeMatchText(field)); fieldOK {
+				if best == -1 || s > bestScore {
+					best, bestScore, bestPositions = i, s, pos
+				}
+			}
+		}
+
+		if best == -1 {
+			return false, 0, nil
+		}
+		score += bestScore
+		if best == cheatHeaderField {
+			headerPositions = append(headerPositions, bestPositions...)
+		}
 	}
-	// Use strings.Contains with pre-lowercased substr (caller should cache this)
-	// For ASCII, we can do a fast manual check
-	return strings.Contains(strings.ToLower(s), substr)
+
+	sort.Ints(headerPositions)
+	return true, score, headerPositions
 }
 
 // ============================================================================
@@ -144,6 +168,51 @@ func debounceFilter() tea.Cmd {
 	})
 }
 
+// ============================================================================
+// Streaming Ingest
+// ============================================================================
+
+// cheatsBatchSize caps how many cheats waitForCheats drains from streamCh per
+// message, so a huge repo doesn't flood the Bubble Tea message queue with one
+// message per file.
+const cheatsBatchSize = 200
+
+// cheatsAppendedMsg carries a batch of newly-parsed cheats from streamCh, plus
+// whether the channel is now exhausted.
+type cheatsAppendedMsg struct {
+	cheats []*parser.Cheat
+	done   bool
+}
+
+// waitForCheats blocks for the next cheat on ch, then drains up to
+// cheatsBatchSize more without blocking, so a fast producer is batched into a
+// single message instead of one per cheat. Returns done=true once ch is
+// closed, so the caller stops re-arming this command.
+func waitForCheats(ch <-chan *parser.Cheat) tea.Cmd {
+	return func() tea.Msg {
+		first, ok := <-ch
+		if !ok {
+			return cheatsAppendedMsg{done: true}
+		}
+
+		batch := make([]*parser.Cheat, 0, cheatsBatchSize)
+		batch = append(batch, first)
+	drain:
+		for len(batch) < cheatsBatchSize {
+			select {
+			case c, ok := <-ch:
+				if !ok {
+					return cheatsAppendedMsg{cheats: batch, done: true}
+				}
+				batch = append(batch, c)
+			default:
+				break drain
+			}
+		}
+		return cheatsAppendedMsg{cheats: batch}
+	}
+}
+
 // ============================================================================
 // Main Model - Unified TUI (Cheat Selection + Variable Resolution)
 // ============================================================================
@@ -177,12 +246,69 @@ type mainModel struct {
 	columns   columnConfig
 	lastQuery string
 
+	// Streaming ingest (only set by newMainModelStreaming): streamCh is the
+	// channel still being drained (nil once exhausted), cheatsSnapshot is
+	// the atomic published copy of cheats that filterCheatsParallel reads
+	// from so it never races a concurrent append.
+	streamCh       <-chan *parser.Cheat
+	cheatsSnapshot *atomic.Pointer[[]cheatItem]
+
 	// Variable resolution state (only used in phaseVarResolve)
 	varState *varResolveState
 
 	// Dependencies for variable resolution
 	cheatIndex *parser.CheatIndex
 	executor   *executor.Executor
+
+	// Cheat-select preview pane (ctrl+/ toggle, shift+up/down scroll): shows
+	// config.GetPreviewCmd()'s output for the cursored cheat, debounced like
+	// --preview in the variable flow, or falls back to the cheat's full
+	// (untruncated) description and command when no preview command is set.
+	showCheatPreview   bool
+	cheatPreviewScroll int
+	cheatPreviewCache  map[string]string // cheatPreviewCacheKey(item) -> PreviewCmd output
+	cheatPreviewGen    int
+
+	// Inline-height mode (config.GetHeight(), fzf --height style): 0 means
+	// run fullscreen in the alt-screen buffer and let WindowSizeMsg drive
+	// m.height as usual; a positive value fixes m.height at that row budget
+	// instead, so the TUI renders below the cursor in the scrollback rather
+	// than taking over the whole terminal. reverseLayout (config.GetReverse())
+	// puts the input above the list instead of below it; it only applies
+	// while inlineHeight is set, matching fzf's own --reverse behavior.
+	inlineHeight  int
+	reverseLayout bool
+
+	// --multi[=N] batch selection (config.GetMulti()): Tab/Shift-Tab marks
+	// several cheats in phaseCheatSelect instead of picking one. multiOrder
+	// is the marked set in toggle order; multiActive, multiQueue, and
+	// multiDone drive chaining phaseVarResolve across every marked cheat
+	// (see startVarResolution/finishCheatResolution) before RunTUI joins
+	// each one's BuildFinalCommand via BuildFinalCommandBatch.
+	multiSelected map[*parser.Cheat]bool
+	multiOrder    []*parser.Cheat
+	multiActive   bool
+	multiQueue    []*parser.Cheat
+	multiDone     []*parser.Cheat
+
+	// outputOverride ("", "print", "copy", or "exec") is ctrl+t's
+	// (cycle-output action) per-invocation override of config.GetOutput(),
+	// applied by RunTUI just before executeOutput runs the selected cheat.
+	outputOverride string
+
+	// Hot reload (the "reload" action, default ctrl+r, and --watch's
+	// background fsnotify trigger - see RunTUI, doReload, handleReloadDone):
+	// reloadFn re-parses the cheat root from scratch, reloadNotice is a
+	// transient status line rendered by renderInput, and reloadNoticeGen
+	// guards its expiry timer against a newer reload superseding it.
+	reloadFn        func() (*parser.CheatIndex, error)
+	reloadNotice    string
+	reloadNoticeGen int
+
+	// hist is the usage history (config.GetHistoryEnabled) used to float
+	// frecent cheats to the top of an empty-query listing and break ties in
+	// filterCheatsParallel's fuzzy scoring; nil when history is disabled.
+	hist *history.History
 }
 
 // varResolveState holds state for resolving variables within the unified TUI
@@ -196,6 +322,22 @@ type varResolveState struct {
 	customHeader string
 	shellErr     error // error from running shell command (if any)
 	isPromptOnly bool  // true if no options, just text input
+
+	// validationErr is validateVarValue's rejection reason for the value
+	// acceptVarValue last tried to accept (typed def.Validate mismatch, an
+	// out-of-range int/float, a path/file/dir that doesn't exist, ...);
+	// renderVarBottom shows it under the input and acceptVarValue refuses to
+	// advance while it's set. Cleared whenever prepareCurrentVar moves on.
+	validationErr string
+
+	picked map[string]bool // selectOpts.Multi: original -> toggled on
+	order  []string        // selectOpts.Multi: picks in toggle order
+
+	// selectOpts.PreviewCmd: ctrl+/ toggle, debounced like schedulePreview
+	showPreview   bool
+	previewScroll int
+	previewCache  map[string]string // varPreviewCacheKey(candidate) -> PreviewCmd output
+	previewGen    int
 }
 
 // filteredVarOption pairs display text with original value for variable selection
@@ -218,24 +360,49 @@ func newMainModel(cheats []*parser.Cheat, index *parser.CheatIndex, exec *execut
 		items[i] = newCheatItem(cheat)
 	}
 
+	snapshot := &atomic.Pointer[[]cheatItem]{}
+	snapshot.Store(&items)
+
 	return mainModel{
-		cheats:     items,
-		filtered:   items,
-		textInput:  ti,
-		columns:    loadColumnConfig(),
-		phase:      phaseCheatSelect,
-		cheatIndex: index,
-		executor:   exec,
+		cheats:            items,
+		filtered:          items,
+		textInput:         ti,
+		columns:           loadColumnConfig(),
+		phase:             phaseCheatSelect,
+		cheatIndex:        index,
+		executor:          exec,
+		cheatsSnapshot:    snapshot,
+		showCheatPreview:  true,
+		cheatPreviewCache: make(map[string]string),
 	}
 }
 
+// newMainModelStreaming creates a mainModel that ingests cheats from ch as
+// they arrive instead of requiring the full, already-parsed slice
+// newMainModel takes: the caller parses in a background goroutine and sends
+// each *parser.Cheat (closing ch when done) while the TUI is already
+// interactive, fzf reader-goroutine style. This keeps startup on a
+// multi-thousand-file cheat repo snappy instead of blocking on a full parse.
+func newMainModelStreaming(ch <-chan *parser.Cheat, index *parser.CheatIndex, exec *executor.Executor) mainModel {
+	m := newMainModel(nil, index, exec)
+	m.streamCh = ch
+	return m
+}
+
 // Init implements tea.Model
 func (m mainModel) Init() tea.Cmd {
+	var cmds []tea.Cmd
+	cmds = append(cmds, textinput.Blink)
+
 	// If we're already in variable resolution phase (from --match), prepare the first variable
 	if m.phase == phaseVarResolve && m.varState != nil {
-		return tea.Batch(textinput.Blink, m.prepareCurrentVar())
+		cmds = append(cmds, m.prepareCurrentVar())
+	}
+	if m.streamCh != nil {
+		cmds = append(cmds, waitForCheats(m.streamCh))
 	}
-	return textinput.Blink
+
+	return tea.Batch(cmds...)
 }
 
 // Update implements tea.Model
@@ -243,10 +410,18 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// Handle window size for both phases
 	if wsMsg, ok := msg.(tea.WindowSizeMsg); ok {
 		m.width = wsMsg.Width
-		m.height = wsMsg.Height
+		if m.inlineHeight > 0 {
+			m.height = m.inlineHeight
+		} else {
+			m.height = wsMsg.Height
+		}
 		m.textInput.Width = wsMsg.Width - 4
 	}
 
+	if caMsg, ok := msg.(cheatsAppendedMsg); ok {
+		return m.handleCheatsAppended(caMsg)
+	}
+
 	// Dispatch based on phase
 	switch m.phase {
 	case phaseVarResolve:
@@ -256,6 +431,126 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 }
 
+// handleCheatsAppended merges a streamed batch into m.cheats, republishes
+// cheatsSnapshot so any in-flight filterCheatsParallel call never sees a
+// torn slice, and re-filters if the user is still on the select screen (so
+// newly-arrived matches appear without waiting for the next keystroke).
+// Re-arms waitForCheats unless the stream is exhausted.
+func (m mainModel) handleCheatsAppended(msg cheatsAppendedMsg) (tea.Model, tea.Cmd) {
+	if len(msg.cheats) > 0 {
+		for _, c := range msg.cheats {
+			m.cheats = append(m.cheats, newCheatItem(c))
+		}
+		snapshot := m.cheats
+		m.cheatsSnapshot.Store(&snapshot)
+		if m.phase == phaseCheatSelect {
+			m.filterCheats()
+		}
+	}
+
+	if msg.done {
+		m.streamCh = nil
+		return m, nil
+	}
+	return m, waitForCheats(m.streamCh)
+}
+
+// ============================================================================
+// Hot Reload
+// ============================================================================
+
+// reloadMsg requests a fresh parse of the cheat root via m.reloadFn, fired
+// by the "reload" action (default ctrl+r) or the background --watch
+// fsnotify trigger.
+type reloadMsg struct{}
+
+// reloadDoneMsg carries the result of a re-parse started by doReload.
+type reloadDoneMsg struct {
+	index *parser.CheatIndex
+	err   error
+}
+
+// reloadNoticeExpireMsg clears m.reloadNotice once its display timer
+// elapses, unless a newer reload has since replaced it (see gen).
+type reloadNoticeExpireMsg struct{ gen int }
+
+// doReload runs m.reloadFn in a tea.Cmd so a (possibly slow) re-parse never
+// blocks the Update loop. A nil reloadFn (only possible if RunTUI was
+// called without one) makes this a no-op.
+func (m *mainModel) doReload() tea.Cmd {
+	if m.reloadFn == nil {
+		return nil
+	}
+	reloadFn := m.reloadFn
+	return func() tea.Msg {
+		index, err := reloadFn()
+		return reloadDoneMsg{index: index, err: err}
+	}
+}
+
+// scheduleReloadNoticeExpiry shows a transient message in renderInput for a
+// couple seconds, bumping reloadNoticeGen so an earlier timer can't clear a
+// message set after it fired.
+func (m *mainModel) scheduleReloadNoticeExpiry() tea.Cmd {
+	m.reloadNoticeGen++
+	gen := m.reloadNoticeGen
+	return tea.Tick(2*time.Second, func(time.Time) tea.Msg {
+		return reloadNoticeExpireMsg{gen: gen}
+	})
+}
+
+// cheatIdentity is the folder/file/header triple handleReloadDone matches a
+// cheat across a reload by: re-parsing allocates an entirely new slice of
+// *parser.Cheat, so the previous cursor's pointer can no longer be compared
+// directly against the fresh one.
+func cheatIdentity(c *parser.Cheat) [3]string {
+	return [3]string{filepath.Dir(c.File), filepath.Base(c.File), c.Header}
+}
+
+// handleReloadDone applies a finished reload: on success, it rebuilds
+// m.cheats from the fresh index, re-filters by the current query, and
+// restores the cursor to whichever cheat was selected before (matched via
+// cheatIdentity, since the old *parser.Cheat pointers no longer exist).
+// A failed reload (e.g. a syntax error mid-edit) is surfaced as a toast and
+// otherwise ignored, leaving the previous index in place.
+func (m mainModel) handleReloadDone(msg reloadDoneMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.reloadNotice = fmt.Sprintf("reload failed: %v", msg.err)
+		return m, m.scheduleReloadNoticeExpiry()
+	}
+
+	var prevKey [3]string
+	hadSelection := m.cursor < len(m.filtered)
+	if hadSelection {
+		prevKey = cheatIdentity(m.filtered[m.cursor].cheat)
+	}
+
+	m.cheatIndex = msg.index
+	cheats := filterCheatsByConfig(msg.index.Cheats, config.GetRequireCheatBlock())
+	items := make([]cheatItem, len(cheats))
+	for i, c := range cheats {
+		items[i] = newCheatItem(c)
+	}
+	m.cheats = items
+	snapshot := items
+	m.cheatsSnapshot.Store(&snapshot)
+	m.cheatPreviewCache = make(map[string]string)
+	m.filterCheats()
+
+	m.cursor = 0
+	if hadSelection {
+		for i, item := range m.filtered {
+			if cheatIdentity(item.cheat) == prevKey {
+				m.cursor = i
+				break
+			}
+		}
+	}
+
+	m.reloadNotice = fmt.Sprintf("reloaded %d cheats", len(m.cheats))
+	return m, m.scheduleReloadNoticeExpiry()
+}
+
 // updateCheatSelect handles updates during cheat selection phase
 func (m mainModel) updateCheatSelect(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
@@ -268,6 +563,21 @@ func (m mainModel) updateCheatSelect(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case filterMsg:
 		m.filterCheats()
 		return m, nil
+	case previewTickMsg:
+		if msg.gen == m.cheatPreviewGen && m.cursor < len(m.filtered) {
+			item := m.filtered[m.cursor]
+			m.cheatPreviewCache[cheatPreviewCacheKey(item)] = runCheatPreviewCmd(config.GetPreviewCmd(), item)
+		}
+		return m, nil
+	case reloadMsg:
+		return m, m.doReload()
+	case reloadDoneMsg:
+		return m.handleReloadDone(msg)
+	case reloadNoticeExpireMsg:
+		if msg.gen == m.reloadNoticeGen {
+			m.reloadNotice = ""
+		}
+		return m, nil
 	}
 
 	prevQuery := m.textInput.Value()
@@ -279,41 +589,76 @@ func (m mainModel) updateCheatSelect(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if m.textInput.Value() != prevQuery {
 		cmds = append(cmds, debounceFilter())
 	}
+	cmds = append(cmds, m.scheduleCheatPreview())
 
 	return m, tea.Batch(cmds...)
 }
 
+// scheduleCheatPreview (re)starts the debounce timer for the cursored
+// cheat's external preview command, config.GetPreviewCmd(), the same way
+// scheduleVarPreview debounces a variable's --preview. Runs regardless of
+// m.showCheatPreview, since renderPreview's always-visible panel consumes
+// the same cache as the ctrl+/ toggle pane. No-op when no command is
+// configured or the cursored cheat's output is already cached.
+func (m *mainModel) scheduleCheatPreview() tea.Cmd {
+	previewCmd := config.GetPreviewCmd()
+	if previewCmd == "" || m.cursor >= len(m.filtered) {
+		return nil
+	}
+	candidate := cheatPreviewCacheKey(m.filtered[m.cursor])
+	if _, cached := m.cheatPreviewCache[candidate]; cached {
+		return nil
+	}
+
+	m.cheatPreviewGen++
+	gen := m.cheatPreviewGen
+	return tea.Tick(previewDebounce, func(time.Time) tea.Msg {
+		return previewTickMsg{gen: gen, candidate: candidate}
+	})
+}
+
+// cheatPreviewCacheKey identifies a cheat for cheatPreviewCache by its
+// folder/file/header triple rather than cheat.File alone, since two cheats
+// in the same file (different headers) would otherwise collide on one
+// cached preview.
+func cheatPreviewCacheKey(item cheatItem) string {
+	return item.folder + "\x00" + item.file + "\x00" + item.cheat.Header
+}
+
+// expandCheatPreviewCmd substitutes preview_command's fzf-style "{}" (the
+// cheat's file path, kept for back-compat with the plain candidate-string
+// convention runPreviewCmd uses for variable previews) and the richer
+// "{folder}", "{file}", "{header}", "{cmd}" placeholders, each shell-quoted.
+func expandCheatPreviewCmd(previewCmd string, item cheatItem) string {
+	replacer := strings.NewReplacer(
+		"{}", shellQuote(item.cheat.File),
+		"{folder}", shellQuote(item.folder),
+		"{file}", shellQuote(item.file),
+		"{header}", shellQuote(item.cheat.Header),
+		"{cmd}", shellQuote(item.cheat.Command),
+	)
+	return replacer.Replace(previewCmd)
+}
+
+// runCheatPreviewCmd runs preview_command for the cursored cheat:
+// expandCheatPreviewCmd fills in its argv placeholders, and the cheat's
+// command is also piped on stdin so a command like "bat" that only reads
+// stdin still works.
+func runCheatPreviewCmd(previewCmd string, item cheatItem) string {
+	rendered := expandCheatPreviewCmd(previewCmd, item)
+	cmd := exec.Command(config.GetShell(), "-c", rendered)
+	cmd.Stdin = strings.NewReader(item.cheat.Command)
+	out, _ := cmd.CombinedOutput()
+	if len(out) > previewOutputCap {
+		out = out[:previewOutputCap]
+	}
+	return strings.TrimRight(string(out), "\n")
+}
+
 // handleCheatSelectKey processes keyboard input during cheat selection
 func (m *mainModel) handleCheatSelectKey(msg tea.KeyMsg) tea.Cmd {
-	switch msg.String() {
-	case "ctrl+c":
-		m.quitting = true
-		return tea.Quit
-	case "esc":
-		m.quitting = true
-		return tea.Quit
-	case "enter":
-		if m.cursor < len(m.filtered) {
-			m.selected = m.filtered[m.cursor].cheat
-			// Transition to variable resolution phase
-			return m.startVarResolution()
-		}
-	case "up", "ctrl+p":
-		m.moveCursor(-1)
-	case "down", "ctrl+n":
-		m.moveCursor(1)
-	case "pgup":
-		m.moveCursor(-10)
-	case "pgdown":
-		m.moveCursor(10)
-	case "home", "ctrl+a":
-		m.cursor = 0
-	case "end", "ctrl+e":
-		m.cursor = max(0, len(m.filtered)-1)
-	case "ctrl+o":
-		if m.cursor < len(m.filtered) {
-			openFileInViewer(m.filtered[m.cursor].cheat.File)
-		}
+	if action, ok := resolveActionChord(phaseCheatSelect, msg.String()); ok {
+		return dispatchAction(m, phaseCheatSelect, action)
 	}
 	return nil
 }
@@ -322,6 +667,7 @@ func (m *mainModel) handleCheatSelectKey(msg tea.KeyMsg) tea.Cmd {
 func (m *mainModel) moveCursor(delta int) {
 	m.cursor += delta
 	m.cursor = clamp(m.cursor, 0, max(0, len(m.filtered)-1))
+	m.cheatPreviewScroll = 0
 	m.adjustOffset()
 }
 
@@ -345,30 +691,141 @@ func (m *mainModel) adjustOffset() {
 	m.offset = clamp(m.offset, 0, maxOffset)
 }
 
-// filterCheats filters the cheat list based on the search query
+// filterCheatsMaxResults caps how many matches filterCheats keeps, to
+// prevent UI lag rendering a huge result set.
+const filterCheatsMaxResults = 1000
+
+// filterCheatsWorkers is how many goroutines filterCheatsParallel splits the
+// cheat list across.
+var filterCheatsWorkers = runtime.NumCPU()
+
+// filterCheats filters the cheat list based on the search query, scoring
+// and sorting matches best-first instead of leaving them in source order.
+// It reads from cheatsSnapshot rather than m.cheats directly, so it never
+// races a concurrent handleCheatsAppended append while streaming ingest is
+// still draining (m.cheats is read directly only when cheatsSnapshot is nil
+// or holds no slice yet).
 func (m *mainModel) filterCheats() {
-	query := strings.TrimSpace(m.textInput.Value())
+	cheats := m.cheats
+	if m.cheatsSnapshot != nil {
+		if snapshot := m.cheatsSnapshot.Load(); snapshot != nil {
+			cheats = *snapshot
+		}
+	}
 
+	query := strings.TrimSpace(m.textInput.Value())
 	if query == "" {
-		m.filtered = m.cheats
+		m.filtered = sortByFrecency(cheats, m.hist)
 	} else {
-		words := strings.Fields(strings.ToLower(query))
-		m.filtered = make([]cheatItem, 0, min(len(m.cheats), 1000))
-		for i := range m.cheats {
-			if m.cheats[i].matchesQuery(words) {
-				m.filtered = append(m.filtered, m.cheats[i])
-				// Limit results to prevent UI lag
-				if len(m.filtered) >= 1000 {
-					break
-				}
-			}
-		}
+		algorithm := resolveAlgorithm("")
+		tokens := parseQueryTokens(normalizeMatchText(query))
+		m.filtered = filterCheatsParallel(cheats, tokens, algorithm, m.hist)
 	}
 
 	m.cursor = clamp(m.cursor, 0, max(0, len(m.filtered)-1))
 	m.adjustOffset()
 }
 
+// historyKey identifies item for hist.RecordSelection/FrecencyScore.
+func historyKey(item *cheatItem) string {
+	return history.Key(item.cheat.Header, item.cheat.Command)
+}
+
+// sortByFrecency reorders cheats - used for the empty-query/browsing case,
+// where filterCheatsParallel's fuzzy scoring doesn't apply - so
+// recently/frequently selected cheats float to the top, fzf --tiebreak
+// style but driven by hist's real usage data instead of input order. A nil
+// hist (config.GetHistoryEnabled is false) is a no-op.
+func sortByFrecency(cheats []cheatItem, hist *history.History) []cheatItem {
+	if hist == nil {
+		return cheats
+	}
+	now := time.Now()
+	sorted := append([]cheatItem(nil), cheats...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return hist.FrecencyScore(historyKey(&sorted[i]), now) > hist.FrecencyScore(historyKey(&sorted[j]), now)
+	})
+	return sorted
+}
+
+// frecencyTiebreakWeight scales history.History.FrecencyScore into
+// cheatItem.score's units: small enough that a real fuzzy-match quality
+// difference always wins, large enough to break a near-tie in the
+// frecent cheat's favor.
+const frecencyTiebreakWeight = 2
+
+// filterCheatsParallel scores cheats against tokens across
+// filterCheatsWorkers goroutines - the CPU-bound part of filtering a
+// multi-thousand-entry cheat list - then merges each worker's matches and
+// sorts the combined result by score descending (ties keep their original,
+// stable order), capped at filterCheatsMaxResults. hist, if non-nil, adds a
+// small frecency-based tiebreak to each match's score (see
+// frecencyTiebreakWeight).
+func filterCheatsParallel(cheats []cheatItem, tokens []queryToken, algorithm Algorithm, hist *history.History) []cheatItem {
+	workers := filterCheatsWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(cheats) {
+		workers = max(1, len(cheats))
+	}
+	if workers <= 1 {
+		return filterCheatsChunk(cheats, tokens, algorithm, hist)
+	}
+
+	chunkResults := make([][]cheatItem, workers)
+	chunkSize := (len(cheats) + workers - 1) / workers
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		start := w * chunkSize
+		end := min(start+chunkSize, len(cheats))
+		if start >= end {
+			continue
+		}
+		wg.Add(1)
+		go func(w, start, end int) {
+			defer wg.Done()
+			chunkResults[w] = filterCheatsChunk(cheats[start:end], tokens, algorithm, hist)
+		}(w, start, end)
+	}
+	wg.Wait()
+
+	filtered := make([]cheatItem, 0, min(len(cheats), filterCheatsMaxResults))
+	for _, chunk := range chunkResults {
+		filtered = append(filtered, chunk...)
+	}
+	sort.SliceStable(filtered, func(i, j int) bool { return filtered[i].score > filtered[j].score })
+	if len(filtered) > filterCheatsMaxResults {
+		filtered = filtered[:filterCheatsMaxResults]
+	}
+	return filtered
+}
+
+// filterCheatsChunk runs the single-threaded score loop over one slice,
+// capped at filterCheatsMaxResults per chunk (filterCheatsParallel
+// re-sorts and caps the merged result, but capping here still bounds how
+// much a single pathological chunk can allocate).
+func filterCheatsChunk(cheats []cheatItem, tokens []queryToken, algorithm Algorithm, hist *history.History) []cheatItem {
+	now := time.Now()
+	filtered := make([]cheatItem, 0, min(len(cheats), filterCheatsMaxResults))
+	for i := range cheats {
+		if ok, score, positions := scoreCheatItem(tokens, algorithm, &cheats[i]); ok {
+			item := cheats[i]
+			item.score = score
+			if hist != nil {
+				item.score += int(hist.FrecencyScore(historyKey(&cheats[i]), now) * frecencyTiebreakWeight)
+			}
+			item.headerPositions = positions
+			filtered = append(filtered, item)
+			if len(filtered) >= filterCheatsMaxResults {
+				break
+			}
+		}
+	}
+	return filtered
+}
+
 // ============================================================================
 // Variable Resolution Phase (unified TUI - no flicker)
 // ============================================================================
@@ -379,16 +836,41 @@ type shellResultMsg struct {
 	err     error
 }
 
+// queryPlaceholder is substituted with the live search/filter query in a
+// --reload variable's shell command, fzf's reload-binding style, and in a
+// manual ctrl+r reload of any variable.
+const queryPlaceholder = "$QUERY"
+
 // startVarResolution initiates variable resolution and returns a command
 func (m *mainModel) startVarResolution() tea.Cmd {
 	m.startVarResolutionInternal()
 	if m.phase != phaseVarResolve {
 		// No variables to resolve - finish immediately
-		return tea.Quit
+		return m.finishCheatResolution()
 	}
 	return m.prepareCurrentVar()
 }
 
+// finishCheatResolution runs once m.selected's variables are fully resolved
+// (or it turned out to have none). Outside --multi batch mode this just
+// quits the program, same as before the --multi feature existed. In batch
+// mode (m.multiActive) it instead records the cheat in multiDone and moves
+// on to the next cheat still in multiQueue, keeping the whole marked set's
+// variable resolution inside one unbroken TUI session; it only quits once
+// the queue is drained.
+func (m *mainModel) finishCheatResolution() tea.Cmd {
+	if !m.multiActive {
+		return tea.Quit
+	}
+	m.multiDone = append(m.multiDone, m.selected)
+	if len(m.multiQueue) > 0 {
+		m.selected = m.multiQueue[0]
+		m.multiQueue = m.multiQueue[1:]
+		return m.startVarResolution()
+	}
+	return tea.Quit
+}
+
 // startVarResolutionInternal sets up variable resolution state
 func (m *mainModel) startVarResolutionInternal() {
 	cheat := m.selected
@@ -415,6 +897,10 @@ func (m *mainModel) startVarResolutionInternal() {
 			vars[i].skipAutoCont = true
 		} else if envVal := os.Getenv(varName); envVal != "" {
 			vars[i].prefill = envVal
+		} else if m.hist != nil && !vars[i].def.Masked {
+			if values := m.hist.VarValues(varName); len(values) > 0 {
+				vars[i].prefill = values[0]
+			}
 		}
 	}
 
@@ -436,7 +922,7 @@ func (m *mainModel) startVarResolutionInternal() {
 // May run a shell command to get options
 func (m *mainModel) prepareCurrentVar() tea.Cmd {
 	if m.varState == nil || m.varState.currentIdx >= len(m.varState.vars) {
-		// All variables resolved - copy to scope and quit
+		// All variables resolved - copy to scope and finish up
 		if m.varState != nil {
 			for _, vs := range m.varState.vars {
 				if vs.resolved {
@@ -444,7 +930,7 @@ func (m *mainModel) prepareCurrentVar() tea.Cmd {
 				}
 			}
 		}
-		return tea.Quit
+		return m.finishCheatResolution()
 	}
 
 	vs := &m.varState.vars[m.varState.currentIdx]
@@ -491,6 +977,19 @@ func (m *mainModel) prepareCurrentVar() tea.Cmd {
 	// Extract custom header from args
 	m.varState.customHeader = extractCustomHeader(vs.def.Args)
 	m.varState.selectOpts = parseSelectorOpts(vs.def.Args)
+	if vs.def.Masked {
+		m.textInput.EchoMode = textinput.EchoPassword
+		m.textInput.EchoCharacter = '•'
+	} else {
+		m.textInput.EchoMode = textinput.EchoNormal
+	}
+	m.varState.picked = make(map[string]bool)
+	m.varState.order = nil
+	m.varState.showPreview = true
+	m.varState.previewScroll = 0
+	m.varState.previewCache = make(map[string]string)
+	m.varState.previewGen = 0
+	m.varState.validationErr = ""
 
 	// Handle literal values (no shell execution)
 	if vs.def.Literal != "" {
@@ -506,6 +1005,18 @@ func (m *mainModel) prepareCurrentVar() tea.Cmd {
 
 	// Check if shell command is empty (prompt only)
 	if strings.TrimSpace(vs.def.Shell) == "" {
+		// bool/enum vars with no shell source pick from a fixed list (yes/no,
+		// or the declared choices) instead of taking free text.
+		if opts := typedOptions(vs.def); len(opts) > 0 {
+			m.varState.options = opts
+			m.varState.isPromptOnly = false
+			m.buildVarFilteredList()
+			m.filterVarOptions()
+			m.cursor = 0
+			m.offset = 0
+			return nil
+		}
+
 		m.varState.isPromptOnly = true
 		m.varState.options = nil
 		m.varState.filtered = nil
@@ -532,6 +1043,136 @@ func (m *mainModel) prepareCurrentVar() tea.Cmd {
 	}
 }
 
+// reloadCurrentVar re-runs the current variable's selector shell command
+// against the current scope, with queryPlaceholder substituted for the live
+// search query, and feeds the result back through handleShellResult via
+// shellResultMsg - the same reentrant path prepareCurrentVar's initial run
+// uses, so options/filtered update without leaving the TUI. Used by the
+// ctrl+r binding (any variable) and, when its selector args declare
+// --reload, automatically whenever the query changes.
+func (m *mainModel) reloadCurrentVar() tea.Cmd {
+	if m.varState == nil || m.varState.currentIdx >= len(m.varState.vars) {
+		return nil
+	}
+	vs := &m.varState.vars[m.varState.currentIdx]
+	if strings.TrimSpace(vs.def.Shell) == "" {
+		return nil
+	}
+
+	scope := make(map[string]string)
+	for _, v := range m.varState.vars {
+		if v.resolved {
+			scope[v.def.Name] = v.value
+		}
+	}
+
+	shellCmd := vs.def.Shell
+	for name, value := range scope {
+		shellCmd = strings.ReplaceAll(shellCmd, "$"+name, value)
+	}
+	shellCmd = strings.ReplaceAll(shellCmd, queryPlaceholder, m.textInput.Value())
+
+	return func() tea.Msg {
+		output, err := m.executor.RunShell(shellCmd)
+		if err != nil {
+			return shellResultMsg{nil, err}
+		}
+		lines := splitLines(output)
+		return shellResultMsg{lines, nil}
+	}
+}
+
+// currentVarCandidate returns the original value of the highlighted option
+// in the variable options list, or "" if nothing is highlighted.
+func (m *mainModel) currentVarCandidate() string {
+	if m.varState == nil || m.cursor < 0 || m.cursor >= len(m.varState.filtered) {
+		return ""
+	}
+	return m.varState.filtered[m.cursor].original
+}
+
+// varPreviewCacheKey builds the previewCache key for candidate: the
+// candidate alone normally, or candidate plus the full ordered Multi pick
+// set when selectOpts.Multi, since a "{+f}" preview command's output
+// depends on every selection, not only the highlighted one.
+func (m *mainModel) varPreviewCacheKey(candidate string) string {
+	if m.varState.selectOpts.Multi && len(m.varState.order) > 0 {
+		return candidate + fieldSep + strings.Join(m.varState.order, fieldSep)
+	}
+	return candidate
+}
+
+// scheduleVarPreview (re)starts the debounce timer for the current
+// variable's selectOpts.PreviewCmd against the highlighted option, mirroring
+// varSelectModel.schedulePreview. A cursor move (or pick toggle, for Multi)
+// in the meantime invalidates the in-flight tick via previewGen, the same
+// way schedulePreview discards stale ticks.
+func (m *mainModel) scheduleVarPreview() tea.Cmd {
+	if m.varState == nil || m.varState.selectOpts.PreviewCmd == "" || !m.varState.showPreview {
+		return nil
+	}
+	candidate := m.currentVarCandidate()
+	if candidate == "" {
+		return nil
+	}
+	if _, cached := m.varState.previewCache[m.varPreviewCacheKey(candidate)]; cached {
+		return nil
+	}
+
+	m.varState.previewGen++
+	gen := m.varState.previewGen
+	return tea.Tick(previewDebounce, func(time.Time) tea.Msg {
+		return previewTickMsg{gen: gen, candidate: candidate}
+	})
+}
+
+// runVarPreviewCmd runs selectOpts.PreviewCmd for the variable flow,
+// fzf-style: "{}" is replaced with the shell-quoted highlighted candidate.
+// When the command also references "{+f}" - needed once the argument list
+// would exceed ARG_MAX - picks (the full Multi selection, falling back to
+// candidate alone when nothing's been toggled) are written one-per-line to
+// a tempfile and "{+f}" is replaced with its path.
+func runVarPreviewCmd(previewCmd, candidate string, picks []string) string {
+	rendered := previewCmd
+	if strings.Contains(rendered, "{+f}") {
+		items := picks
+		if len(items) == 0 && candidate != "" {
+			items = []string{candidate}
+		}
+		path, err := writePreviewPicksFile(items)
+		if err == nil {
+			defer os.Remove(path)
+		}
+		rendered = strings.ReplaceAll(rendered, "{+f}", path)
+	}
+	rendered = strings.ReplaceAll(rendered, "{}", shellQuote(candidate))
+
+	cmd := exec.Command(config.GetShell(), "-c", rendered)
+	cmd.Stdin = strings.NewReader(candidate)
+	out, _ := cmd.CombinedOutput()
+	if len(out) > previewOutputCap {
+		out = out[:previewOutputCap]
+	}
+	return strings.TrimRight(string(out), "\n")
+}
+
+// writePreviewPicksFile writes items one-per-line to a tempfile for a
+// "{+f}" preview command and returns its path; the caller removes it once
+// the command has run.
+func writePreviewPicksFile(items []string) (string, error) {
+	f, err := os.CreateTemp("", "cheatmd-preview-*")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	for _, item := range items {
+		if _, err := f.WriteString(item + "\n"); err != nil {
+			return "", err
+		}
+	}
+	return f.Name(), nil
+}
+
 // parseSelectorOpts parses selector options from args
 func parseSelectorOpts(selectorArgs string) SelectOptions {
 	opts := SelectOptions{}
@@ -557,6 +1198,25 @@ func parseSelectorOpts(selectorArgs string) SelectOptions {
 				opts.MapCmd = args[i+1]
 				i++
 			}
+		case "--reload":
+			opts.Reload = true
+		case "--join":
+			if i+1 < len(args) {
+				opts.Joiner = args[i+1]
+				i++
+			}
+		case "--preview":
+			if i+1 < len(args) {
+				opts.PreviewCmd = args[i+1]
+				i++
+			}
+		default:
+			if rest, ok := strings.CutPrefix(args[i], "--multi="); ok {
+				opts.Multi = true
+				fmt.Sscanf(rest, "%d", &opts.MultiMax)
+			} else if args[i] == "--multi" {
+				opts.Multi = true
+			}
 		}
 	}
 	return opts
@@ -571,6 +1231,13 @@ func (m mainModel) updateVarResolve(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	case shellResultMsg:
 		return m.handleShellResult(msg)
+	case previewTickMsg:
+		if m.varState != nil && msg.gen == m.varState.previewGen {
+			picks := m.varState.order
+			output := runVarPreviewCmd(m.varState.selectOpts.PreviewCmd, msg.candidate, picks)
+			m.varState.previewCache[m.varPreviewCacheKey(msg.candidate)] = output
+		}
+		return m, nil
 	}
 
 	// Update text input
@@ -578,12 +1245,16 @@ func (m mainModel) updateVarResolve(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var tiCmd tea.Cmd
 	m.textInput, tiCmd = m.textInput.Update(msg)
 
-	// Filter options if query changed
+	// Filter options if query changed - a --reload variable re-runs its
+	// shell command with the new query instead of filtering client-side
 	if m.textInput.Value() != prevQuery && !m.varState.isPromptOnly {
+		if m.varState.selectOpts.Reload {
+			return m, tea.Batch(tiCmd, m.reloadCurrentVar())
+		}
 		m.filterVarOptions()
 	}
 
-	return m, tiCmd
+	return m, tea.Batch(tiCmd, m.scheduleVarPreview())
 }
 
 // handleShellResult processes the result of a shell command
@@ -627,6 +1298,27 @@ func (m mainModel) handleShellResult(msg shellResultMsg) (tea.Model, tea.Cmd) {
 		m.textInput.SetValue(prefill)
 		m.textInput.CursorEnd()
 	default:
+		// Multiple options - give the configured external backend (fzf/sk/
+		// gum) first refusal, same as resolveVar's dormant fzf-shelling path;
+		// the embedded picker below is the zero-dependency default.
+		if value, goBack, handled := tryExternalSelector(msg.options, m.varState.selectOpts, m.plainVarHeader()); handled {
+			if goBack {
+				if m.varState.currentIdx > 0 {
+					m.varState.currentIdx--
+					prev := &m.varState.vars[m.varState.currentIdx]
+					prev.resolved = false
+					prev.value = ""
+					prev.skipAutoCont = true
+				}
+				return m, m.prepareCurrentVar()
+			}
+			vs.value = value
+			vs.rawValue = value
+			vs.resolved = true
+			m.varState.currentIdx++
+			return m, m.prepareCurrentVar()
+		}
+
 		// Multiple options - show selection
 		m.varState.isPromptOnly = false
 		m.buildVarFilteredList()
@@ -639,7 +1331,7 @@ func (m mainModel) handleShellResult(msg shellResultMsg) (tea.Model, tea.Cmd) {
 		m.offset = 0
 	}
 
-	return m, nil
+	return m, m.scheduleVarPreview()
 }
 
 // buildVarFilteredList builds the filtered list from options
@@ -703,59 +1395,8 @@ func (m *mainModel) filterVarOptions() {
 
 // handleVarResolveKey processes keyboard input during variable resolution
 func (m *mainModel) handleVarResolveKey(msg tea.KeyMsg) tea.Cmd {
-	switch msg.String() {
-	case "ctrl+c":
-		m.quitting = true
-		m.selected = nil // Signal to not execute
-		return tea.Quit
-	case "esc":
-		// Go back to previous var or cheat selection
-		if m.varState.currentIdx > 0 {
-			m.varState.currentIdx--
-			vs := &m.varState.vars[m.varState.currentIdx]
-			vs.resolved = false
-			vs.value = ""
-			vs.skipAutoCont = true
-			m.textInput.SetValue("")
-			m.cursor = 0
-			m.offset = 0
-			return m.prepareCurrentVar()
-		}
-		// Go back to cheat selection
-		m.phase = phaseCheatSelect
-		m.varState = nil
-		m.selected = nil
-		m.textInput.SetValue("")
-		m.textInput.Placeholder = "Type to search..."
-		m.cursor = 0
-		m.offset = 0
-		return nil
-	case "enter":
-		return m.acceptVarValue()
-	case "up", "ctrl+p":
-		if !m.varState.isPromptOnly {
-			m.moveVarCursor(-1)
-		}
-	case "down", "ctrl+n":
-		if !m.varState.isPromptOnly {
-			m.moveVarCursor(1)
-		}
-	case "pgup":
-		if !m.varState.isPromptOnly {
-			m.moveVarCursor(-10)
-		}
-	case "pgdown":
-		if !m.varState.isPromptOnly {
-			m.moveVarCursor(10)
-		}
-	case "tab":
-		if !m.varState.isPromptOnly && m.cursor < len(m.varState.filtered) {
-			m.textInput.SetValue(m.varState.filtered[m.cursor].display)
-		}
-	case "ctrl+o":
-		if m.varState != nil && m.varState.cheat != nil {
-			openFileInViewer(m.varState.cheat.File)
-		}
+	if action, ok := resolveActionChord(phaseVarResolve, msg.String()); ok {
+		return dispatchAction(m, phaseVarResolve, action)
 	}
 	return nil
 }
@@ -780,17 +1421,93 @@ func (m *mainModel) moveVarCursor(delta int) {
 	m.offset = clamp(m.offset, 0, maxOffset)
 }
 
-// acceptVarValue accepts the current value and moves to next variable
-func (m *mainModel) acceptVarValue() tea.Cmd {
-	if m.varState == nil {
-		return tea.Quit
+// toggleVarCursor toggles the highlighted option on or off for a Multi
+// variable, refusing to add a pick past selectOpts.MultiMax (0 means
+// unbounded).
+func (m *mainModel) toggleVarCursor() {
+	if m.varState == nil || m.cursor >= len(m.varState.filtered) {
+		return
 	}
+	value := m.varState.filtered[m.cursor].original
 
-	vs := &m.varState.vars[m.varState.currentIdx]
-	var value string
-
-	if m.varState.isPromptOnly {
-		value = m.textInput.Value()
+	if m.varState.picked[value] {
+		delete(m.varState.picked, value)
+		for i, v := range m.varState.order {
+			if v == value {
+				m.varState.order = append(m.varState.order[:i], m.varState.order[i+1:]...)
+				break
+			}
+		}
+		return
+	}
+
+	if maxN := m.varState.selectOpts.MultiMax; maxN > 0 && len(m.varState.order) >= maxN {
+		return
+	}
+	m.varState.picked[value] = true
+	m.varState.order = append(m.varState.order, value)
+}
+
+// selectAllVarOptions picks every currently filtered option for a Multi
+// variable, stopping at selectOpts.MultiMax if it's set.
+func (m *mainModel) selectAllVarOptions() {
+	if m.varState == nil {
+		return
+	}
+	for _, opt := range m.varState.filtered {
+		if m.varState.picked[opt.original] {
+			continue
+		}
+		if maxN := m.varState.selectOpts.MultiMax; maxN > 0 && len(m.varState.order) >= maxN {
+			break
+		}
+		m.varState.picked[opt.original] = true
+		m.varState.order = append(m.varState.order, opt.original)
+	}
+}
+
+// deselectAllVarOptions clears every pick for the current Multi variable.
+func (m *mainModel) deselectAllVarOptions() {
+	if m.varState == nil {
+		return
+	}
+	m.varState.picked = make(map[string]bool)
+	m.varState.order = nil
+}
+
+// joinedVarValue returns the Multi picks, in toggle order, joined by
+// selectOpts.Joiner (default " "). If nothing was toggled, it falls back to
+// the highlighted (or typed) value so Enter still works without Tab first.
+func (m *mainModel) joinedVarValue() string {
+	picks := m.varState.order
+	if len(picks) == 0 {
+		if m.cursor < len(m.varState.filtered) {
+			picks = []string{m.varState.filtered[m.cursor].original}
+		} else if v := m.textInput.Value(); v != "" {
+			picks = []string{v}
+		}
+	}
+
+	joiner := m.varState.selectOpts.Joiner
+	if joiner == "" {
+		joiner = " "
+	}
+	return strings.Join(picks, joiner)
+}
+
+// acceptVarValue accepts the current value and moves to next variable
+func (m *mainModel) acceptVarValue() tea.Cmd {
+	if m.varState == nil {
+		return tea.Quit
+	}
+
+	vs := &m.varState.vars[m.varState.currentIdx]
+	var value string
+
+	if m.varState.isPromptOnly {
+		value = m.textInput.Value()
+	} else if m.varState.selectOpts.Multi {
+		value = m.joinedVarValue()
 	} else if m.cursor < len(m.varState.filtered) {
 		// Selected from list - get original value
 		selected := m.varState.filtered[m.cursor].original
@@ -815,8 +1532,22 @@ func (m *mainModel) acceptVarValue() tea.Cmd {
 		value = m.textInput.Value()
 	}
 
+	// --multi joins several picks into one string - type/range validation,
+	// which assumes a single scalar, doesn't apply to the joined result.
+	if !m.varState.selectOpts.Multi {
+		normalized, verr := validateVarValue(vs.def, value)
+		if verr != nil {
+			m.varState.validationErr = verr.Error()
+			return nil
+		}
+		value = normalized
+	}
+
 	vs.value = value
 	vs.resolved = true
+	if m.hist != nil && !vs.def.Masked {
+		m.hist.RecordVarValue(vs.def.Name, value)
+	}
 	m.varState.currentIdx++
 
 	// Reset for next variable
@@ -865,21 +1596,22 @@ func (m mainModel) renderVarBottom(width int) string {
 	b.WriteString(styles.Divider.Render(strings.Repeat("─", width)))
 	b.WriteString("\n")
 
-	// Options list (if not prompt-only)
+	// Options list (if not prompt-only), with a side-by-side preview pane
+	// when the variable declared --preview and it hasn't been hidden
+	showVarPreview := m.varState.selectOpts.PreviewCmd != "" && m.varState.showPreview
 	if !m.varState.isPromptOnly && len(m.varState.filtered) > 0 {
-		listHeight := minInt(10, len(m.varState.filtered))
-		start, end := scrollWindow(m.cursor, len(m.varState.filtered), listHeight, &m.offset)
-
-		for i := start; i < end; i++ {
-			opt := m.varState.filtered[i]
-			if i == m.cursor {
-				b.WriteString(styles.Cursor.Render("▶ "))
-				b.WriteString(styles.Selected.Render(styles.Command.Render(opt.display)))
-			} else {
-				b.WriteString("  ")
-				b.WriteString(styles.Command.Render(opt.display))
-			}
+		if showVarPreview {
+			listWidth, previewWidth := previewSplit(width)
+			listHeight := minInt(10, len(m.varState.filtered))
+			divider := styles.Divider.Render(strings.TrimSuffix(strings.Repeat("│\n", listHeight), "\n"))
+			b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top,
+				m.renderVarOptionsList(listWidth),
+				divider,
+				m.renderVarPreviewPane(previewWidth),
+			))
 			b.WriteString("\n")
+		} else {
+			b.WriteString(m.renderVarOptionsList(width))
 		}
 	}
 
@@ -891,15 +1623,98 @@ func (m mainModel) renderVarBottom(width int) string {
 		b.WriteString(styles.Dim.Render(fmt.Sprintf("  %d options", len(m.varState.filtered))))
 		b.WriteString(" • ")
 	}
+	if m.varState.selectOpts.Multi {
+		b.WriteString(styles.Dim.Render(multiSelectedLabel(m.varState) + " • Tab toggle • Ctrl+A all • Ctrl+D none"))
+		b.WriteString(" • ")
+	}
+	if m.varState.selectOpts.PreviewCmd != "" {
+		b.WriteString(styles.Dim.Render("Ctrl+/ preview • "))
+	}
 	b.WriteString(styles.Dim.Render("ESC back"))
 	b.WriteString(" • ")
 	b.WriteString(styles.Dim.Render("Enter accept"))
 	b.WriteString("\n")
 	b.WriteString(m.textInput.View())
 
+	if m.varState.validationErr != "" {
+		b.WriteString("\n")
+		b.WriteString(errorStyle.Render(m.varState.validationErr))
+	}
+
 	return b.String()
 }
 
+// renderVarOptionsList renders the scrollable candidate list for the
+// current variable, width-clamped so it can sit beside renderVarPreviewPane.
+func (m mainModel) renderVarOptionsList(width int) string {
+	b := getBuilder()
+	defer putBuilder(b)
+
+	listHeight := minInt(10, len(m.varState.filtered))
+	start, end := scrollWindow(m.cursor, len(m.varState.filtered), listHeight, &m.offset)
+
+	for i := start; i < end; i++ {
+		opt := m.varState.filtered[i]
+		mark := ""
+		if m.varState.selectOpts.Multi {
+			mark = "☐ "
+			if m.varState.picked[opt.original] {
+				mark = "☑ "
+			}
+		}
+		if i == m.cursor {
+			b.WriteString(styles.Cursor.Render("▶ " + mark))
+			b.WriteString(styles.Selected.Render(styles.Command.Render(opt.display)))
+		} else {
+			b.WriteString("  " + mark)
+			b.WriteString(styles.Command.Render(opt.display))
+		}
+		b.WriteString("\n")
+	}
+
+	return lipgloss.NewStyle().Width(width).Render(b.String())
+}
+
+// renderVarPreviewPane renders the right-hand pane showing selectOpts.PreviewCmd's
+// output for the highlighted candidate (and, for a Multi variable, the full
+// pick set once one exists), populated asynchronously by scheduleVarPreview.
+func (m mainModel) renderVarPreviewPane(width int) string {
+	b := getBuilder()
+	defer putBuilder(b)
+
+	b.WriteString(styles.PreviewHeader.Render("Preview"))
+	b.WriteString(" ")
+	b.WriteString(styles.Dim.Render("(ctrl+/ toggle)"))
+	b.WriteString("\n")
+
+	candidate := m.currentVarCandidate()
+	output, ready := m.varState.previewCache[m.varPreviewCacheKey(candidate)]
+	if !ready {
+		output = "Loading preview..."
+	}
+
+	lines := strings.Split(output, "\n")
+	const previewHeight = 8
+	start := clamp(m.varState.previewScroll, 0, maxInt(0, len(lines)-1))
+	end := minInt(len(lines), start+previewHeight)
+
+	for _, line := range lines[start:end] {
+		b.WriteString(styles.PreviewCmd.Render(line))
+		b.WriteString("\n")
+	}
+
+	return lipgloss.NewStyle().Width(width).Render(b.String())
+}
+
+// multiSelectedLabel formats the running "n selected" footer indicator for a
+// Multi variable, including the configured --multi=N cap when one is set.
+func multiSelectedLabel(vs *varResolveState) string {
+	if vs.selectOpts.MultiMax > 0 {
+		return fmt.Sprintf("%d/%d selected", len(vs.order), vs.selectOpts.MultiMax)
+	}
+	return fmt.Sprintf("%d selected", len(vs.order))
+}
+
 // renderVarHeader renders the progress header for variable resolution
 func (m mainModel) renderVarHeader(width int) string {
 	if m.varState == nil {
@@ -951,6 +1766,29 @@ func (m mainModel) renderVarHeader(width int) string {
 	return b.String()
 }
 
+// plainVarHeader renders the same progress context as renderVarHeader, but
+// unstyled, for tryExternalSelector to hand to fzf/sk/gum's --header and
+// --preview (which don't want lipgloss escape codes baked in).
+func (m mainModel) plainVarHeader() string {
+	if m.varState == nil {
+		return ""
+	}
+
+	cmdLine := m.varState.cheat.Command
+	for i, vs := range m.varState.vars {
+		if vs.resolved {
+			cmdLine = replaceVar(cmdLine, vs.def.Name, vs.value)
+		} else if i == m.varState.currentIdx {
+			cmdLine = replaceVar(cmdLine, vs.def.Name, "$"+vs.def.Name)
+		}
+	}
+
+	if m.varState.customHeader == "" {
+		return cmdLine
+	}
+	return cmdLine + "\n\n" + m.varState.customHeader
+}
+
 // View implements tea.Model
 func (m mainModel) View() string {
 	if m.quitting && m.selected == nil {
@@ -966,63 +1804,244 @@ func (m mainModel) View() string {
 	}
 }
 
+// previewWindowPosition is where --preview-window puts renderPreview's
+// panel relative to the cheat list.
+type previewWindowPosition int
+
+const (
+	previewUp    previewWindowPosition = iota // above the list (the default, unchanged since before --preview-window existed)
+	previewDown                               // below the list
+	previewRight                              // beside the list, split like a variable prompt's --preview pane
+)
+
+// parsePreviewWindow splits config.GetPreviewWindow() ("up", "down:10",
+// "right:40%", ...) into a position and an optional size override. size 0
+// means "use the built-in default": 6 fixed lines for up/down, or
+// config.GetPreviewWidth()'s percentage for right.
+func parsePreviewWindow(spec string) (pos previewWindowPosition, size int, isPercent bool) {
+	position, sizeSpec, _ := strings.Cut(spec, ":")
+	switch strings.TrimSpace(position) {
+	case "down":
+		pos = previewDown
+	case "right":
+		pos = previewRight
+	default:
+		pos = previewUp
+	}
+
+	sizeSpec = strings.TrimSpace(sizeSpec)
+	if sizeSpec == "" {
+		return pos, 0, false
+	}
+	if n, err := strconv.Atoi(strings.TrimSuffix(sizeSpec, "%")); err == nil && n > 0 {
+		return pos, n, strings.HasSuffix(sizeSpec, "%")
+	}
+	return pos, 0, false
+}
+
 // renderCheatSelect builds the cheat selection view
 func (m mainModel) renderCheatSelect() string {
 	width := maxInt(m.width, 80)
 	height := maxInt(m.height, 24)
 
-	preview := m.renderPreview(width)
+	cheatPreview := ""
+	if m.showCheatPreview {
+		cheatPreview = m.renderCheatPreviewPane(width)
+	}
+	cheatPreviewLines := countLines(cheatPreview)
+	inputLines := 3 // divider + info + input
+
+	pos, size, isPercent := parsePreviewWindow(config.GetPreviewWindow())
+	if pos == previewRight {
+		return m.renderCheatSelectRight(width, height, size, isPercent, cheatPreview, cheatPreviewLines, inputLines)
+	}
+
+	maxLines := 6
+	if size > 0 && !isPercent {
+		maxLines = size
+	}
+	preview := m.renderPreview(width, maxLines)
 	previewLines := countLines(preview)
 
-	inputLines := 3 // divider + info + input
-	listHeight := maxInt(height-previewLines-inputLines, 3)
+	listHeight := maxInt(height-previewLines-cheatPreviewLines-inputLines, 3)
 	list := m.renderList(listHeight)
 	listLines := countLines(list)
 
-	padding := maxInt(height-previewLines-listLines-inputLines, 0)
+	padding := maxInt(height-previewLines-cheatPreviewLines-listLines-inputLines, 0)
 
 	b := getBuilder()
 	defer putBuilder(b)
-	b.WriteString(preview)
-	b.WriteString(list)
-	b.WriteString(strings.Repeat("\n", padding))
-	b.WriteString(m.renderInput(width))
+	if m.reverseLayout {
+		// fzf --reverse: input stays on top, list grows downward beneath it.
+		b.WriteString(m.renderInput(width))
+		if pos == previewDown {
+			b.WriteString(list)
+			b.WriteString(cheatPreview)
+			b.WriteString(preview)
+		} else {
+			b.WriteString(preview)
+			b.WriteString(cheatPreview)
+			b.WriteString(list)
+		}
+		b.WriteString(strings.Repeat("\n", padding))
+	} else if pos == previewDown {
+		b.WriteString(list)
+		b.WriteString(strings.Repeat("\n", padding))
+		b.WriteString(cheatPreview)
+		b.WriteString(preview)
+		b.WriteString(m.renderInput(width))
+	} else {
+		b.WriteString(preview)
+		b.WriteString(list)
+		b.WriteString(strings.Repeat("\n", padding))
+		b.WriteString(cheatPreview)
+		b.WriteString(m.renderInput(width))
+	}
 
 	return b.String()
 }
 
-// renderPreview renders the preview section for the selected cheat
-func (m mainModel) renderPreview(width int) string {
+// renderCheatSelectRight is renderCheatSelect's --preview-window=right
+// layout: the list and renderCheatSidePreview sit side by side (like
+// renderVarBottom's --preview split), with the ctrl+/ pane and input still
+// stacked beneath, same as the up/down layouts.
+func (m mainModel) renderCheatSelectRight(width, height, size int, isPercent bool, cheatPreview string, cheatPreviewLines, inputLines int) string {
+	var previewWidth int
+	switch {
+	case size > 0 && isPercent:
+		previewWidth = (width - 1) * size / 100
+	case size > 0:
+		previewWidth = size
+	default:
+		previewWidth = (width - 1) * clamp(config.GetPreviewWidth(), 10, 90) / 100
+	}
+	previewWidth = clamp(previewWidth, 10, maxInt(width-21, 10))
+	listWidth := width - previewWidth - 1
+
+	listHeight := maxInt(height-cheatPreviewLines-inputLines, 3)
+	list := m.renderList(listHeight)
+	listLines := countLines(list)
+	padding := maxInt(height-cheatPreviewLines-listLines-inputLines, 0)
+
+	divider := styles.Divider.Render(strings.TrimSuffix(strings.Repeat("│\n", listLines), "\n"))
+	body := lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().Width(listWidth).Render(list),
+		divider,
+		m.renderCheatSidePreview(previewWidth, listLines),
+	)
+
+	b := getBuilder()
+	defer putBuilder(b)
+	if m.reverseLayout {
+		b.WriteString(m.renderInput(width))
+		b.WriteString(body)
+		b.WriteString("\n")
+		b.WriteString(cheatPreview)
+		b.WriteString(strings.Repeat("\n", padding))
+	} else {
+		b.WriteString(body)
+		b.WriteString("\n")
+		b.WriteString(strings.Repeat("\n", padding))
+		b.WriteString(cheatPreview)
+		b.WriteString(m.renderInput(width))
+	}
+
+	return b.String()
+}
+
+// renderCheatSidePreview renders --preview-window=right's preview panel:
+// the same preview_command output renderPreview/renderCheatPreviewPane show
+// (or, when unconfigured, the cheat's full description/command), laid out
+// beside the list instead of above or below it.
+func (m mainModel) renderCheatSidePreview(width, height int) string {
+	b := getBuilder()
+	defer putBuilder(b)
+
+	if m.cursor < len(m.filtered) {
+		item := m.filtered[m.cursor]
+
+		var body string
+		if previewCmd := config.GetPreviewCmd(); previewCmd != "" {
+			output, ready := m.cheatPreviewCache[cheatPreviewCacheKey(item)]
+			if !ready {
+				output = "Loading preview..."
+			}
+			body = output
+		} else {
+			var full strings.Builder
+			if item.cheat.Description != "" {
+				full.WriteString(item.cheat.Description)
+				full.WriteString("\n\n")
+			}
+			full.WriteString(highlightSource(item.cheat.Command, ""))
+			body = full.String()
+		}
+
+		lines := strings.Split(body, "\n")
+		if len(lines) > height {
+			lines = lines[:height]
+		}
+		for _, line := range lines {
+			b.WriteString(styles.PreviewCmd.Render(line))
+			b.WriteString("\n")
+		}
+	}
+
+	return lipgloss.NewStyle().Width(width).Render(strings.TrimSuffix(b.String(), "\n"))
+}
+
+// renderPreview renders the preview section for the selected cheat, fixed
+// at maxLines tall (6 by default; --preview-window=up:N or down:N overrides
+// it - see parsePreviewWindow). When config.GetPreviewCmd() is set, this
+// shows that command's (cached, asynchronously populated) output instead of
+// the built-in Header/Description/Command summary below.
+func (m mainModel) renderPreview(width, maxLines int) string {
 	b := getBuilder()
 	defer putBuilder(b)
 	lines := 0
-	const maxLines = 6
 
 	if m.cursor < len(m.filtered) {
 		item := m.filtered[m.cursor]
-		b.WriteString(styles.PreviewPath.Render(item.folder + "/" + item.file))
-		b.WriteString("\n")
-		lines++
 
-		b.WriteString(styles.PreviewHeader.Render(item.cheat.Header))
-		b.WriteString("\n")
-		lines++
+		if previewCmd := config.GetPreviewCmd(); previewCmd != "" {
+			output, ready := m.cheatPreviewCache[cheatPreviewCacheKey(item)]
+			if !ready {
+				output = "Loading preview..."
+			}
+			outLines := strings.SplitN(output, "\n", maxLines+1)
+			if len(outLines) > maxLines {
+				outLines = outLines[:maxLines]
+			}
+			for _, line := range outLines {
+				b.WriteString(styles.PreviewCmd.Render(line))
+				b.WriteString("\n")
+				lines++
+			}
+		} else {
+			b.WriteString(styles.PreviewPath.Render(item.folder + "/" + item.file))
+			b.WriteString("\n")
+			lines++
 
-		if item.cheat.Description != "" {
-			desc := truncateLines(item.cheat.Description, 1, 200)
-			b.WriteString(styles.PreviewDesc.Render(desc))
+			b.WriteString(styles.PreviewHeader.Render(item.cheat.Header))
 			b.WriteString("\n")
 			lines++
-		}
 
-		b.WriteString("\n")
-		lines++
+			if item.cheat.Description != "" {
+				desc := truncateLines(item.cheat.Description, 1, 200)
+				b.WriteString(styles.PreviewDesc.Render(desc))
+				b.WriteString("\n")
+				lines++
+			}
 
-		cmd := truncateLines(item.cheat.Command, maxLines-lines, 0)
-		cmdLines := strings.Count(cmd, "\n") + 1
-		b.WriteString(styles.PreviewCmd.Render(cmd))
-		b.WriteString("\n")
-		lines += cmdLines
+			b.WriteString("\n")
+			lines++
+
+			cmd := truncateLines(item.cheat.Command, maxLines-lines, 0)
+			cmdLines := strings.Count(cmd, "\n") + 1
+			b.WriteString(styles.PreviewCmd.Render(cmd))
+			b.WriteString("\n")
+			lines += cmdLines
+		}
 	}
 
 	// Pad to fixed height
@@ -1037,6 +2056,64 @@ func (m mainModel) renderPreview(width int) string {
 	return b.String()
 }
 
+// cheatPreviewPaneLines bounds how many lines of cheatPreviewPane's body are
+// visible at once; shift+up/down scroll through the rest.
+const cheatPreviewPaneLines = 10
+
+// renderCheatPreviewPane renders the toggleable (ctrl+/) bottom preview
+// window for the cursored cheat: config.GetPreviewCmd()'s output when one is
+// configured, populated asynchronously by scheduleCheatPreview, or the
+// cheat's full (untruncated) description and command otherwise - unlike
+// renderPreview's fixed-height, truncated summary above the list.
+func (m mainModel) renderCheatPreviewPane(width int) string {
+	if m.cursor >= len(m.filtered) {
+		return ""
+	}
+	item := m.filtered[m.cursor]
+
+	b := getBuilder()
+	defer putBuilder(b)
+
+	b.WriteString(styles.PreviewHeader.Render("Preview"))
+	b.WriteString(" ")
+	b.WriteString(styles.Dim.Render("(ctrl+/ toggle, shift+↑/↓ scroll)"))
+	b.WriteString("\n")
+
+	var body string
+	if previewCmd := config.GetPreviewCmd(); previewCmd != "" {
+		output, ready := m.cheatPreviewCache[cheatPreviewCacheKey(item)]
+		if !ready {
+			output = "Loading preview..."
+		}
+		body = output
+	} else {
+		var full strings.Builder
+		if item.cheat.Description != "" {
+			full.WriteString(item.cheat.Description)
+			full.WriteString("\n\n")
+		}
+		full.WriteString(highlightSource(item.cheat.Command, ""))
+		body = full.String()
+	}
+
+	lines := strings.Split(body, "\n")
+	start := clamp(m.cheatPreviewScroll, 0, maxInt(0, len(lines)-1))
+	end := minInt(len(lines), start+cheatPreviewPaneLines)
+
+	var visible strings.Builder
+	for _, line := range lines[start:end] {
+		visible.WriteString(line)
+		visible.WriteString("\n")
+	}
+
+	b.WriteString(styles.Border.Width(maxInt(width-2, 1)).Render(strings.TrimSuffix(visible.String(), "\n")))
+	b.WriteString("\n")
+	b.WriteString(styles.Divider.Render(strings.Repeat("─", width)))
+	b.WriteString("\n")
+
+	return b.String()
+}
+
 // renderList renders the scrollable list of cheats
 func (m *mainModel) renderList(maxHeight int) string {
 	if len(m.filtered) == 0 {
@@ -1065,7 +2142,7 @@ func (m mainModel) renderListItem(item cheatItem, selected bool, gap string) str
 	// Build header column
 	pathPart := item.folder + "/" + item.file
 	headerPart := item.cheat.Header
-	headerRendered := m.renderHeaderColumn(pathPart, headerPart, pStyle, hStyle, selected)
+	headerRendered := m.renderHeaderColumn(pathPart, headerPart, pStyle, hStyle, selected, item.headerPositions)
 
 	// Description and command columns
 	desc := truncateString(firstLine(item.cheat.Description), m.columns.descWidth)
@@ -1081,10 +2158,22 @@ func (m mainModel) renderListItem(item cheatItem, selected bool, gap string) str
 	}
 
 	line := headerRendered + gapStr + dStyle.Render(descPadded) + gapStr + cStyle.Render(cmd)
+
+	prefix := "  "
 	if selected {
-		return styles.Cursor.Render("▶ ") + line
+		prefix = styles.Cursor.Render("▶ ")
+	}
+	if m.hist != nil && m.hist.FrecencyScore(historyKey(&item), time.Now()) > 0 {
+		prefix = styles.Dim.Render("★ ") + prefix
+	}
+	if config.GetMulti() {
+		marker := "  "
+		if m.multiSelected[item.cheat] {
+			marker = styles.Command.Render("✓ ")
+		}
+		prefix = marker + prefix
 	}
-	return "  " + line
+	return prefix + line
 }
 
 // getItemStyles returns the appropriate styles based on selection state
@@ -1099,11 +2188,18 @@ func (m mainModel) getItemStyles(selected bool) (path, header, desc, cmd lipglos
 	return
 }
 
-// renderHeaderColumn renders the path+header column with proper truncation
-func (m mainModel) renderHeaderColumn(pathPart, headerPart string, pStyle, hStyle lipgloss.Style, selected bool) string {
+// renderHeaderColumn renders the path+header column with proper truncation.
+// positions are the matched rune indices from scoreCheatItem into the
+// original (untruncated) headerPart; they're only used to highlight matches
+// with styles.Cursor when headerPart survives truncation intact, since a
+// truncated headerPart no longer indexes the same runes.
+func (m mainModel) renderHeaderColumn(pathPart, headerPart string, pStyle, hStyle lipgloss.Style, selected bool, positions []int) string {
 	fullHeader := pathPart + " " + headerPart
+	original := headerPart
+	truncated := false
 
 	if m.columns.headerWidth > 1 && len(fullHeader) > m.columns.headerWidth {
+		truncated = true
 		fullHeader = fullHeader[:m.columns.headerWidth-1] + "…"
 		if len(pathPart) >= len(fullHeader) {
 			pathPart = fullHeader
@@ -1114,10 +2210,13 @@ func (m mainModel) renderHeaderColumn(pathPart, headerPart string, pStyle, hStyl
 	}
 
 	var rendered string
-	if headerPart != "" {
-		rendered = pStyle.Render(pathPart) + " " + hStyle.Render(headerPart)
-	} else {
+	switch {
+	case headerPart == "":
 		rendered = pStyle.Render(pathPart)
+	case !truncated && headerPart == original && len(positions) > 0:
+		rendered = pStyle.Render(pathPart) + " " + highlightMatches(headerPart, positions, hStyle)
+	default:
+		rendered = pStyle.Render(pathPart) + " " + hStyle.Render(headerPart)
 	}
 
 	// Pad to column width
@@ -1157,7 +2256,19 @@ func (m mainModel) renderInput(width int) string {
 	defer putBuilder(b)
 	b.WriteString(styles.Divider.Render(strings.Repeat("─", width)))
 	b.WriteString("\n")
-	b.WriteString(styles.Dim.Render(fmt.Sprintf("  %d/%d", len(m.filtered), len(m.cheats))))
+	counter := fmt.Sprintf("  %d/%d", len(m.filtered), len(m.cheats))
+	if config.GetMulti() && len(m.multiOrder) > 0 {
+		counter += fmt.Sprintf(" (%d)", len(m.multiOrder))
+	}
+	b.WriteString(styles.Dim.Render(counter))
+	if m.reloadNotice != "" {
+		b.WriteString(" • ")
+		b.WriteString(styles.Dim.Render(m.reloadNotice))
+	}
+	if m.outputOverride != "" {
+		b.WriteString(" • ")
+		b.WriteString(styles.Dim.Render("output: " + m.outputOverride))
+	}
 	b.WriteString(" • ")
 	b.WriteString(styles.Dim.Render("Ctrl+O open"))
 	b.WriteString(" • ")
@@ -1194,8 +2305,14 @@ func getTTY() (in *os.File, out *os.File, cleanup func()) {
 			closers = append(closers, func() { in.Close() })
 		}
 
-		// Tell lipgloss to use the TTY for color detection
-		lipgloss.SetDefaultRenderer(lipgloss.NewRenderer(out))
+		// Tell lipgloss to use the TTY for color detection, then apply our
+		// own COLORTERM/TERM/NO_COLOR/--force-color profile on top: termenv's
+		// own auto-detection sniffs os.Stdout, which here is /dev/tty rather
+		// than the pipe cheatmd's actual stdout writes to, so it can't be
+		// trusted to pick up an override by itself.
+		renderer := lipgloss.NewRenderer(out)
+		applyColorProfile(renderer)
+		lipgloss.SetDefaultRenderer(renderer)
 
 		return in, out, func() {
 			for _, c := range closers {
@@ -1204,12 +2321,78 @@ func getTTY() (in *os.File, out *os.File, cleanup func()) {
 		}
 	}
 
-	// stdout IS a terminal - use normal stdin/stdout
+	// stdout IS a terminal - still apply our profile so --force-color and
+	// NO_COLOR are honored even though termenv's own sniff of os.Stdout
+	// would otherwise already get COLORTERM/TERM right on its own.
+	applyColorProfile(lipgloss.DefaultRenderer())
 	return os.Stdin, os.Stdout, func() {}
 }
 
-// RunTUI launches the Bubble Tea interface (unified - no flicker)
-func RunTUI(index *parser.CheatIndex, exec *executor.Executor, initialQuery, matchCmd string) error {
+// terminalRows returns the terminal's current row count by shelling out to
+// "stty size" against stdin - no new terminal-size dependency
+// needed since the codebase already shells out for terminal work elsewhere
+// (see disableEcho in internal/executor/scope.go). Returns 0, err if stdin
+// isn't a terminal or stty isn't available, in which case callers fall back
+// to a sane default.
+func terminalRows() (int, error) {
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) != 2 {
+		return 0, fmt.Errorf("unexpected stty size output: %q", out)
+	}
+	rows, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, err
+	}
+	return rows, nil
+}
+
+// parseHeightSpec resolves config.GetHeight()'s fzf-style spec - a bare row
+// count ("20") or a percentage of the terminal ("40%") - against the known
+// terminal row count. Returns 0 (fullscreen) for an empty spec or one that
+// fails to parse.
+func parseHeightSpec(spec string, termRows int) int {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return 0
+	}
+	if pct, ok := strings.CutSuffix(spec, "%"); ok {
+		n, err := strconv.Atoi(strings.TrimSpace(pct))
+		if err != nil || n <= 0 {
+			return 0
+		}
+		rows := termRows * n / 100
+		return maxInt(rows, 3)
+	}
+	n, err := strconv.Atoi(spec)
+	if err != nil || n <= 0 {
+		return 0
+	}
+	return n
+}
+
+// reserveInlineRows prints n blank lines and then moves the cursor back up
+// to the first of them, the same trick fzf's own --height mode uses to
+// claim scrollback space without clearing the terminal: the TUI then
+// renders into those reserved rows instead of the alt-screen buffer.
+func reserveInlineRows(out *os.File, n int) {
+	if n <= 0 {
+		return
+	}
+	fmt.Fprint(out, strings.Repeat("\n", n))
+	fmt.Fprintf(out, "\x1b[%dA", n)
+}
+
+// RunTUI launches the Bubble Tea interface (unified - no flicker). reload,
+// if non-nil, re-parses the cheat root from scratch and backs the "reload"
+// action (default ctrl+r); watchDir, if non-empty, is additionally watched
+// in the background via fsnotify so an external edit reloads on its own.
+func RunTUI(index *parser.CheatIndex, exec *executor.Executor, initialQuery, matchCmd string, reload func() (*parser.CheatIndex, error), watchDir string) error {
 	requireCheatBlock := config.GetRequireCheatBlock()
 	autoSelect := config.GetAutoSelect()
 
@@ -1219,20 +2402,38 @@ func RunTUI(index *parser.CheatIndex, exec *executor.Executor, initialQuery, mat
 	}
 
 	m := newMainModel(cheats, index, exec)
+	m.reloadFn = reload
+	if config.GetHistoryEnabled() {
+		m.hist = history.Load(config.GetHistoryPath(), config.GetHistoryMaxEntries())
+	}
+
+	inlineHeight := 0
+	if heightSpec := config.GetHeight(); heightSpec != "" {
+		termRows, err := terminalRows()
+		if err != nil || termRows <= 0 {
+			termRows = 24
+		}
+		inlineHeight = parseHeightSpec(heightSpec, termRows)
+	}
+	m.inlineHeight = inlineHeight
+	m.reverseLayout = inlineHeight > 0 && config.GetReverse()
 
 	// If matchCmd is provided, try to find a cheat whose command matches
 	if matchCmd != "" {
 		if matched := findMatchingCheat(cheats, matchCmd); matched != nil {
 			m.selected = matched
-			// Pre-fill scope from the matched command
+			// Pre-fill scope from the matched command, then work backward to
+			// infer any hidden variables (e.g. auth_method) that produced it
 			prefillScopeFromMatch(matched, matchCmd)
+			inferDependentVars(matched, index)
 			// Start variable resolution immediately
 			m.startVarResolutionInternal()
 
 			// If no variables to resolve, skip TUI entirely
 			if m.phase != phaseVarResolve {
 				finalCmd := exec.BuildFinalCommand(m.selected)
-				return executeOutput(finalCmd, exec)
+				m.recordHistorySelection(m.selected)
+				return executeOutput(m.selected, finalCmd, exec)
 			}
 		} else {
 			// No exact match - use as initial query
@@ -1252,7 +2453,8 @@ func RunTUI(index *parser.CheatIndex, exec *executor.Executor, initialQuery, mat
 			// If no variables to resolve, skip TUI entirely
 			if m.phase != phaseVarResolve {
 				finalCmd := exec.BuildFinalCommand(m.selected)
-				return executeOutput(finalCmd, exec)
+				m.recordHistorySelection(m.selected)
+				return executeOutput(m.selected, finalCmd, exec)
 			}
 		}
 	}
@@ -1260,7 +2462,17 @@ func RunTUI(index *parser.CheatIndex, exec *executor.Executor, initialQuery, mat
 	// Always run the TUI (unified flow handles everything)
 	ttyIn, ttyOut, cleanup := getTTY()
 	RefreshStyles() // Refresh after getTTY sets up the renderer
-	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithOutput(ttyOut), tea.WithInput(ttyIn))
+
+	opts := []tea.ProgramOption{tea.WithOutput(ttyOut), tea.WithInput(ttyIn)}
+	if m.inlineHeight > 0 {
+		reserveInlineRows(ttyOut, m.inlineHeight)
+	} else {
+		opts = append(opts, tea.WithAltScreen())
+	}
+	p := tea.NewProgram(m, opts...)
+	if watchDir != "" {
+		go watchCheatDir(p, watchDir)
+	}
 	finalModel, err := p.Run()
 	cleanup()
 
@@ -1273,13 +2485,98 @@ func RunTUI(index *parser.CheatIndex, exec *executor.Executor, initialQuery, mat
 		return nil
 	}
 
+	// ctrl+t (cycle-output) overrides config.GetOutput() for just this run.
+	if result.outputOverride != "" {
+		config.SetOutput(result.outputOverride)
+	}
+
+	// --multi: every marked cheat's variables were resolved in turn, so join
+	// their built commands instead of running the single-selection path.
+	// Hooks (pre_hook/post_hook) run once, in the first marked cheat's
+	// context, the same way a single cheat's hooks always have.
+	if result.multiActive {
+		if len(result.multiDone) == 0 {
+			return nil
+		}
+		for _, cheat := range result.multiDone {
+			result.recordHistorySelection(cheat)
+		}
+		finalCmd := exec.BuildFinalCommandBatch(result.multiDone, config.GetMultiJoin())
+		return executeOutput(result.multiDone[0], finalCmd, exec)
+	}
+
 	// The unified TUI completes with the final command built
 	if result.selected == nil {
 		return nil
 	}
 
+	result.recordHistorySelection(result.selected)
 	finalCmd := exec.BuildFinalCommand(result.selected)
-	return executeOutput(finalCmd, exec)
+	return executeOutput(result.selected, finalCmd, exec)
+}
+
+// recordHistorySelection records cheat's selection in m.hist (a no-op if
+// history is disabled) and flushes it to disk immediately - RunTUI returns
+// right after calling this, so there's no later point to batch the save.
+func (m mainModel) recordHistorySelection(cheat *parser.Cheat) {
+	if m.hist == nil || cheat == nil {
+		return
+	}
+	m.hist.RecordSelection(history.Key(cheat.Header, cheat.Command), time.Now())
+	_ = m.hist.Save()
+}
+
+// reloadWatchDebounce coalesces a burst of filesystem events (e.g. a save
+// that touches several files, or an editor's write-then-rename) into a
+// single reload instead of one per event.
+const reloadWatchDebounce = 300 * time.Millisecond
+
+// watchCheatDir runs for the life of the TUI, sending p a reloadMsg
+// whenever a file under root changes. It's best-effort: if fsnotify fails
+// to start (e.g. the inotify watch limit is hit), it silently gives up,
+// matching --watch's framing as an optional convenience over the "reload"
+// action, which always works. Watches are registered once at startup, so a
+// newly created subdirectory only starts being watched after the next
+// reload.
+func watchCheatDir(p *tea.Program, root string) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return
+	}
+	defer watcher.Close()
+
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		watcher.Add(path)
+		return nil
+	})
+
+	var debounce *time.Timer
+	for {
+		select {
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+				continue
+			}
+			if debounce == nil {
+				debounce = time.AfterFunc(reloadWatchDebounce, func() { p.Send(reloadMsg{}) })
+			} else {
+				debounce.Reset(reloadWatchDebounce)
+			}
+		case _, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
 }
 
 // filterCheatsByConfig returns cheats matching configuration
@@ -1365,16 +2662,6 @@ func truncateLines(text string, maxLines int, maxLen int) string {
 	return text
 }
 
-// matchesAllWords returns true if text contains all words
-func matchesAllWords(text string, words []string) bool {
-	for _, word := range words {
-		if !strings.Contains(text, word) {
-			return false
-		}
-	}
-	return true
-}
-
 // findMatchingCheat finds a cheat whose command pattern matches the input
 // It builds a regex from the cheat command (replacing $var with capture groups)
 // and returns the first match
@@ -1385,7 +2672,7 @@ func findMatchingCheat(cheats []*parser.Cheat, input string) *parser.Cheat {
 	}
 
 	for _, cheat := range cheats {
-		pattern := buildMatchPattern(cheat.Command)
+		pattern, _ := buildMatchPattern(cheat.Command)
 		if pattern.MatchString(input) {
 			return cheat
 		}
@@ -1393,36 +2680,92 @@ func findMatchingCheat(cheats []*parser.Cheat, input string) *parser.Cheat {
 	return nil
 }
 
-// buildMatchPattern converts a command template to a regex pattern for matching
-// e.g. "echo $name" -> "^echo (\S+)$"
-// e.g. 'echo "$name"' -> '^echo "([^"]*)"$'
-func buildMatchPattern(cmd string) *regexp.Regexp {
+// matchPatternCache caches the compiled regex and variable names for a
+// command template, keyed on the raw template string, so repeated --match
+// lookups across the whole index don't recompile and re-expand the same
+// pattern classes on every call.
+var (
+	matchPatternMu    sync.Mutex
+	matchPatternCache = make(map[string]matchPatternEntry)
+)
+
+type matchPatternEntry struct {
+	re       *regexp.Regexp
+	varNames []string
+}
+
+// annotatedVarPattern matches a QuoteMeta-escaped "$name" optionally followed
+// by ":CLASS", selecting a patterns.catalogue entry for reverse extraction,
+// e.g. "$dc_ip:IPV4" captures only dotted-quad text for dc_ip instead of a
+// generic catch-all.
+var annotatedVarPattern = regexp.MustCompile(`\\\$(\w+)(?::(\w+))?`)
+
+// buildMatchPattern converts a command template into a regex for reverse
+// extraction, e.g. "echo $name" -> "^echo (.+?)$". Variables may be
+// annotated with a patterns.catalogue class, e.g. "$dc_ip:IPV4", to capture
+// more precisely than the default catch-all; unannotated variables fall back
+// to patterns.Default, a non-greedy match that lets the surrounding literal
+// text (rather than whitespace) decide where the value ends - this is what
+// lets a multi-token value like "-p mypassword" round-trip through a single
+// $auth_flags variable. Returns the compiled regex along with the variable
+// name for each capture group, in the order the groups appear (a variable
+// used more than once in the template appears more than once in the slice).
+// A Command using the template package's richer syntax ("{{#if}}",
+// "${var:-default}", "{{#each}}", ...) derives its reverse-extraction regex
+// from the same parsed AST the template uses to render the command, via
+// Template.ReverseRegex, instead of the QuoteMeta-based approach below.
+func buildMatchPattern(cmd string) (*regexp.Regexp, []string) {
+	matchPatternMu.Lock()
+	if entry, ok := matchPatternCache[cmd]; ok {
+		matchPatternMu.Unlock()
+		return entry.re, entry.varNames
+	}
+	matchPatternMu.Unlock()
+
+	if template.HasTemplateSyntax(cmd) {
+		if tmpl, err := template.Parse(cmd); err == nil {
+			if re, varNames, err := tmpl.ReverseRegex(); err == nil {
+				matchPatternMu.Lock()
+				matchPatternCache[cmd] = matchPatternEntry{re: re, varNames: varNames}
+				matchPatternMu.Unlock()
+				return re, varNames
+			}
+		}
+	}
+
+	var varNames []string
 	escaped := regexp.QuoteMeta(cmd)
-	// After QuoteMeta: "$var" becomes "\$var" (quotes not escaped, $ is escaped)
-	// Replace "\$var" inside double quotes with "([^"]*)"
-	quotedVarPattern := regexp.MustCompile(`"\\\$(\w+)"`)
-	escaped = quotedVarPattern.ReplaceAllString(escaped, `"([^"]*)"`)
-	// Same for single quotes
-	singleQuotedVarPattern := regexp.MustCompile(`'\\\$(\w+)'`)
-	escaped = singleQuotedVarPattern.ReplaceAllString(escaped, `'([^']*)'`)
-	// Replace remaining unquoted $var with non-whitespace match
-	varPattern := regexp.MustCompile(`\\\$(\w+)`)
-	escaped = varPattern.ReplaceAllString(escaped, `(\S+)`)
-	pattern := `^\s*` + escaped + `\s*$`
-	re, err := regexp.Compile(pattern)
+	escaped = annotatedVarPattern.ReplaceAllStringFunc(escaped, func(tok string) string {
+		m := annotatedVarPattern.FindStringSubmatch(tok)
+		name, class := m[1], m[2]
+		if class == "" {
+			class = patterns.Default
+		}
+		fragment, err := patterns.Expand(class)
+		if err != nil {
+			fragment, _ = patterns.Expand(patterns.Default)
+		}
+		varNames = append(varNames, name)
+		return "(" + fragment + ")"
+	})
+
+	re, err := regexp.Compile(`^\s*` + escaped + `\s*$`)
 	if err != nil {
-		return regexp.MustCompile(`^$`)
+		re = regexp.MustCompile(`^$`)
+		varNames = nil
 	}
-	return re
+
+	matchPatternMu.Lock()
+	matchPatternCache[cmd] = matchPatternEntry{re: re, varNames: varNames}
+	matchPatternMu.Unlock()
+
+	return re, varNames
 }
 
 // prefillScopeFromMatch extracts variable values from the matched command
 func prefillScopeFromMatch(cheat *parser.Cheat, input string) {
 	input = strings.TrimSpace(input)
-	pattern := buildMatchPattern(cheat.Command)
-	if pattern == nil {
-		return
-	}
+	pattern, varNames := buildMatchPattern(cheat.Command)
 
 	matches := pattern.FindStringSubmatch(input)
 	if matches == nil {
@@ -1433,7 +2776,6 @@ func prefillScopeFromMatch(cheat *parser.Cheat, input string) {
 		cheat.Scope = make(map[string]string)
 	}
 
-	varNames := extractVarNames(cheat.Command)
 	for i, name := range varNames {
 		if i+1 < len(matches) {
 			cheat.Scope[name] = matches[i+1]
@@ -1441,19 +2783,120 @@ func prefillScopeFromMatch(cheat *parser.Cheat, input string) {
 	}
 }
 
-// extractVarNames returns variable names in order of appearance
-func extractVarNames(cmd string) []string {
-	varPattern := regexp.MustCompile(`\$(\w+)`)
-	matches := varPattern.FindAllStringSubmatch(cmd, -1)
-	var names []string
-	seen := make(map[string]bool)
-	for _, m := range matches {
-		if !seen[m[1]] {
-			names = append(names, m[1])
-			seen[m[1]] = true
+// extractEmbeddedVars matches actual against template (e.g. a VarDef.Literal
+// such as "-p $credential") the same way prefillScopeFromMatch matches a
+// whole command, writing any captured variables into scope. It's used to
+// pull values like $credential back out of a realized $auth_flags string.
+func extractEmbeddedVars(template, actual string, scope map[string]string) map[string]string {
+	if scope == nil {
+		scope = make(map[string]string)
+	}
+	pattern, varNames := buildMatchPattern(template)
+	matches := pattern.FindStringSubmatch(strings.TrimSpace(actual))
+	if matches == nil {
+		return scope
+	}
+	for i, name := range varNames {
+		if i+1 < len(matches) {
+			scope[name] = matches[i+1]
 		}
 	}
-	return names
+	return scope
+}
+
+// inferDependentVars works backward from values already present in
+// cheat.Scope (typically filled in by prefillScopeFromMatch from a --match
+// input) to infer the hidden variables that must have produced them. A
+// conditional VarDef like {Literal: "-p $credential", Condition: "$auth_method
+// == password"} means a scope value of auth_flags="-p mypassword" implies
+// both auth_method=password and credential=mypassword, even though neither
+// appeared verbatim in the matched command.
+func inferDependentVars(cheat *parser.Cheat, index *parser.CheatIndex) {
+	varDefs := make(map[string][]parser.VarDef)
+
+	var collectFromImports func(imports []string, seen map[string]bool)
+	collectFromImports = func(imports []string, seen map[string]bool) {
+		for _, name := range imports {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			module, ok := index.Modules[name]
+			if !ok {
+				continue
+			}
+			collectFromImports(module.Imports, seen)
+			for _, v := range module.Vars {
+				varDefs[v.Name] = append(varDefs[v.Name], v)
+			}
+		}
+	}
+	collectFromImports(cheat.Imports, make(map[string]bool))
+	for _, v := range cheat.Vars {
+		varDefs[v.Name] = append(varDefs[v.Name], v)
+	}
+
+	// A single pass may only pin down auth_method; a second pass can then use
+	// that newly-known value together with other vars, so loop until a full
+	// pass makes no further progress.
+	for progress := true; progress; {
+		progress = false
+		for name, defs := range varDefs {
+			actual, ok := cheat.Scope[name]
+			if !ok {
+				continue
+			}
+			for _, def := range defs {
+				if def.Literal == "" || def.Condition == "" {
+					continue
+				}
+				condVar, condValue, ok := parseEqualityCondition(def.Condition)
+				if !ok {
+					continue
+				}
+				if _, known := cheat.Scope[condVar]; known {
+					continue
+				}
+				pattern, _ := buildMatchPattern(def.Literal)
+				if !pattern.MatchString(strings.TrimSpace(actual)) {
+					continue
+				}
+				cheat.Scope[condVar] = condValue
+				for k, v := range extractEmbeddedVars(def.Literal, actual, make(map[string]string)) {
+					if _, known := cheat.Scope[k]; known {
+						continue
+					}
+					if transforms := varDefs[k]; len(transforms) > 0 && len(transforms[0].Transforms) > 0 {
+						if t, err := parser.ApplyTransforms(v, transforms[0].Transforms); err == nil {
+							v = t
+						}
+					}
+					cheat.Scope[k] = v
+				}
+				progress = true
+			}
+		}
+	}
+}
+
+// parseEqualityCondition extracts the "$var == value" shape used by
+// Condition strings, returning the bare variable name and the value it's
+// compared against. Only equality pins down a single inferred value, so
+// "!=" and truthy conditions are left alone.
+func parseEqualityCondition(condition string) (name, value string, ok bool) {
+	condition = strings.TrimSpace(condition)
+	if !strings.HasPrefix(condition, "$") {
+		return "", "", false
+	}
+	parts := strings.SplitN(condition, "==", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	name = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(parts[0]), "$"))
+	if name == "" {
+		return "", "", false
+	}
+	return name, strings.TrimSpace(parts[1]), true
 }
 
 // openFileInViewer opens the file in the configured editor or system default
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -10,26 +11,38 @@ import (
 	"github.com/gubarz/cheatmd/internal/config"
 	"github.com/gubarz/cheatmd/internal/executor"
 	"github.com/gubarz/cheatmd/internal/parser"
+	"github.com/gubarz/cheatmd/internal/secrets"
+	"github.com/gubarz/cheatmd/internal/shellparse"
 )
 
 // ============================================================================
 // Entry Point
 // ============================================================================
 
-// Run launches the Bubble Tea TUI interface
-func Run(index *parser.CheatIndex, exec *executor.Executor, initialQuery string) error {
-	return RunTUI(index, exec, initialQuery)
+// Run launches the Bubble Tea TUI interface. reload re-parses the cheat
+// root from scratch for the picker's "reload" action and --watch's
+// background fsnotify trigger; watchDir, if non-empty, is the root to
+// watch for changes and enables that trigger.
+func Run(index *parser.CheatIndex, exec *executor.Executor, initialQuery, matchCmd string, reload func() (*parser.CheatIndex, error), watchDir string) error {
+	return RunTUI(index, exec, initialQuery, matchCmd, reload, watchDir)
 }
 
 // ============================================================================
 // Variable Resolution
 // ============================================================================
 
+// fieldSep joins multiple fields into one cache key or similar composite
+// string; it's a unit separator rather than a printable character so it
+// can't collide with a field's own contents.
+const fieldSep = "\x1f"
+
 // varState tracks a variable and its resolved value
 type varState struct {
 	def          parser.VarDef   // The selected/active definition
 	variants     []parser.VarDef // All conditional variants (for if/fi blocks)
 	value        string
+	values       []string // Individual picks for a --multi variable; nil for single-valued vars
+	rawValue     string   // Pre-Transforms value, kept only to show users what a transform did
 	resolved     bool
 	prefill      string
 	skipAutoCont bool // True if user went back to this var - don't auto-continue
@@ -92,7 +105,8 @@ func resolveAllVariables(cheat *parser.Cheat, index *parser.CheatIndex, exec *ex
 		// Auto-continue if var is prefilled from environment and auto_continue is enabled
 		// But NOT if user went back to this var (skipAutoCont is set)
 		if autoContinue && vs.prefill != "" && !vs.skipAutoCont {
-			vs.value = vs.prefill
+			vs.rawValue = vs.prefill
+			vs.value = applyVarTransforms(vs.def, vs.prefill)
 			vs.resolved = true
 			currentIdx++
 			continue
@@ -100,7 +114,7 @@ func resolveAllVariables(cheat *parser.Cheat, index *parser.CheatIndex, exec *ex
 
 		header := buildProgressHeader(cheat.Command, vars, currentIdx)
 
-		value, goBack, err := resolveVar(vs.def, scope, exec, header, vs.prefill)
+		value, goBack, err := resolveVar(vs.def, scope, exec, header, vs.prefill, cheat.Command)
 		if err != nil {
 			return false, err
 		}
@@ -120,7 +134,17 @@ func resolveAllVariables(cheat *parser.Cheat, index *parser.CheatIndex, exec *ex
 			continue
 		}
 
+		// Secret-backed values are still an opaque SecretRef at this point -
+		// their Transforms travel with the ref and run just before exec (see
+		// executor.BuildFinalCommand), not here.
+		vs.rawValue = value
+		if !vs.def.IsSecret() {
+			value = applyVarTransforms(vs.def, value)
+		}
 		vs.value = value
+		if vs.def.IsMultiSelect() {
+			vs.values = strings.Split(vs.rawValue, parseSelectorOptions(vs.def.Args).separator)
+		}
 		vs.resolved = true
 		currentIdx++
 	}
@@ -153,7 +177,17 @@ func collectVariables(cheat *parser.Cheat, index *parser.CheatIndex) []varState
 			if module, ok := index.Modules[importName]; ok {
 				collectFromImports(module.Imports, seen)
 				for _, v := range module.Vars {
+					// Bare name, for the compatibility shadowing rules
+					// selectVariant already applies - unqualified "$region"
+					// still works as long as it's unambiguous. Alongside it,
+					// register the same def under its namespaced
+					// "module.var" key so a command can disambiguate with
+					// "$aws.region"/"$aws::region" when two imports both
+					// declare "$region" (see findCommandVars).
 					varDefs[v.Name] = append(varDefs[v.Name], v)
+					qualified := v
+					qualified.Name = importName + "." + v.Name
+					varDefs[qualified.Name] = append(varDefs[qualified.Name], qualified)
 				}
 			}
 		}
@@ -168,6 +202,23 @@ func collectVariables(cheat *parser.Cheat, index *parser.CheatIndex) []varState
 	// Find vars used in the command (quote-aware - only real variable refs)
 	usedVars := findCommandVars(cheat.Command, nil)
 
+	// A pipeline cheat's "$_" is never prompted for - it's populated at
+	// execution time from the previous step's captured stdout (see
+	// executePipelineSteps), not resolved here like every other var.
+	if cheat.Pipeline {
+		usedVars = excludeVar(usedVars, "_")
+	}
+
+	// "${name:-default}" references to an otherwise-undefined var seed its
+	// prefill, so e.g. "${region:-us-east-1}" behaves like a suggested value
+	// rather than an empty prompt.
+	cmdDefaults := make(map[string]string)
+	for _, ref := range shellparse.ReferencedVars(cheat.Command) {
+		if ref.DefaultExpr != "" {
+			cmdDefaults[ref.Name] = ref.DefaultExpr
+		}
+	}
+
 	// Find dependencies (transitive closure) - quote-aware for shell commands
 	// but check ALL vars in conditions (conditions are our DSL, not shell)
 	allNeeded := make(map[string]bool)
@@ -203,9 +254,9 @@ func collectVariables(cheat *parser.Cheat, index *parser.CheatIndex) []varState
 					}
 				}
 			}
-			// Conditions: use findAllVars (our DSL, not shell - all $vars are refs)
+			// Conditions: walk the condexpr AST for $var references
 			if def.Condition != "" {
-				deps := findAllVars(def.Condition)
+				deps := conditionVars(def.Condition)
 				for _, dep := range deps {
 					if !allNeeded[dep] {
 						queue = append(queue, dep)
@@ -242,7 +293,7 @@ func collectVariables(cheat *parser.Cheat, index *parser.CheatIndex) []varState
 				}
 			}
 			if def.Condition != "" {
-				for _, dep := range findAllVars(def.Condition) {
+				for _, dep := range conditionVars(def.Condition) {
 					addWithDeps(dep)
 				}
 			}
@@ -266,7 +317,8 @@ func collectVariables(cheat *parser.Cheat, index *parser.CheatIndex) []varState
 			})
 		} else {
 			vars = append(vars, varState{
-				def: parser.VarDef{Name: varName, Shell: ""},
+				def:     parser.VarDef{Name: varName, Shell: ""},
+				prefill: cmdDefaults[varName],
 			})
 		}
 	}
@@ -288,7 +340,7 @@ func selectVariant(variants []parser.VarDef, scope map[string]string) *parser.Va
 			}
 			continue
 		}
-		if evaluateCondition(v.Condition, scope) {
+		if Evaluate(v.Condition, scope) {
 			return v
 		}
 	}
@@ -296,37 +348,19 @@ func selectVariant(variants []parser.VarDef, scope map[string]string) *parser.Va
 	return defaultDef
 }
 
-// evaluateCondition evaluates a condition expression against the scope
-// Supports: $var == value, $var != value, $var (truthy check)
-func evaluateCondition(condition string, scope map[string]string) bool {
-	condition = strings.TrimSpace(condition)
-
-	// Substitute variables in condition
-	for name, value := range scope {
-		condition = strings.ReplaceAll(condition, "$"+name, value)
-	}
-
-	// Check for comparison operators
-	if strings.Contains(condition, "==") {
-		parts := strings.SplitN(condition, "==", 2)
-		if len(parts) == 2 {
-			left := strings.TrimSpace(parts[0])
-			right := strings.TrimSpace(parts[1])
-			return left == right
-		}
+// applyVarTransforms runs a resolved value through def's declared Transforms
+// (e.g. "$credential | base64d | trim"), warning to stderr and returning the
+// raw value unchanged if a transform fails rather than aborting resolution.
+func applyVarTransforms(def parser.VarDef, value string) string {
+	if len(def.Transforms) == 0 {
+		return value
 	}
-
-	if strings.Contains(condition, "!=") {
-		parts := strings.SplitN(condition, "!=", 2)
-		if len(parts) == 2 {
-			left := strings.TrimSpace(parts[0])
-			right := strings.TrimSpace(parts[1])
-			return left != right
-		}
+	transformed, err := parser.ApplyTransforms(value, def.Transforms)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: transform for $%s: %v\n", def.Name, err)
+		return value
 	}
-
-	// Truthy check - non-empty after substitution
-	return condition != ""
+	return transformed
 }
 
 // buildScope creates a scope map from resolved variables
@@ -349,7 +383,11 @@ func buildProgressHeader(cmd string, vars []varState, currentIdx int) string {
 	progressCmd := cmd
 	for i, vs := range vars {
 		if vs.resolved {
-			progressCmd = replaceVar(progressCmd, vs.def.Name, styles.Header.Render(vs.value))
+			if len(vs.values) > 0 {
+				progressCmd = replaceVarList(progressCmd, vs.def.Name, vs.values)
+			} else {
+				progressCmd = replaceVar(progressCmd, vs.def.Name, styles.Header.Render(vs.value))
+			}
 		} else if i == currentIdx {
 			progressCmd = replaceVar(progressCmd, vs.def.Name, styles.Cursor.Render("$"+vs.def.Name))
 		}
@@ -365,6 +403,10 @@ func buildProgressHeader(cmd string, vars []varState, currentIdx int) string {
 			sb.WriteString(styles.Dim.Render("$" + vs.def.Name))
 			sb.WriteString(" = ")
 			sb.WriteString(styles.Header.Render(vs.value))
+			if len(vs.def.Transforms) > 0 && vs.rawValue != vs.value {
+				sb.WriteString(" ")
+				sb.WriteString(styles.Dim.Render(fmt.Sprintf("(raw: %s)", vs.rawValue)))
+			}
 		} else if i == currentIdx {
 			sb.WriteString(styles.Cursor.Render("▶ $" + vs.def.Name))
 		} else {
@@ -381,8 +423,38 @@ func replaceVar(cmd, varName, replacement string) string {
 	return re.ReplaceAllLiteralString(cmd, replacement)
 }
 
-// resolveVar resolves a single variable using the TUI
-func resolveVar(v parser.VarDef, scope map[string]string, exec *executor.Executor, header, prefill string) (string, bool, error) {
+// quotedVarRef matches a $varname reference wrapped in double quotes, e.g.
+// "$pods" - the one place a shell treats a bare "$var" as a single word
+// rather than letting it split on whitespace.
+func quotedVarRef(varName string) *regexp.Regexp {
+	return regexp.MustCompile(`"\$` + regexp.QuoteMeta(varName) + `\b"`)
+}
+
+// replaceVarList replaces $varname in cmd with a --multi variable's
+// individual picks. Inside double quotes ("$pods") each pick is rendered as
+// its own quoted, styled word so the preview matches how a shell would
+// expand them; everywhere else the picks are shown space-joined.
+func replaceVarList(cmd, varName string, values []string) string {
+	rendered := make([]string, len(values))
+	for i, v := range values {
+		rendered[i] = styles.Header.Render(v)
+	}
+
+	if re := quotedVarRef(varName); re.MatchString(cmd) {
+		quoted := make([]string, len(values))
+		for i, v := range rendered {
+			quoted[i] = `"` + v + `"`
+		}
+		return re.ReplaceAllLiteralString(cmd, strings.Join(quoted, " "))
+	}
+
+	return replaceVar(cmd, varName, strings.Join(rendered, " "))
+}
+
+// resolveVar resolves a single variable using the TUI. source is the cheat's
+// raw, unsubstituted command template, threaded through to the var prompt's
+// preview pane.
+func resolveVar(v parser.VarDef, scope map[string]string, exec *executor.Executor, header, prefill, source string) (string, bool, error) {
 	customHeader := extractCustomHeader(v.Args)
 
 	// Debug: show what we're working with
@@ -394,12 +466,16 @@ func resolveVar(v parser.VarDef, scope map[string]string, exec *executor.Executo
 		fmt.Fprintf(os.Stderr, "[DEBUG]   customHeader: %q\n", customHeader)
 	}
 
+	// Secret-backed variable: offer choices from the backend's List, but
+	// never resolve the actual value here - store an opaque reference and
+	// let the command assembler expand it just before exec.
+	if v.IsSecret() {
+		return resolveSecretVar(v, header, customHeader, prefill, source)
+	}
+
 	// Handle literal values (no shell execution, just variable substitution)
 	if v.Literal != "" {
-		result := v.Literal
-		for name, value := range scope {
-			result = strings.ReplaceAll(result, "$"+name, value)
-		}
+		result := shellparse.SubstituteVars(v.Literal, scope, false)
 		if os.Getenv("CHEATMD_DEBUG") != "" {
 			fmt.Fprintf(os.Stderr, "[DEBUG]   Literal result: %q\n", result)
 		}
@@ -408,14 +484,12 @@ func resolveVar(v parser.VarDef, scope map[string]string, exec *executor.Executo
 
 	if strings.TrimSpace(v.Shell) == "" {
 		// No shell command defined - just prompt
-		return PromptWithTUI(v.Name, header, customHeader, prefill)
+		return PromptWithTUIOptions(v.Name, header, customHeader, prefill, "", SelectOptions{Source: source})
 	}
 
-	// Substitute scope into shell command
-	shellCmd := v.Shell
-	for name, value := range scope {
-		shellCmd = strings.ReplaceAll(shellCmd, "$"+name, value)
-	}
+	// Substitute scope into shell command, shell-quoting each value so one
+	// containing spaces or quotes still survives as a single word.
+	shellCmd := shellparse.SubstituteVars(v.Shell, scope, true)
 
 	if os.Getenv("CHEATMD_DEBUG") != "" {
 		fmt.Fprintf(os.Stderr, "[DEBUG]   Running: %s\n", shellCmd)
@@ -427,7 +501,7 @@ func resolveVar(v parser.VarDef, scope map[string]string, exec *executor.Executo
 			fmt.Fprintf(os.Stderr, "[DEBUG]   Error: %v\n", err)
 		}
 		// Command failed - show prompt with customHeader
-		return PromptWithTUI(v.Name, header, customHeader, prefill)
+		return PromptWithTUIOptions(v.Name, header, customHeader, prefill, "", SelectOptions{Source: source})
 	}
 
 	lines := splitLines(output)
@@ -437,34 +511,81 @@ func resolveVar(v parser.VarDef, scope map[string]string, exec *executor.Executo
 
 	// Parse selector options
 	opts := parseSelectorOptions(v.Args)
+	opts.source = source
+
+	if opts.multi && len(lines) > 0 {
+		return MultiSelectWithTUI(v.Name, lines, header, customHeader, opts)
+	}
 
 	switch len(lines) {
 	case 0:
 		// No output - show prompt
-		return PromptWithTUI(v.Name, header, customHeader, prefill)
+		return PromptWithTUIOptions(v.Name, header, customHeader, prefill, "", SelectOptions{Source: source})
 	case 1:
 		// Single result - prefill the prompt with it so user can accept or modify
 		if prefill == "" {
 			prefill = applyMapTransform(lines[0], opts)
 		}
-		return PromptWithTUI(v.Name, header, customHeader, prefill)
+		return PromptWithTUIOptions(v.Name, header, customHeader, prefill, "", SelectOptions{Source: source})
 	default:
 		// Multiple results - show selection with options
-		return SelectWithTUIOptions(v.Name, lines, header, customHeader, prefill, opts)
+		return resolveSelector(v.Name, prefill, "").Select(lines, opts, header)
 	}
 }
 
+// resolveSecretVar prompts for a secret-backed variable. The ref (e.g.
+// "keyring:service") is parsed for its scheme, List offers known names under
+// it as choices, and the chosen reference - never the secret value itself -
+// is returned wrapped as an opaque SecretRef for storage in cheat.Scope.
+func resolveSecretVar(v parser.VarDef, header, customHeader, prefill, source string) (string, bool, error) {
+	r, err := secrets.ParseRef(v.Source)
+	if err != nil {
+		return "", false, err
+	}
+
+	choices, _ := secrets.List(context.Background(), r.Scheme, r.Rest)
+
+	var value string
+	var goBack bool
+	if len(choices) > 1 {
+		value, goBack, err = resolveSelector(v.Name, prefill, "").Select(choices, selectorOptions{header: customHeader, source: source}, header)
+	} else {
+		if len(choices) == 1 && prefill == "" {
+			prefill = choices[0]
+		}
+		value, goBack, err = PromptWithTUIOptions(v.Name, header, customHeader, prefill, "", SelectOptions{Source: source})
+	}
+	if err != nil || goBack || value == "__EXIT__" {
+		return value, goBack, err
+	}
+
+	ref := value
+	if !strings.Contains(ref, ":") {
+		// User accepted a bare name offered by List - re-attach the scheme.
+		ref = r.Scheme + ":" + ref
+	}
+	return executor.SecretRef(ref, v.Transforms), false, nil
+}
+
 // selectorOptions holds parsed selector arguments
 type selectorOptions struct {
-	header    string
-	delimiter string
-	column    int    // 1-indexed, 0 means all columns
-	mapCmd    string // command to transform selected value
+	header     string
+	delimiter  string
+	column     int    // 1-indexed, 0 means all columns
+	mapCmd     string // command to transform selected value
+	multi      bool   // --multi: resolve to several picks instead of one
+	separator  string // joins --multi picks into vs.value, default " "
+	minSelect  int    // --min-select: minimum picks required (--multi only)
+	maxSelect  int    // --max-select: 0 means unbounded (--multi only)
+	previewCmd string // --preview: shell command rendering a third preview pane
+	algorithm  string // --algorithm: "substring", "exact", "fuzzy-v1", or "fuzzy-v2"
+	source     string // raw command template the var belongs to, for the preview pane
+	language   string // highlightSource language for source, "" defaults to shell
 }
 
 // parseSelectorOptions parses all selector arguments
 func parseSelectorOptions(selectorArgs string) selectorOptions {
-	opts := selectorOptions{column: 0} // default: show all
+	opts := selectorOptions{column: 0, separator: " "} // default: show all columns, space-joined picks
 	if selectorArgs == "" {
 		return opts
 	}
@@ -492,6 +613,33 @@ func parseSelectorOptions(selectorArgs string) selectorOptions {
 				opts.mapCmd = args[i+1]
 				i++
 			}
+		case "--multi":
+			opts.multi = true
+		case "--separator":
+			if i+1 < len(args) {
+				opts.separator = args[i+1]
+				i++
+			}
+		case "--min-select":
+			if i+1 < len(args) {
+				fmt.Sscanf(args[i+1], "%d", &opts.minSelect)
+				i++
+			}
+		case "--max-select":
+			if i+1 < len(args) {
+				fmt.Sscanf(args[i+1], "%d", &opts.maxSelect)
+				i++
+			}
+		case "--preview":
+			if i+1 < len(args) {
+				opts.previewCmd = args[i+1]
+				i++
+			}
+		case "--algorithm":
+			if i+1 < len(args) {
+				opts.algorithm = args[i+1]
+				i++
+			}
 		}
 	}
 	return opts
@@ -515,53 +663,68 @@ func extractCustomHeader(selectorArgs string) string {
 // Output Handling
 // ============================================================================
 
-// executeOutput handles the final command based on output mode
-func executeOutput(command string, exec *executor.Executor) error {
-	// Apply hooks
-	finalCmd := command
-	if preHook := config.GetPreHook(); preHook != "" {
-		finalCmd = preHook + finalCmd
-	}
-	if postHook := config.GetPostHook(); postHook != "" {
-		finalCmd = finalCmd + postHook
-	}
-
-	switch config.GetOutput() {
-	case "exec":
-		fmt.Fprintf(os.Stderr, "\033[1;32m▶ Executing:\033[0m %s\n", finalCmd)
-		return exec.Execute(finalCmd)
-	case "copy":
-		if err := copyToClipboard(finalCmd); err != nil {
+// executeOutput handles the final command based on output mode. Each branch
+// is dispatched through exec.RunWithHooks so the configured (or per-cheat)
+// pre_hook/post_hook always brackets it, regardless of mode.
+func executeOutput(cheat *parser.Cheat, command string, exec *executor.Executor) error {
+	if cheat.Pipeline {
+		return executePipelineSteps(cheat, command, exec)
+	}
+
+	mode := executor.OutputMode(config.GetOutput())
+	switch mode {
+	case executor.OutputExec:
+		fmt.Fprintf(os.Stderr, "\033[1;32m▶ Executing:\033[0m %s\n", command)
+		return exec.Execute(cheat, command)
+	case executor.OutputCopy:
+		err := exec.RunWithHooks(cheat, command, mode, func() error {
+			return exec.CopyToClipboard(command)
+		})
+		if err != nil {
 			return err
 		}
 		fmt.Fprintf(os.Stderr, "\033[1;33m✓ Copied to clipboard\033[0m\n")
 		return nil
 	default: // print
-		fmt.Print(finalCmd)
-		return nil
+		return exec.RunWithHooks(cheat, command, executor.OutputPrint, func() error {
+			// A "secret" variable (VarDef.Masked) is echoed as a placeholder
+			// here even though the real value was already used for copy/exec.
+			fmt.Print(exec.BuildDisplayCommand(cheat))
+			return nil
+		})
 	}
 }
 
-// copyToClipboard copies text to the system clipboard
-func copyToClipboard(text string) error {
-	var copyCmd *exec.Cmd
-
-	switch {
-	case commandExists("wl-copy"):
-		copyCmd = exec.Command("wl-copy")
-	case commandExists("xclip"):
-		copyCmd = exec.Command("xclip", "-selection", "clipboard")
-	case commandExists("xsel"):
-		copyCmd = exec.Command("xsel", "--clipboard", "--input")
-	case commandExists("pbcopy"):
-		copyCmd = exec.Command("pbcopy")
-	default:
-		fmt.Print(text)
+// executePipelineSteps runs a parser.Cheat.Pipeline's steps (one shell
+// command per line of the already-substituted command) in order, streaming
+// each to the terminal as it runs. Each step's "$_" is replaced by the
+// previous step's captured stdout (empty for the first step) - "$_" is never resolved as an
+// ordinary var (see collectVariables) precisely so it can carry the real
+// previous-step output instead of a value guessed ahead of execution.
+//
+// Unlike the single-shot modes above, a pipeline cheat ignores
+// config.GetOutput(): there's no useful "print" or "copy" form of a command
+// chain, only watching it run step by step.
+func executePipelineSteps(cheat *parser.Cheat, command string, exec *executor.Executor) error {
+	steps := splitLines(command)
+	return exec.RunWithHooks(cheat, command, executor.OutputPipeline, func() error {
+		var prev string
+		for i, step := range steps {
+			// prev is arbitrary previous-step stdout, not trusted shell
+			// source - substitute it through the real shell-syntax parser
+			// with shellQuote so it lands as one literal argument instead of
+			// splicing whatever whitespace/metacharacters it contains into
+			// this step's command.
+			step = shellparse.SubstituteVars(step, map[string]string{"_": prev}, true)
+			fmt.Fprintf(os.Stderr, "\033[1;32m▶ step %d/%d:\033[0m %s\n", i+1, len(steps), step)
+			out, err := exec.RunPipelineStep(step)
+			if err != nil {
+				return fmt.Errorf("step %d: %w", i+1, err)
+			}
+			prev = out
+		}
 		return nil
-	}
-
-	copyCmd.Stdin = strings.NewReader(text)
-	return copyCmd.Run()
+	})
 }
 
 // commandExists checks if a command is available in PATH
@@ -574,90 +737,81 @@ func commandExists(name string) bool {
 // String Utilities
 // ============================================================================
 
-// findAllVars finds ALL $varname patterns in a command, ignoring quoting.
-// Used for collecting all variables that might need resolution.
+// findAllVars finds the $varname/${varname} references in cmd. Used for our
+// DSL text (Literal values). It goes through shellparse.ReferencedVars, so
+// shell quoting applies: a reference inside single quotes is not counted.
 func findAllVars(cmd string) []string {
 	var vars []string
-	seen := make(map[string]bool)
-
-	for i := 0; i < len(cmd); i++ {
-		if cmd[i] != '$' || i+1 >= len(cmd) {
-			continue
-		}
-		// Skip escaped $
-		if i > 0 && cmd[i-1] == '\\' {
-			continue
-		}
-
-		j := i + 1
-		for j < len(cmd) && isVarChar(cmd[j], j == i+1) {
-			j++
-		}
-
-		if j > i+1 {
-			varName := cmd[i+1 : j]
-			if !seen[varName] {
-				vars = append(vars, varName)
-				seen[varName] = true
-			}
-		}
-		i = j - 1
+	for _, ref := range shellparse.ReferencedVars(cmd) {
+		vars = append(vars, ref.Name)
 	}
-
 	return vars
 }
 
-// findCommandVars finds $varname patterns that will be expanded by shell.
-// Ignores variables inside single quotes (literal strings in shell).
-// Used for determining execution order (what vars a command needs before running).
+// qualifiedVarRef matches cheatmd's own "$module.var" / "$module::var"
+// namespaced reference syntax. The shell has no concept of this - bash
+// terminates a bare $name at the dot, so shellparse.ReferencedVars sees only
+// "module" and leaves ".var"/"::var" as surrounding literal text. This
+// regex recovers the qualified name the cheat author actually meant;
+// findCommandVars only trusts a match once it confirms shellparse also saw
+// the bare module name as a real (unquoted) reference at all.
+var qualifiedVarRef = regexp.MustCompile(`\$([A-Za-z_]\w*)(?:\.|::)([A-Za-z_]\w*)`)
+
+// findCommandVars finds $varname/${varname} references that will be expanded
+// by the shell, via a real shell-syntax parse rather than hand-rolled quote
+// tracking - so references inside single quotes (never expanded) are
+// correctly excluded, braces and ${var:-default} are understood, and a
+// reference inside "$(...)" is parsed in its own quoting context instead of
+// inheriting the outer one. Used for determining execution order (what vars a
+// command needs before running).
+//
+// A namespaced reference like "$aws.region" is promoted to the qualified
+// name "aws.region" so collectVariables resolves it against that specific
+// imported module's var rather than whichever "region" wins the bare-name
+// shadowing rules.
 func findCommandVars(cmd string, scope map[string]string) []string {
-	var vars []string
-	seen := make(map[string]bool)
-	inSingleQuote := false
-	inDoubleQuote := false
-
-	for i := 0; i < len(cmd); i++ {
-		c := cmd[i]
+	refs := shellparse.ReferencedVars(cmd)
+	refSeen := make(map[string]bool, len(refs))
+	for _, ref := range refs {
+		refSeen[ref.Name] = true
+	}
 
-		// Track quote state (handle escapes)
-		if c == '\\' && i+1 < len(cmd) {
-			i++ // Skip escaped char
-			continue
-		}
-		if c == '\'' && !inDoubleQuote {
-			inSingleQuote = !inSingleQuote
-			continue
-		}
-		if c == '"' && !inSingleQuote {
-			inDoubleQuote = !inDoubleQuote
-			continue
+	qualifiedOf := make(map[string]string)
+	for _, m := range qualifiedVarRef.FindAllStringSubmatch(cmd, -1) {
+		if !refSeen[m[1]] {
+			continue // e.g. sitting inside single quotes - shell never expands it
 		}
+		qualifiedOf[m[1]] = m[1] + "." + m[2]
+	}
 
-		// Skip variables inside single quotes - they're literal
-		if inSingleQuote {
-			continue
+	var vars []string
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		name := ref.Name
+		if qualified, ok := qualifiedOf[name]; ok {
+			name = qualified
 		}
-
-		if c != '$' || i+1 >= len(cmd) {
+		if seen[name] {
 			continue
 		}
-
-		j := i + 1
-		for j < len(cmd) && isVarChar(cmd[j], j == i+1) {
-			j++
+		if scope != nil && scope[name] != "" {
+			continue
 		}
+		seen[name] = true
+		vars = append(vars, name)
+	}
+	return vars
+}
 
-		if j > i+1 {
-			varName := cmd[i+1 : j]
-			if !seen[varName] && (scope == nil || scope[varName] == "") {
-				vars = append(vars, varName)
-				seen[varName] = true
-			}
+// excludeVar returns vars with name removed, preserving order.
+func excludeVar(vars []string, name string) []string {
+	var out []string
+	for _, v := range vars {
+		if v != name {
+			out = append(out, v)
 		}
-		i = j - 1
 	}
-
-	return vars
+	return out
 }
 
 // splitLines splits text into non-empty trimmed lines
@@ -671,14 +825,6 @@ func splitLines(s string) []string {
 	return lines
 }
 
-// isVarChar returns true if c is valid in a variable name
-func isVarChar(c byte, first bool) bool {
-	if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' {
-		return true
-	}
-	return !first && c >= '0' && c <= '9'
-}
-
 // parseShellArgs parses a string into arguments, respecting quotes
 func parseShellArgs(s string) []string {
 	var args []string
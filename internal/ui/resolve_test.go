@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindCommandVarsQualified(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  string
+		want []string
+	}{
+		{
+			name: "dotted namespaced ref",
+			cmd:  "echo $aws.region",
+			want: []string{"aws.region"},
+		},
+		{
+			name: "double-colon namespaced ref",
+			cmd:  "kubectl get pod $pod -n $k8s::namespace",
+			want: []string{"pod", "k8s.namespace"},
+		},
+		{
+			name: "bare ref unaffected",
+			cmd:  "echo $region",
+			want: []string{"region"},
+		},
+		{
+			name: "single-quoted dotted text is not a reference",
+			cmd:  `echo '$aws.region'`,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findCommandVars(tt.cmd, nil)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("findCommandVars(%q) = %v, want %v", tt.cmd, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExcludeVar(t *testing.T) {
+	tests := []struct {
+		name string
+		vars []string
+		drop string
+		want []string
+	}{
+		{name: "drops match, keeps order", vars: []string{"a", "_", "b"}, drop: "_", want: []string{"a", "b"}},
+		{name: "no match is a no-op", vars: []string{"a", "b"}, drop: "_", want: []string{"a", "b"}},
+		{name: "empty input", vars: nil, drop: "_", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := excludeVar(tt.vars, tt.drop)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("excludeVar(%v, %q) = %v, want %v", tt.vars, tt.drop, got, tt.want)
+			}
+		})
+	}
+}
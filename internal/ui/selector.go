@@ -0,0 +1,189 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/gubarz/cheatmd/internal/config"
+)
+
+// ============================================================================
+// Pluggable Selector Backend
+// ============================================================================
+
+// Selector picks one value out of a candidate list. The built-in Bubble Tea
+// picker is the zero-dependency default; external backends (fzf, skim, gum)
+// let power users reuse a fuzzy-finder config they already have instead of
+// cheatmd's own.
+type Selector interface {
+	// Select presents items to the user and returns the chosen value. goBack
+	// reports that the user backed out (esc) rather than picking a value;
+	// "__EXIT__" mirrors SelectWithTUIOptions's full-exit sentinel.
+	Select(items []string, opts selectorOptions, header string) (string, bool, error)
+}
+
+// builtinSelector adapts the existing Bubble Tea picker to Selector, carrying
+// the per-variable context (name, prefill, source file) that external
+// backends have no use for.
+type builtinSelector struct {
+	varName  string
+	prefill  string
+	filePath string
+}
+
+func (s builtinSelector) Select(items []string, opts selectorOptions, header string) (string, bool, error) {
+	return SelectWithTUIOptions(s.varName, items, header, opts.header, s.prefill, s.filePath, opts)
+}
+
+// externalBinary shells out to an external fuzzy-finder, feeding candidates
+// on stdin and reading the chosen line back from stdout. fzf, sk, and gum all
+// manage their own terminal UI via /dev/tty, so piping stdin/capturing stdout
+// is all that's needed here.
+type externalBinary struct {
+	bin       string
+	buildArgs func(opts selectorOptions, header string) []string
+}
+
+func (e externalBinary) Select(items []string, opts selectorOptions, header string) (string, bool, error) {
+	cmd := exec.Command(e.bin, e.buildArgs(opts, header)...)
+	cmd.Stdin = strings.NewReader(strings.Join(items, "\n"))
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			// fzf/sk/gum all exit non-zero on esc/ctrl-c with no output -
+			// treat that as "go back" rather than a hard error.
+			return "", true, nil
+		}
+		return "", false, err
+	}
+
+	selected := strings.TrimSpace(string(out))
+	if selected == "" {
+		return "", true, nil
+	}
+	return selected, false, nil
+}
+
+// selectorBackends maps a CHEATMD_SELECTOR value to its binary and flag
+// builder. "sk" (skim) is fzf-compatible, so it reuses fzfArgs.
+var selectorBackends = map[string]externalBinary{
+	"fzf": {bin: "fzf", buildArgs: fzfArgs},
+	"sk":  {bin: "sk", buildArgs: fzfArgs},
+	"gum": {bin: "gum", buildArgs: gumArgs},
+}
+
+// resolveSelector picks the Selector backend named by config.GetSelector
+// (env CHEATMD_SELECTOR or the "selector" config key), falling back to the
+// built-in picker if none is configured, the name is unknown, or the binary
+// isn't on PATH.
+func resolveSelector(varName, prefill, filePath string) Selector {
+	builtin := builtinSelector{varName: varName, prefill: prefill, filePath: filePath}
+
+	name := strings.TrimSpace(config.GetSelector())
+	if name == "" || name == "builtin" {
+		return builtin
+	}
+
+	backend, ok := selectorBackends[name]
+	if !ok || !commandExists(backend.bin) {
+		return builtin
+	}
+	return backend
+}
+
+// tryExternalSelector offers options through the external backend named by
+// config.GetSelector (fzf/sk/gum), mirroring resolveSelector's fallback
+// rules. handled is false when no external backend is configured, its
+// binary isn't on PATH, or it errored - the caller should fall back to the
+// embedded picker in all of those cases rather than surface an error.
+//
+// Multi-select stays on the embedded picker: fzf/sk/gum each have their own
+// multi-pick conventions (--multi, Tab) that don't map cleanly onto
+// selectOpts.MultiMax/Joiner, so SelectOptions.Multi requests never reach
+// here.
+func tryExternalSelector(options []string, selectOpts SelectOptions, header string) (value string, goBack bool, handled bool) {
+	if selectOpts.Multi {
+		return "", false, false
+	}
+	name := strings.TrimSpace(config.GetSelector())
+	if name == "" || name == "builtin" {
+		return "", false, false
+	}
+
+	backend, ok := selectorBackends[name]
+	if !ok || !commandExists(backend.bin) {
+		return "", false, false
+	}
+
+	opts := selectorOptions{
+		delimiter: selectOpts.Delimiter,
+		column:    selectOpts.Column,
+		mapCmd:    selectOpts.MapCmd,
+	}
+	value, goBack, err := backend.Select(options, opts, header)
+	if err != nil {
+		return "", false, false
+	}
+	if value != "" && !goBack {
+		value = applyMapTransform(value, opts)
+	}
+	return value, goBack, true
+}
+
+// fzfArgs builds the flags fzf (and its skim-compatible alias sk) understand
+// for the selectorOptions vocabulary cheat authors already use.
+func fzfArgs(opts selectorOptions, header string) []string {
+	args := []string{"--preview", buildPreviewCommand(header)}
+	if opts.header != "" {
+		args = append(args, "--header", opts.header)
+	}
+	if opts.delimiter != "" {
+		args = append(args, "--delimiter", opts.delimiter)
+		if opts.column > 0 {
+			args = append(args, "--nth", fmt.Sprintf("%d", opts.column))
+		}
+	}
+	return args
+}
+
+// gumArgs builds the flags "gum choose" understands. gum has no --delimiter,
+// --nth, or --preview equivalent, so those selectorOptions are left to the
+// builtin picker (or fzf/sk) when column-restricted matching or a live
+// preview matters.
+func gumArgs(opts selectorOptions, header string) []string {
+	args := []string{"choose"}
+	if opts.header != "" {
+		args = append(args, "--header", opts.header)
+	}
+	return args
+}
+
+// currentVarToken finds the "$name" token the selector is resolving, which
+// buildProgressHeader leaves un-substituted (just styled) on the command
+// line it renders as the header's first line.
+var currentVarToken = regexp.MustCompile(`\$\w+`)
+
+// buildPreviewCommand renders a shell one-liner for --preview: the static
+// progress header plus the command line with the in-progress variable
+// substituted for fzf's "{}" placeholder, so the final command updates live
+// as the user moves between candidates.
+func buildPreviewCommand(header string) string {
+	cmdLine := strings.SplitN(header, "\n", 2)[0]
+
+	substituted := cmdLine
+	if token := currentVarToken.FindString(cmdLine); token != "" {
+		substituted = strings.Replace(cmdLine, token, "{}", 1)
+	}
+
+	return fmt.Sprintf("printf '%%s\\n\\n%%s\\n' %s %s", shellQuote(header), shellQuote(substituted))
+}
+
+// shellQuote wraps s in single quotes for safe use inside a shell -c string.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -27,7 +27,7 @@ type StyleManager struct {
 	Divider lipgloss.Style
 
 	// Colors for direct access
-	SelectedBg lipgloss.Color
+	SelectedBg lipgloss.TerminalColor
 }
 
 // DefaultStyles returns a StyleManager with default styles
@@ -50,17 +50,30 @@ func DefaultStyles() *StyleManager {
 	}
 }
 
-// LoadFromConfig updates styles based on configuration
+// LoadFromConfig updates styles based on configuration, resolving the
+// configured theme (config.GetTheme()) through LoadTheme.
 func (s *StyleManager) LoadFromConfig() {
-	// Get colors from config
-	headerColor := parseANSIColor(config.GetColorHeader())
-	descColor := parseANSIColor(config.GetColorDesc())
-	cmdColor := parseANSIColor(config.GetColorCommand())
-	pathColor := parseANSIColor(config.GetColorPath())
-	borderColor := lipgloss.Color(config.GetColorBorder())
-	cursorColor := lipgloss.Color(config.GetColorCursor())
-	selectedBg := lipgloss.Color(config.GetColorSelected())
-	dimColor := lipgloss.Color(config.GetColorDim())
+	s.ApplyTheme(LoadTheme(config.GetTheme()))
+}
+
+// ApplyTheme rebuilds every style from t's colors, resolving each through
+// resolveColor against the detected (or --force-color-forced) color
+// profile: hex/rgb/named colors are downsampled to xterm-256 or ANSI-16 on
+// terminals that can't render them directly, and everything renders as
+// plain text under ColorProfileNone. Used by LoadFromConfig for the
+// configured theme, and directly by the `cheatmd themes` picker to
+// live-preview a theme before it's saved.
+func (s *StyleManager) ApplyTheme(t Theme) {
+	profile := DetectColorProfile()
+
+	headerColor := resolveColor(t.Header, profile)
+	descColor := resolveColor(t.Desc, profile)
+	cmdColor := resolveColor(t.Command, profile)
+	pathColor := resolveColor(t.Path, profile)
+	borderColor := resolveColor(t.Border, profile)
+	cursorColor := resolveColor(t.Cursor, profile)
+	selectedBg := resolveColor(t.Selected, profile)
+	dimColor := resolveColor(t.Dim, profile)
 
 	// List view styles
 	s.Header = lipgloss.NewStyle().Foreground(headerColor)
@@ -88,20 +101,6 @@ func (s *StyleManager) WithSelection(style lipgloss.Style) lipgloss.Style {
 	return style.Background(s.SelectedBg)
 }
 
-// parseANSIColor converts ANSI color codes to lipgloss colors
-func parseANSIColor(code string) lipgloss.Color {
-	ansiToLipgloss := map[string]string{
-		"30": "0", "31": "1", "32": "2", "33": "3",
-		"34": "4", "35": "5", "36": "6", "37": "7",
-		"90": "8", "91": "9", "92": "10", "93": "11",
-		"94": "12", "95": "13", "96": "14", "97": "15",
-	}
-	if mapped, ok := ansiToLipgloss[code]; ok {
-		return lipgloss.Color(mapped)
-	}
-	return lipgloss.Color(code)
-}
-
 // Global style manager instance
 var styles = DefaultStyles()
 
@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gubarz/cheatmd/internal/config"
+)
+
+// Theme holds every color StyleManager paints with. Values are whatever
+// resolveColor accepts: an ANSI code, a "0"-"255" index, a "#rrggbb" hex, an
+// "rgb(r,g,b)" triple, or a named web color.
+type Theme struct {
+	Name     string
+	Header   string
+	Command  string
+	Desc     string
+	Path     string
+	Border   string
+	Cursor   string
+	Selected string
+	Dim      string
+}
+
+// builtinThemes ship with cheatmd so `cheatmd themes` has choices even
+// without a ~/.config/cheatmd/themes directory.
+var builtinThemes = map[string]Theme{
+	"dracula": {
+		Header: "#bd93f9", Command: "#50fa7b", Desc: "#6272a4", Path: "#f1fa8c",
+		Border: "#44475a", Cursor: "#ff79c6", Selected: "#44475a", Dim: "#6272a4",
+	},
+	"gruvbox": {
+		Header: "#fabd2f", Command: "#b8bb26", Desc: "#928374", Path: "#fe8019",
+		Border: "#504945", Cursor: "#d3869b", Selected: "#3c3836", Dim: "#928374",
+	},
+	"solarized-dark": {
+		Header: "#268bd2", Command: "#859900", Desc: "#657b83", Path: "#b58900",
+		Border: "#073642", Cursor: "#d33682", Selected: "#073642", Dim: "#586e75",
+	},
+	"tokyonight": {
+		Header: "#7aa2f7", Command: "#9ece6a", Desc: "#565f89", Path: "#e0af68",
+		Border: "#3b4261", Cursor: "#bb9af7", Selected: "#283457", Dim: "#565f89",
+	},
+}
+
+// themeFromColors builds the "custom" theme out of the flat color_* config
+// keys, so a cheatmd.yaml written before the theme subsystem existed (no
+// "theme:" key, or "theme: custom") keeps rendering exactly as before.
+func themeFromColors(c config.ColorConfig) Theme {
+	return Theme{
+		Name: "custom", Header: c.Header, Command: c.Command, Desc: c.Desc,
+		Path: c.Path, Border: c.Border, Cursor: c.Cursor, Selected: c.Selected, Dim: c.Dim,
+	}
+}
+
+// LoadTheme resolves name to a Theme: "" or "custom" returns the flat
+// color_* keys from config, a name matching a built-in returns that
+// built-in, and anything else is looked up as "<name>.toml" under
+// config.ThemesDir(). A name that can't be resolved falls back to the
+// custom/default theme with a warning, so a typo or a deleted theme file
+// never blocks the TUI from starting.
+func LoadTheme(name string) Theme {
+	if name == "" || name == "custom" {
+		return themeFromColors(config.GetColors())
+	}
+	if t, ok := builtinThemes[name]; ok {
+		t.Name = name
+		return t
+	}
+
+	t, err := loadThemeFile(filepath.Join(config.ThemesDir(), name+".toml"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: theme %q: %v, falling back to default\n", name, err)
+		return themeFromColors(config.GetColors())
+	}
+	t.Name = name
+	return t
+}
+
+// availableThemes returns the sorted built-in theme names followed by any
+// user-defined themes under config.ThemesDir(), skipping a user file whose
+// name collides with a built-in (the built-in wins, matching LoadTheme).
+func availableThemes() []string {
+	names := make([]string, 0, len(builtinThemes)+1)
+	names = append(names, "custom")
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+
+	entries, err := os.ReadDir(config.ThemesDir())
+	if err != nil {
+		return names
+	}
+
+	var user []string
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".toml" {
+			continue
+		}
+		name := strings.TrimSuffix(e.Name(), ".toml")
+		if _, builtin := builtinThemes[name]; builtin {
+			continue
+		}
+		user = append(user, name)
+	}
+	sort.Strings(user)
+	return append(names, user...)
+}
+
+// loadThemeFile reads a minimal TOML theme file: a "[colors]" table of
+// key = "value" pairs named after Theme's fields, lowercased. This is a
+// hand-rolled reader rather than a dependency on a TOML library, for the
+// same reason config.loadDotEnv hand-rolls its ".env" sidecar reader. It covers the
+// `key = "value"` and "# comment" forms a theme file actually needs; arrays,
+// inline tables, and multi-line strings aren't supported.
+func loadThemeFile(path string) (Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, err
+	}
+
+	var t Theme
+	fields := map[string]*string{
+		"header": &t.Header, "command": &t.Command, "desc": &t.Desc, "path": &t.Path,
+		"border": &t.Border, "cursor": &t.Cursor, "selected": &t.Selected, "dim": &t.Dim,
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		if dst, ok := fields[key]; ok {
+			*dst = value
+		}
+	}
+	return t, scanner.Err()
+}
@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gubarz/cheatmd/internal/config"
+)
+
+// themePickerModel is a small standalone Bubble Tea model for `cheatmd
+// themes`: arrow keys apply each candidate theme to the global styles
+// immediately (live preview), Enter picks the highlighted one, Esc restores
+// whatever theme was active when the picker started.
+type themePickerModel struct {
+	names    []string
+	cursor   int
+	original Theme
+	chosen   bool
+}
+
+func newThemePickerModel() themePickerModel {
+	return themePickerModel{
+		names:    availableThemes(),
+		original: LoadTheme(config.GetTheme()),
+	}
+}
+
+func (m themePickerModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m themePickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "ctrl+c", "esc":
+		styles.ApplyTheme(m.original)
+		return m, tea.Quit
+	case "enter":
+		m.chosen = true
+		return m, tea.Quit
+	case "up", "k":
+		m.cursor = clamp(m.cursor-1, 0, maxInt(0, len(m.names)-1))
+	case "down", "j":
+		m.cursor = clamp(m.cursor+1, 0, maxInt(0, len(m.names)-1))
+	}
+
+	if m.cursor < len(m.names) {
+		styles.ApplyTheme(LoadTheme(m.names[m.cursor]))
+	}
+	return m, nil
+}
+
+// View renders the theme list beside a static sample of list/preview
+// content, styled with the live-previewed theme so the effect of each
+// candidate is visible without leaving the picker.
+func (m themePickerModel) View() string {
+	var b strings.Builder
+	b.WriteString(styles.Header.Render("Select a theme"))
+	b.WriteString("\n")
+	b.WriteString(styles.Divider.Render(strings.Repeat("─", 40)))
+	b.WriteString("\n")
+
+	for i, name := range m.names {
+		if i == m.cursor {
+			b.WriteString(styles.WithSelection(styles.Cursor).Render("> " + name))
+		} else {
+			b.WriteString(styles.Dim.Render("  " + name))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(styles.PreviewHeader.Render("kubectl get pods"))
+	b.WriteString("\n")
+	b.WriteString(styles.PreviewDesc.Render("list pods in the current namespace"))
+	b.WriteString("\n")
+	b.WriteString(styles.PreviewCmd.Render("kubectl get pods -n $namespace"))
+	b.WriteString("\n")
+	b.WriteString(styles.PreviewPath.Render("k8s.md"))
+	b.WriteString("\n\n")
+	b.WriteString(styles.Dim.Render("↑/↓ preview · enter select · esc cancel"))
+
+	return b.String()
+}
+
+// selectedName returns the theme the user pressed Enter on, or "" if they
+// cancelled.
+func (m themePickerModel) selectedName() string {
+	if !m.chosen || m.cursor >= len(m.names) {
+		return ""
+	}
+	return m.names[m.cursor]
+}
+
+// RunThemePicker launches the live-preview theme picker and, if the user
+// confirms a theme with Enter, persists it via config.SetTheme. Returns an
+// error if there are no themes to choose from or the pick couldn't be
+// saved; cancelling with Esc is not an error.
+func RunThemePicker() error {
+	m := newThemePickerModel()
+	if len(m.names) == 0 {
+		return fmt.Errorf("no themes available")
+	}
+	styles.ApplyTheme(LoadTheme(m.names[m.cursor]))
+
+	finalModel, err := tea.NewProgram(m).Run()
+	if err != nil {
+		return err
+	}
+
+	result := finalModel.(themePickerModel)
+	if name := result.selectedName(); name != "" {
+		return config.SetTheme(name)
+	}
+	return nil
+}
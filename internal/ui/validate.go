@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/gubarz/cheatmd/internal/parser"
+)
+
+// errorStyle renders validateVarValue's rejection reason under the input -
+// deliberately not theme-driven (styles.go's StyleManager has no Error
+// color), since it should stay legible regardless of the configured theme.
+var errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Bold(true)
+
+// typedOptions returns the fixed candidate list a shell-less var.def.Type
+// should offer instead of free text: "yes"/"no" for bool, def.Choices for
+// enum. Returns nil for every other type, telling prepareCurrentVar to fall
+// back to its usual text prompt.
+func typedOptions(def parser.VarDef) []string {
+	switch strings.ToLower(def.Type) {
+	case "bool":
+		return []string{"yes", "no"}
+	case "enum":
+		return def.Choices
+	default:
+		return nil
+	}
+}
+
+// validateVarValue applies def.Type's validation rule plus def.Validate's
+// regex (checked regardless of type) to a value acceptVarValue is about to
+// accept, returning a normalized value (e.g. bool's "yes"/"no" become
+// "true"/"false") or an error describing why it was rejected. An empty value
+// falls back to def.Default before any of that runs.
+func validateVarValue(def parser.VarDef, value string) (string, error) {
+	if value == "" && def.Default != "" {
+		value = def.Default
+	}
+
+	switch strings.ToLower(def.Type) {
+	case "int":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return "", fmt.Errorf("%q is not an integer", value)
+		}
+		if def.Min != "" {
+			if min, err := strconv.Atoi(def.Min); err == nil && n < min {
+				return "", fmt.Errorf("%d is below the minimum of %d", n, min)
+			}
+		}
+		if def.Max != "" {
+			if max, err := strconv.Atoi(def.Max); err == nil && n > max {
+				return "", fmt.Errorf("%d is above the maximum of %d", n, max)
+			}
+		}
+	case "float":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf("%q is not a number", value)
+		}
+		if def.Min != "" {
+			if min, err := strconv.ParseFloat(def.Min, 64); err == nil && f < min {
+				return "", fmt.Errorf("%g is below the minimum of %g", f, min)
+			}
+		}
+		if def.Max != "" {
+			if max, err := strconv.ParseFloat(def.Max, 64); err == nil && f > max {
+				return "", fmt.Errorf("%g is above the maximum of %g", f, max)
+			}
+		}
+	case "bool":
+		switch strings.ToLower(value) {
+		case "y", "yes", "true", "1":
+			value = "true"
+		case "n", "no", "false", "0":
+			value = "false"
+		default:
+			return "", fmt.Errorf("%q is not yes/no", value)
+		}
+	case "enum":
+		if len(def.Choices) > 0 && !sliceContains(def.Choices, value) {
+			return "", fmt.Errorf("%q is not one of: %s", value, strings.Join(def.Choices, ", "))
+		}
+	case "path":
+		if _, err := os.Stat(value); err != nil {
+			return "", fmt.Errorf("%q does not exist", value)
+		}
+	case "file":
+		info, err := os.Stat(value)
+		if err != nil {
+			return "", fmt.Errorf("%q does not exist", value)
+		}
+		if info.IsDir() {
+			return "", fmt.Errorf("%q is a directory, expected a file", value)
+		}
+	case "dir":
+		info, err := os.Stat(value)
+		if err != nil {
+			return "", fmt.Errorf("%q does not exist", value)
+		}
+		if !info.IsDir() {
+			return "", fmt.Errorf("%q is a file, expected a directory", value)
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return "", fmt.Errorf("%q is not a date (want YYYY-MM-DD)", value)
+		}
+	}
+
+	// def.Validate applies on top of the type check above, regardless of
+	// type - this is what "type: regex" means in practice. An invalid
+	// pattern is the cheat author's bug, not the user's, so it's ignored
+	// rather than blocking input.
+	if def.Validate != "" {
+		if re, err := regexp.Compile(def.Validate); err == nil && !re.MatchString(value) {
+			return "", fmt.Errorf("%q doesn't match %s", value, def.Validate)
+		}
+	}
+
+	return value, nil
+}
+
+func sliceContains(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/gubarz/cheatmd/internal/parser"
+)
+
+func TestValidateVarValueTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		def     parser.VarDef
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "int ok", def: parser.VarDef{Type: "int"}, value: "42", want: "42"},
+		{name: "int not a number", def: parser.VarDef{Type: "int"}, value: "abc", wantErr: true},
+		{name: "int below min", def: parser.VarDef{Type: "int", Min: "10"}, value: "5", wantErr: true},
+		{name: "int above max", def: parser.VarDef{Type: "int", Max: "10"}, value: "11", wantErr: true},
+		{name: "int within range", def: parser.VarDef{Type: "int", Min: "1", Max: "10"}, value: "5", want: "5"},
+		{name: "bool yes", def: parser.VarDef{Type: "bool"}, value: "yes", want: "true"},
+		{name: "bool n", def: parser.VarDef{Type: "bool"}, value: "n", want: "false"},
+		{name: "bool invalid", def: parser.VarDef{Type: "bool"}, value: "maybe", wantErr: true},
+		{name: "enum allowed", def: parser.VarDef{Type: "enum", Choices: []string{"dev", "prod"}}, value: "dev", want: "dev"},
+		{name: "enum rejected", def: parser.VarDef{Type: "enum", Choices: []string{"dev", "prod"}}, value: "staging", wantErr: true},
+		{name: "default fills empty", def: parser.VarDef{Default: "fallback"}, value: "", want: "fallback"},
+		{name: "validate pattern matches", def: parser.VarDef{Validate: `^\d+$`}, value: "123", want: "123"},
+		{name: "validate pattern mismatch", def: parser.VarDef{Validate: `^\d+$`}, value: "abc", wantErr: true},
+		{name: "date ok", def: parser.VarDef{Type: "date"}, value: "2026-07-30", want: "2026-07-30"},
+		{name: "date invalid", def: parser.VarDef{Type: "date"}, value: "07/30/2026", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validateVarValue(tt.def, tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("validateVarValue(%+v, %q) = %q, want error", tt.def, tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateVarValue(%+v, %q) unexpected error: %v", tt.def, tt.value, err)
+			}
+			if got != tt.want {
+				t.Fatalf("validateVarValue(%+v, %q) = %q, want %q", tt.def, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTypedOptions(t *testing.T) {
+	if got := typedOptions(parser.VarDef{Type: "bool"}); len(got) != 2 {
+		t.Fatalf("typedOptions(bool) = %v, want [yes no]", got)
+	}
+	if got := typedOptions(parser.VarDef{Type: "enum", Choices: []string{"a", "b"}}); len(got) != 2 {
+		t.Fatalf("typedOptions(enum) = %v, want [a b]", got)
+	}
+	if got := typedOptions(parser.VarDef{Type: "string"}); got != nil {
+		t.Fatalf("typedOptions(string) = %v, want nil", got)
+	}
+}
@@ -2,22 +2,43 @@ package ui
 
 import (
 	"os/exec"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/gubarz/cheatmd/internal/config"
 )
 
+// previewDebounce is how long the cursor must sit still on a candidate
+// before selectOpts.PreviewCmd runs for it, so fast arrow-key navigation
+// doesn't spawn a process per keystroke.
+const previewDebounce = 80 * time.Millisecond
+
+// previewOutputCap bounds how much of a PreviewCmd's stdout is kept, so a
+// misbehaving preview command (e.g. one that dumps a whole log file) can't
+// blow up memory or rendering.
+const previewOutputCap = 64 * 1024
+
 // ============================================================================
 // Variable Select Model - For selecting from a list of options
 // ============================================================================
 
 // SelectOptions holds display options for selection
 type SelectOptions struct {
-	Delimiter string
-	Column    int    // 1-indexed, 0 = all
-	MapCmd    string // command to transform selected value
+	Delimiter  string
+	Column     int    // 1-indexed, 0 = all
+	MapCmd     string // command to transform selected value
+	PreviewCmd string // --preview: external command rendering a third preview pane
+	Algorithm  Algorithm
+	Source     string // raw command template the variable belongs to, for the preview pane
+	Language   string // highlightSource language for Source, "" defaults to shell
+	Multi      bool   // toggle-select several options (Tab/Ctrl+Space) instead of picking one
+	MultiMax   int    // --multi=N: caps how many picks Multi allows, 0 means unbounded
+	Joiner     string // joins multi picks into the returned value, default " "
+	Reload     bool   // --reload: re-run the shell command on query change instead of client-side filtering
 }
 
 // varSelectModel is for selecting from a list of options
@@ -29,6 +50,7 @@ type varSelectModel struct {
 	displayOpts  []string // what to display (may be transformed by delimiter/column)
 	filtered     []filteredOption
 	cursor       int
+	offset       int // renderList's scrollWindow state, keeping the cursor in view
 	textInput    textinput.Model
 	width        int
 	height       int
@@ -36,12 +58,32 @@ type varSelectModel struct {
 	cancelled    bool
 	selectOpts   SelectOptions
 	filePath     string // source file for opening with ctrl+o
+
+	cmdLine       string // header's first line: cmd with this var as the styled "$name" token
+	showPreview   bool
+	previewScroll int
+	mappedCache   map[string]string // candidate -> MapCmd output
+	previewCache  map[string]string // candidate -> PreviewCmd output
+	previewGen    int               // bumped each time the debounce timer restarts, invalidating stale ticks
+
+	picked map[string]bool // selectOpts.Multi: original -> toggled on
+	order  []string        // selectOpts.Multi: picks in toggle order
+}
+
+// previewTickMsg fires previewDebounce after the cursor settles on
+// candidate; gen must still match m.previewGen for the preview to run, so a
+// cursor move in the meantime discards it.
+type previewTickMsg struct {
+	gen       int
+	candidate string
 }
 
 // filteredOption pairs display text with original value
 type filteredOption struct {
-	display  string
-	original string
+	display   string
+	original  string
+	score     int   // match score from scoreAndFilterOptions, higher is better
+	positions []int // matched rune indices into display, for highlightMatches
 }
 
 // newVarSelectModel creates a new variable selection model
@@ -79,6 +121,12 @@ func newVarSelectModelWithOpts(varName string, options []string, header, customH
 		textInput:    ti,
 		selectOpts:   opts,
 		filePath:     filePath,
+
+		cmdLine:      strings.SplitN(header, "\n", 2)[0],
+		showPreview:  true,
+		mappedCache:  make(map[string]string),
+		previewCache: make(map[string]string),
+		picked:       make(map[string]bool),
 	}
 }
 
@@ -110,13 +158,41 @@ func (m varSelectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.textInput.Width = msg.Width - 4
+	case previewTickMsg:
+		if msg.gen == m.previewGen {
+			m.previewCache[msg.candidate] = runPreviewCmd(m.selectOpts.PreviewCmd, msg.candidate)
+		}
+		return m, nil
 	}
 
 	var cmd tea.Cmd
 	m.textInput, cmd = m.textInput.Update(msg)
 	m.filterOptions()
 
-	return m, cmd
+	return m, tea.Batch(cmd, m.schedulePreview())
+}
+
+// schedulePreview (re)starts the debounce timer for the currently-highlighted
+// candidate when selectOpts.PreviewCmd is set and its output isn't cached
+// yet. Each call bumps previewGen, so a timer already in flight for a
+// candidate the cursor has since left fires but is discarded as stale.
+func (m *varSelectModel) schedulePreview() tea.Cmd {
+	if m.selectOpts.PreviewCmd == "" {
+		return nil
+	}
+	candidate := m.currentCandidate()
+	if candidate == "" {
+		return nil
+	}
+	if _, cached := m.previewCache[candidate]; cached {
+		return nil
+	}
+
+	m.previewGen++
+	gen := m.previewGen
+	return tea.Tick(previewDebounce, func(time.Time) tea.Msg {
+		return previewTickMsg{gen: gen, candidate: candidate}
+	})
 }
 
 // handleKeyPress processes keyboard input
@@ -130,18 +206,36 @@ func (m *varSelectModel) handleKeyPress(msg tea.KeyMsg) tea.Cmd {
 		m.cancelled = true
 		return tea.Quit
 	case "enter":
-		if m.cursor < len(m.filtered) {
+		if m.selectOpts.Multi {
+			m.selected = m.joinedValue()
+		} else if m.cursor < len(m.filtered) {
 			m.selected = m.filtered[m.cursor].original // Return original value, not display
 		} else {
 			m.selected = m.textInput.Value()
 		}
 		return tea.Quit
-	case "up", "ctrl+p":
+	case "up":
 		m.moveCursor(-1)
-	case "down", "ctrl+n":
+	case "down":
 		m.moveCursor(1)
-	case "tab":
-		if m.cursor < len(m.filtered) {
+	case "ctrl+p":
+		if m.showPreview && m.selectOpts.PreviewCmd != "" {
+			m.previewScroll = maxInt(0, m.previewScroll-1)
+		} else {
+			m.moveCursor(-1)
+		}
+	case "ctrl+n":
+		if m.showPreview && m.selectOpts.PreviewCmd != "" {
+			m.previewScroll++
+		} else {
+			m.moveCursor(1)
+		}
+	case "ctrl+/":
+		m.showPreview = !m.showPreview
+	case "tab", "ctrl+@":
+		if m.selectOpts.Multi {
+			m.toggleCursor()
+		} else if m.cursor < len(m.filtered) {
 			m.textInput.SetValue(m.filtered[m.cursor].display)
 		}
 	case "ctrl+o":
@@ -156,11 +250,54 @@ func (m *varSelectModel) handleKeyPress(msg tea.KeyMsg) tea.Cmd {
 func (m *varSelectModel) moveCursor(delta int) {
 	m.cursor += delta
 	m.cursor = clamp(m.cursor, 0, maxInt(0, len(m.filtered)-1))
+	m.previewScroll = 0
+}
+
+// toggleCursor toggles the highlighted option on or off for selectOpts.Multi
+func (m *varSelectModel) toggleCursor() {
+	if m.cursor >= len(m.filtered) {
+		return
+	}
+	value := m.filtered[m.cursor].original
+
+	if m.picked[value] {
+		delete(m.picked, value)
+		for i, v := range m.order {
+			if v == value {
+				m.order = append(m.order[:i], m.order[i+1:]...)
+				break
+			}
+		}
+		return
+	}
+
+	m.picked[value] = true
+	m.order = append(m.order, value)
+}
+
+// joinedValue returns the Multi picks, in toggle order, joined by
+// selectOpts.Joiner (default " "). If nothing was toggled, it falls back to
+// the highlighted (or typed) value so Enter still works without Tab first.
+func (m *varSelectModel) joinedValue() string {
+	picks := m.order
+	if len(picks) == 0 {
+		if m.cursor < len(m.filtered) {
+			picks = []string{m.filtered[m.cursor].original}
+		} else if v := m.textInput.Value(); v != "" {
+			picks = []string{v}
+		}
+	}
+
+	joiner := m.selectOpts.Joiner
+	if joiner == "" {
+		joiner = " "
+	}
+	return strings.Join(picks, joiner)
 }
 
 // filterOptions filters options based on the input query
 func (m *varSelectModel) filterOptions() {
-	query := strings.TrimSpace(strings.ToLower(m.textInput.Value()))
+	query := strings.TrimSpace(m.textInput.Value())
 
 	if query == "" {
 		// No filter - show all options
@@ -172,18 +309,7 @@ func (m *varSelectModel) filterOptions() {
 			}
 		}
 	} else {
-		words := strings.Fields(query)
-		m.filtered = make([]filteredOption, 0, len(m.options))
-		for _, opt := range m.options {
-			display := getDisplayColumn(opt, m.selectOpts.Delimiter, m.selectOpts.Column)
-			// Match against both display and original
-			if matchesAllWords(strings.ToLower(display), words) || matchesAllWords(strings.ToLower(opt), words) {
-				m.filtered = append(m.filtered, filteredOption{
-					display:  display,
-					original: opt,
-				})
-			}
-		}
+		m.filtered = scoreAndFilterOptions(m.options, query, m.selectOpts)
 	}
 
 	m.cursor = clamp(m.cursor, 0, maxInt(0, len(m.filtered)-1))
@@ -218,41 +344,385 @@ func (m varSelectModel) renderHeader() string {
 	b.WriteString(styles.Divider.Render(strings.Repeat("─", width)))
 	b.WriteString("\n")
 
+	hints := " • Ctrl+O open • Ctrl+/ preview • ESC back • Enter select"
+	if m.selectOpts.Multi {
+		hints = " • Tab toggle (" + strconv.Itoa(len(m.order)) + " picked)" + hints
+	}
+
 	if m.customHeader != "" {
 		b.WriteString(styles.Cursor.Render(m.customHeader))
-		b.WriteString(styles.Dim.Render(" • Ctrl+O open • ESC back • Enter select"))
+		b.WriteString(styles.Dim.Render(hints))
 	} else {
 		b.WriteString(styles.Dim.Render("Select value for "))
 		b.WriteString(styles.Cursor.Render("$" + m.varName))
-		b.WriteString(styles.Dim.Render(" • Ctrl+O open • ESC back • Enter select"))
+		b.WriteString(styles.Dim.Render(hints))
 	}
 
 	return b.String()
 }
 
-// renderBottom renders the options list and input
+// renderBottom renders the options list, an optional preview panel beside it,
+// and the input footer.
 func (m varSelectModel) renderBottom(width int) string {
 	var b strings.Builder
 	b.WriteString(styles.Divider.Render(strings.Repeat("─", width)))
 	b.WriteString("\n")
 
-	// Options list
+	if m.showPreview {
+		listWidth, previewWidth := previewSplit(width)
+		listHeight := minInt(10, maxInt(len(m.filtered), 1))
+		divider := styles.Divider.Render(strings.TrimSuffix(strings.Repeat("│\n", listHeight), "\n"))
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top,
+			m.renderList(listWidth),
+			divider,
+			m.renderPreviewPane(previewWidth),
+		))
+	} else {
+		b.WriteString(m.renderList(width))
+	}
+	b.WriteString("\n")
+
+	// Footer
+	b.WriteString(styles.Divider.Render(strings.Repeat("─", width)))
+	b.WriteString("\n")
+	b.WriteString(m.textInput.View())
+
+	return b.String()
+}
+
+// renderList renders the scrollable candidate list.
+func (m varSelectModel) renderList(width int) string {
+	var b strings.Builder
 	listHeight := minInt(10, len(m.filtered))
-	start, end := scrollWindow(m.cursor, len(m.filtered), listHeight)
+	start, end := scrollWindow(m.cursor, len(m.filtered), listHeight, &m.offset)
 
 	for i := start; i < end; i++ {
 		opt := m.filtered[i]
+		mark := ""
+		if m.selectOpts.Multi {
+			mark = "☐ "
+			if m.picked[opt.original] {
+				mark = "☑ "
+			}
+		}
 		if i == m.cursor {
-			b.WriteString(styles.Cursor.Render("▶ "))
-			b.WriteString(styles.Selected.Render(styles.Command.Render(opt.display)))
+			b.WriteString(styles.Cursor.Render("▶ " + mark))
+			b.WriteString(styles.Selected.Render(highlightMatches(opt.display, opt.positions, styles.Command)))
 		} else {
-			b.WriteString("  ")
-			b.WriteString(styles.Command.Render(opt.display))
+			b.WriteString("  " + mark)
+			b.WriteString(highlightMatches(opt.display, opt.positions, styles.Command))
+		}
+		b.WriteString("\n")
+	}
+
+	return lipgloss.NewStyle().Width(width).Render(b.String())
+}
+
+// currentCandidate returns the original value of the highlighted option, or
+// "" if nothing is highlighted (empty filter results).
+func (m varSelectModel) currentCandidate() string {
+	if m.cursor < 0 || m.cursor >= len(m.filtered) {
+		return ""
+	}
+	return m.filtered[m.cursor].original
+}
+
+// mappedValue applies selectOpts.MapCmd to candidate, caching per candidate
+// since it shells out.
+func (m varSelectModel) mappedValue(candidate string) string {
+	if m.selectOpts.MapCmd == "" {
+		return candidate
+	}
+	if v, ok := m.mappedCache[candidate]; ok {
+		return v
+	}
+	v := applyMapTransformCmd(candidate, m.selectOpts.MapCmd)
+	m.mappedCache[candidate] = v
+	return v
+}
+
+// renderPreviewPane renders the right-hand preview panel: the final command
+// with the highlighted candidate (and --map, if set) substituted into
+// "$varName", plus the --preview command's output below it when configured.
+func (m varSelectModel) renderPreviewPane(width int) string {
+	var b strings.Builder
+	b.WriteString(styles.PreviewHeader.Render("Preview"))
+	b.WriteString(" ")
+	b.WriteString(styles.Dim.Render("(ctrl+/ toggle)"))
+	b.WriteString("\n")
+
+	candidate := m.currentCandidate()
+	if m.selectOpts.Source != "" {
+		rendered := replaceVar(m.selectOpts.Source, m.varName, m.mappedValue(candidate))
+		b.WriteString(highlightSource(rendered, m.selectOpts.Language))
+		b.WriteString("\n")
+	} else if m.cmdLine != "" {
+		rendered := m.cmdLine
+		if token := currentVarToken.FindString(rendered); token != "" {
+			rendered = strings.Replace(rendered, token, styles.Header.Render(m.mappedValue(candidate)), 1)
+		}
+		b.WriteString(rendered)
+		b.WriteString("\n")
+	}
+
+	if m.selectOpts.PreviewCmd != "" {
+		output, ready := m.previewCache[candidate]
+		if !ready {
+			output = "Loading preview..."
+		}
+
+		lines := strings.Split(output, "\n")
+		previewHeight := 8
+		start := clamp(m.previewScroll, 0, maxInt(0, len(lines)-1))
+		end := minInt(len(lines), start+previewHeight)
+
+		var body strings.Builder
+		for _, line := range lines[start:end] {
+			body.WriteString(styles.PreviewCmd.Render(line))
+			body.WriteString("\n")
+		}
+
+		b.WriteString(styles.Border.Width(maxInt(width-2, 1)).Render(strings.TrimSuffix(body.String(), "\n")))
+		b.WriteString("\n")
+	}
+
+	return lipgloss.NewStyle().Width(width).Render(b.String())
+}
+
+// ============================================================================
+// Variable Multi-Select Model - For picking several options at once
+// ============================================================================
+
+// varMultiSelectModel is for selecting zero or more options from a list,
+// toggled with tab/space and confirmed with enter. It mirrors varSelectModel
+// closely, swapping the single m.selected string for an ordered set of
+// picks (m.order) so the final value preserves toggle order rather than
+// list order.
+type varMultiSelectModel struct {
+	varName      string
+	header       string
+	customHeader string
+	options      []string
+	filtered     []filteredOption
+	cursor       int
+	offset       int // renderBottom's scrollWindow state, keeping the cursor in view
+	textInput    textinput.Model
+	width        int
+	height       int
+	picked       map[string]bool
+	order        []string // picks in the order they were toggled on
+	minSelect    int
+	maxSelect    int // 0 means unbounded
+	separator    string
+	confirmed    bool
+	cancelled    bool
+	selectOpts   SelectOptions
+	filePath     string
+}
+
+// newVarMultiSelectModel creates a new multi-select model
+func newVarMultiSelectModel(varName string, options []string, header, customHeader, filePath string, opts SelectOptions, minSelect, maxSelect int, separator string) varMultiSelectModel {
+	ti := textinput.New()
+	ti.Placeholder = "Type to filter..."
+	ti.Focus()
+	ti.CharLimit = 512
+	ti.Width = 60
+
+	filtered := make([]filteredOption, len(options))
+	for i, opt := range options {
+		filtered[i] = filteredOption{
+			display:  getDisplayColumn(opt, opts.Delimiter, opts.Column),
+			original: opt,
+		}
+	}
+
+	return varMultiSelectModel{
+		varName:      varName,
+		header:       header,
+		customHeader: customHeader,
+		options:      options,
+		filtered:     filtered,
+		textInput:    ti,
+		picked:       make(map[string]bool),
+		minSelect:    minSelect,
+		maxSelect:    maxSelect,
+		separator:    separator,
+		selectOpts:   opts,
+		filePath:     filePath,
+	}
+}
+
+// Init implements tea.Model
+func (m varMultiSelectModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Update implements tea.Model
+func (m varMultiSelectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if cmd := m.handleKeyPress(msg); cmd != nil {
+			return m, cmd
+		}
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.textInput.Width = msg.Width - 4
+	}
+
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	m.filterOptions()
+
+	return m, cmd
+}
+
+// handleKeyPress processes keyboard input
+func (m *varMultiSelectModel) handleKeyPress(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "ctrl+c":
+		m.cancelled = true
+		return tea.Quit
+	case "esc":
+		m.cancelled = true
+		return tea.Quit
+	case "enter":
+		if len(m.order) < m.minSelect {
+			return nil // not enough picks yet - ignore confirm
+		}
+		m.confirmed = true
+		return tea.Quit
+	case "tab", " ":
+		m.toggleCursor()
+	case "up", "ctrl+p":
+		m.moveCursor(-1)
+	case "down", "ctrl+n":
+		m.moveCursor(1)
+	case "ctrl+o":
+		if m.filePath != "" {
+			openFileInViewer(m.filePath)
+		}
+	}
+	return nil
+}
+
+// toggleCursor toggles the highlighted option on or off, refusing to add a
+// pick past maxSelect (0 means unbounded)
+func (m *varMultiSelectModel) toggleCursor() {
+	if m.cursor >= len(m.filtered) {
+		return
+	}
+	value := m.filtered[m.cursor].original
+
+	if m.picked[value] {
+		delete(m.picked, value)
+		for i, v := range m.order {
+			if v == value {
+				m.order = append(m.order[:i], m.order[i+1:]...)
+				break
+			}
+		}
+		return
+	}
+
+	if m.maxSelect > 0 && len(m.order) >= m.maxSelect {
+		return
+	}
+	m.picked[value] = true
+	m.order = append(m.order, value)
+}
+
+// moveCursor moves the cursor by delta
+func (m *varMultiSelectModel) moveCursor(delta int) {
+	m.cursor += delta
+	m.cursor = clamp(m.cursor, 0, maxInt(0, len(m.filtered)-1))
+}
+
+// filterOptions filters options based on the input query
+func (m *varMultiSelectModel) filterOptions() {
+	query := strings.TrimSpace(m.textInput.Value())
+
+	if query == "" {
+		m.filtered = make([]filteredOption, len(m.options))
+		for i, opt := range m.options {
+			m.filtered[i] = filteredOption{
+				display:  getDisplayColumn(opt, m.selectOpts.Delimiter, m.selectOpts.Column),
+				original: opt,
+			}
+		}
+	} else {
+		m.filtered = scoreAndFilterOptions(m.options, query, m.selectOpts)
+	}
+
+	m.cursor = clamp(m.cursor, 0, maxInt(0, len(m.filtered)-1))
+}
+
+// View implements tea.Model
+func (m varMultiSelectModel) View() string {
+	width := maxInt(m.width, 80)
+	height := maxInt(m.height, 24)
+
+	header := m.renderHeader()
+	bottom := m.renderBottom(width)
+
+	headerLines := countLines(header)
+	bottomLines := countLines(bottom)
+	spacing := maxInt(height-headerLines-bottomLines, 0)
+
+	var b strings.Builder
+	b.WriteString(header)
+	b.WriteString(strings.Repeat("\n", spacing))
+	b.WriteString(bottom)
+
+	return b.String()
+}
+
+// renderHeader renders the header section
+func (m varMultiSelectModel) renderHeader() string {
+	width := maxInt(m.width, 80)
+	var b strings.Builder
+	b.WriteString(m.header)
+	b.WriteString("\n")
+	b.WriteString(styles.Divider.Render(strings.Repeat("─", width)))
+	b.WriteString("\n")
+
+	label := m.customHeader
+	if label == "" {
+		label = "Select values for $" + m.varName
+	}
+	b.WriteString(styles.Cursor.Render(label))
+	b.WriteString(" ")
+	b.WriteString(styles.Dim.Render("(" + strconv.Itoa(len(m.order)) + " picked)"))
+	b.WriteString(styles.Dim.Render(" • Tab/Space toggle • Ctrl+O open • ESC back • Enter confirm"))
+
+	return b.String()
+}
+
+// renderBottom renders the options list and input
+func (m varMultiSelectModel) renderBottom(width int) string {
+	var b strings.Builder
+	b.WriteString(styles.Divider.Render(strings.Repeat("─", width)))
+	b.WriteString("\n")
+
+	listHeight := minInt(10, len(m.filtered))
+	start, end := scrollWindow(m.cursor, len(m.filtered), listHeight, &m.offset)
+
+	for i := start; i < end; i++ {
+		opt := m.filtered[i]
+		mark := "☐"
+		if m.picked[opt.original] {
+			mark = "☑"
+		}
+		if i == m.cursor {
+			b.WriteString(styles.Cursor.Render("▶ " + mark + " "))
+			b.WriteString(styles.Selected.Render(highlightMatches(opt.display, opt.positions, styles.Command)))
+		} else {
+			b.WriteString("  " + mark + " ")
+			b.WriteString(highlightMatches(opt.display, opt.positions, styles.Command))
 		}
 		b.WriteString("\n")
 	}
 
-	// Footer
 	b.WriteString(styles.Divider.Render(strings.Repeat("─", width)))
 	b.WriteString("\n")
 	b.WriteString(m.textInput.View())
@@ -260,6 +730,11 @@ func (m varSelectModel) renderBottom(width int) string {
 	return b.String()
 }
 
+// value joins the picks (in toggle order) with the configured separator
+func (m varMultiSelectModel) value() string {
+	return strings.Join(m.order, m.separator)
+}
+
 // ============================================================================
 // Variable Input Model - For entering a custom value
 // ============================================================================
@@ -275,10 +750,17 @@ type varInputModel struct {
 	value        string
 	cancelled    bool
 	filePath     string // source file for opening with ctrl+o
+	selectOpts   SelectOptions
+	showPreview  bool
 }
 
 // newVarInputModel creates a new variable input model
 func newVarInputModel(varName, header, customHeader, prefill, filePath string) varInputModel {
+	return newVarInputModelWithOpts(varName, header, customHeader, prefill, filePath, SelectOptions{})
+}
+
+// newVarInputModelWithOpts creates a variable input model with preview options
+func newVarInputModelWithOpts(varName, header, customHeader, prefill, filePath string, opts SelectOptions) varInputModel {
 	ti := textinput.New()
 	ti.Placeholder = "Enter value..."
 	ti.Focus()
@@ -295,6 +777,8 @@ func newVarInputModel(varName, header, customHeader, prefill, filePath string) v
 		customHeader: customHeader,
 		textInput:    ti,
 		filePath:     filePath,
+		selectOpts:   opts,
+		showPreview:  true,
 	}
 }
 
@@ -338,6 +822,8 @@ func (m *varInputModel) handleKeyPress(msg tea.KeyMsg) tea.Cmd {
 		if m.filePath != "" {
 			openFileInViewer(m.filePath)
 		}
+	case "ctrl+/":
+		m.showPreview = !m.showPreview
 	}
 	return nil
 }
@@ -373,25 +859,61 @@ func (m varInputModel) renderHeader() string {
 
 	if m.customHeader != "" {
 		b.WriteString(styles.Cursor.Render(m.customHeader))
-		b.WriteString(styles.Dim.Render(" • Ctrl+O open • ESC back • Enter confirm"))
+		b.WriteString(styles.Dim.Render(" • Ctrl+O open • Ctrl+/ preview • ESC back • Enter confirm"))
 	} else {
 		b.WriteString(styles.Dim.Render("Enter value for "))
 		b.WriteString(styles.Cursor.Render("$" + m.varName))
-		b.WriteString(styles.Dim.Render(" • Ctrl+O open • ESC back • Enter confirm"))
+		b.WriteString(styles.Dim.Render(" • Ctrl+O open • Ctrl+/ preview • ESC back • Enter confirm"))
 	}
 
 	return b.String()
 }
 
-// renderBottom renders the input section
+// renderBottom renders the input section, and an optional right-hand preview
+// panel showing the command the value belongs to.
 func (m varInputModel) renderBottom(width int) string {
 	var b strings.Builder
 	b.WriteString(styles.Divider.Render(strings.Repeat("─", width)))
 	b.WriteString("\n")
-	b.WriteString(m.textInput.View())
+
+	if m.showPreview && m.selectOpts.Source != "" {
+		inputWidth, previewWidth := previewSplit(width)
+		divider := styles.Divider.Render("│")
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top,
+			lipgloss.NewStyle().Width(inputWidth).Render(m.textInput.View()),
+			divider,
+			m.renderPreviewPane(previewWidth),
+		))
+	} else {
+		b.WriteString(m.textInput.View())
+	}
+
 	return b.String()
 }
 
+// renderPreviewPane renders the right-hand preview panel: the command the
+// value belongs to, with "$varName" substituted live from the current
+// textInput.Value() and shell syntax highlighting applied.
+func (m varInputModel) renderPreviewPane(width int) string {
+	var b strings.Builder
+	b.WriteString(styles.PreviewHeader.Render("Preview"))
+	b.WriteString(" ")
+	b.WriteString(styles.Dim.Render("(ctrl+/ toggle)"))
+	b.WriteString("\n")
+
+	value := m.textInput.Value()
+	if value == "" {
+		value = styles.Cursor.Render("$" + m.varName)
+	} else {
+		value = styles.Header.Render(value)
+	}
+	rendered := replaceVar(m.selectOpts.Source, m.varName, value)
+	b.WriteString(highlightSource(rendered, m.selectOpts.Language))
+	b.WriteString("\n")
+
+	return lipgloss.NewStyle().Width(width).Render(b.String())
+}
+
 // ============================================================================
 // Public API for Variable Resolution
 // ============================================================================
@@ -410,9 +932,13 @@ func SelectWithTUIOptions(varName string, options []string, header, customHeader
 	defer cleanup()
 
 	selectOpts := SelectOptions{
-		Delimiter: opts.delimiter,
-		Column:    opts.column,
-		MapCmd:    opts.mapCmd,
+		Delimiter:  opts.delimiter,
+		Column:     opts.column,
+		MapCmd:     opts.mapCmd,
+		PreviewCmd: opts.previewCmd,
+		Algorithm:  Algorithm(opts.algorithm),
+		Source:     opts.source,
+		Language:   opts.language,
 	}
 
 	m := newVarSelectModelWithOpts(varName, options, header, customHeader, prefill, filePath, selectOpts)
@@ -433,10 +959,10 @@ func SelectWithTUIOptions(varName string, options []string, header, customHeader
 
 	// Apply select-column extraction if specified
 	selected := result.selected
-	if opts.selectColumn > 0 && opts.delimiter != "" {
+	if opts.column > 0 && opts.delimiter != "" {
 		parts := strings.Split(selected, opts.delimiter)
-		if opts.selectColumn <= len(parts) {
-			selected = strings.TrimSpace(parts[opts.selectColumn-1])
+		if opts.column <= len(parts) {
+			selected = strings.TrimSpace(parts[opts.column-1])
 		}
 	}
 
@@ -448,6 +974,97 @@ func SelectWithTUIOptions(varName string, options []string, header, customHeader
 	return selected, false, nil
 }
 
+// SelectMultiWithTUI displays options with varSelectModel's Tab-toggle Multi
+// mode and returns the toggled picks joined by joiner (default " "). Unlike
+// SelectWithTUIOptions, which threads DSL selectorOptions through, this is
+// meant for callers outside the cheat DSL - e.g. the parser/executor layer
+// resolving a $hosts or $files variable it has already declared as
+// multi-valued - so it takes its joiner directly instead of a selectorOptions.
+// Returns (value, goBack, error) - if value is "__EXIT__" caller should exit completely
+func SelectMultiWithTUI(varName string, options []string, header, customHeader, prefill, filePath, joiner string) (string, bool, error) {
+	ttyIn, ttyOut, cleanup := getTTY()
+	RefreshStyles() // Refresh after getTTY sets up the renderer
+	defer cleanup()
+
+	selectOpts := SelectOptions{Multi: true, Joiner: joiner}
+	m := newVarSelectModelWithOpts(varName, options, header, customHeader, prefill, filePath, selectOpts)
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithOutput(ttyOut), tea.WithInput(ttyIn))
+
+	finalModel, err := p.Run()
+	if err != nil {
+		return "", false, err
+	}
+
+	result := finalModel.(varSelectModel)
+	if result.selected == "__EXIT__" {
+		return "__EXIT__", false, nil
+	}
+	if result.cancelled {
+		return "", true, nil
+	}
+
+	return result.selected, false, nil
+}
+
+// MultiSelectWithTUI displays options for a --multi variable and lets the
+// user toggle several on before confirming.
+// Returns (value, goBack, error); value is the picks joined by opts.separator.
+// Ctrl+C and Esc both come back as goBack; "__EXIT__" is never returned.
+func MultiSelectWithTUI(varName string, options []string, header, customHeader string, opts selectorOptions) (string, bool, error) {
+	ttyIn, ttyOut, cleanup := getTTY()
+	RefreshStyles() // Refresh after getTTY sets up the renderer
+	defer cleanup()
+
+	selectOpts := SelectOptions{
+		Delimiter: opts.delimiter,
+		Column:    opts.column,
+		MapCmd:    opts.mapCmd,
+		Algorithm: Algorithm(opts.algorithm),
+	}
+
+	m := newVarMultiSelectModel(varName, options, header, customHeader, "", selectOpts, opts.minSelect, opts.maxSelect, opts.separator)
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithOutput(ttyOut), tea.WithInput(ttyIn))
+
+	finalModel, err := p.Run()
+	if err != nil {
+		return "", false, err
+	}
+
+	result := finalModel.(varMultiSelectModel)
+	if result.cancelled {
+		return "", true, nil
+	}
+	if !result.confirmed {
+		return "", true, nil
+	}
+
+	value := result.value()
+	if opts.mapCmd != "" {
+		picks := strings.Split(value, opts.separator)
+		mapped := make([]string, len(picks))
+		for i, p := range picks {
+			mapped[i] = applyMapTransformCmd(p, opts.mapCmd)
+		}
+		value = strings.Join(mapped, opts.separator)
+	}
+
+	return value, false, nil
+}
+
+// runPreviewCmd runs a --preview command for candidate, fzf-style: the "{}"
+// placeholder is replaced with the shell-quoted candidate, which is also fed
+// on stdin so either form works.
+func runPreviewCmd(previewCmd, candidate string) string {
+	rendered := strings.ReplaceAll(previewCmd, "{}", shellQuote(candidate))
+	cmd := exec.Command(config.GetShell(), "-c", rendered)
+	cmd.Stdin = strings.NewReader(candidate)
+	out, _ := cmd.CombinedOutput()
+	if len(out) > previewOutputCap {
+		out = out[:previewOutputCap]
+	}
+	return strings.TrimRight(string(out), "\n")
+}
+
 // applyMapTransformCmd runs the map command on the selected value
 func applyMapTransformCmd(value, mapCmd string) string {
 	if mapCmd == "" {
@@ -465,11 +1082,18 @@ func applyMapTransformCmd(value, mapCmd string) string {
 // PromptWithTUI displays an input prompt for variable entry
 // Returns (value, goBack, error) - if value is "__EXIT__" caller should exit completely
 func PromptWithTUI(varName, header, customHeader, prefill, filePath string) (string, bool, error) {
+	return PromptWithTUIOptions(varName, header, customHeader, prefill, filePath, SelectOptions{})
+}
+
+// PromptWithTUIOptions displays an input prompt for variable entry with a
+// preview pane driven by opts.Source/opts.Language.
+// Returns (value, goBack, error) - if value is "__EXIT__" caller should exit completely
+func PromptWithTUIOptions(varName, header, customHeader, prefill, filePath string, opts SelectOptions) (string, bool, error) {
 	ttyIn, ttyOut, cleanup := getTTY()
 	RefreshStyles() // Refresh after getTTY sets up the renderer
 	defer cleanup()
 
-	m := newVarInputModel(varName, header, customHeader, prefill, filePath)
+	m := newVarInputModelWithOpts(varName, header, customHeader, prefill, filePath, opts)
 	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithOutput(ttyOut), tea.WithInput(ttyIn))
 
 	finalModel, err := p.Run()
@@ -491,6 +1115,17 @@ func PromptWithTUI(varName, header, customHeader, prefill, filePath string) (str
 // Additional Helpers
 // ============================================================================
 
+// previewSplit divides width between the candidate list (or input) and the
+// preview pane, leaving one column for the divider between them. The
+// preview's share is config.GetPreviewWidth (a percentage), clamped so
+// neither side collapses to nothing.
+func previewSplit(width int) (primary, preview int) {
+	ratio := clamp(config.GetPreviewWidth(), 10, 90)
+	preview = (width - 1) * ratio / 100
+	primary = width - 1 - preview
+	return primary, preview
+}
+
 // minInt returns the smaller of a and b
 func minInt(a, b int) int {
 	if a < b {